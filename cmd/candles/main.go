@@ -0,0 +1,39 @@
+// Command candles rebuilds the candle store from all the trades into a new store and
+// swaps it in, the gateways keep writing the new candles while it runs
+package main
+
+import (
+	"log"
+	"os"
+
+	_candleSvc "gateway/internal/candle/service"
+	"gateway/internal/repositories"
+	"gateway/pkg/mongo"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println(".env not found, will use host environment variables")
+	}
+
+	utils.InitLogger()
+
+	mongoConn, err := mongo.InitConnection(os.Getenv("MONGO_URL"))
+	if err != nil {
+		logs.Log.Fatal().Err(err).Msg("failed to connect with mongo")
+	}
+
+	tradeRepo := repositories.NewTradeRepository(mongoConn)
+	candleRepo := repositories.NewCandleRepository(mongoConn)
+	leaseRepo := repositories.NewLeaseRepository(mongoConn)
+
+	if err := _candleSvc.NewCandleService(tradeRepo, candleRepo, leaseRepo).Rebuild(); err != nil {
+		logs.Log.Fatal().Err(err).Msg("failed to rebuild the candle store")
+	}
+
+	logs.Log.Info().Msg("candle store rebuilt")
+}
@@ -30,6 +30,7 @@ require (
 	github.com/swaggo/swag v1.16.1
 	github.com/ulule/limiter/v3 v3.11.2
 	go.mongodb.org/mongo-driver v1.11.6
+	google.golang.org/grpc v1.56.2
 )
 
 require (
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	_engineType "gateway/internal/engine/types"
+	"gateway/internal/repositories"
+	"gateway/pkg/candle"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the backfill aggregates the trades a week at a time
+const backfillRange = 7 * 24 * time.Hour
+
+// a single node backfills the candle store, it renews its lease as it goes
+const (
+	candleLease = "candles"
+	leaseTTL    = time.Minute
+)
+
+var errLeaseLost = errors.New("candle backfill lease lost")
+
+type candleService struct {
+	tradeRepo  *repositories.TradeRepository
+	candleRepo *repositories.CandleRepository
+	leaseRepo  *repositories.LeaseRepository
+
+	// the node holding the leases
+	owner string
+
+	mu sync.Mutex
+	// the instruments traded in the candles which are not written yet, by resolution and tick
+	dirty map[string]map[time.Time]map[string]bool
+	// the candle open when the node started, it is written for every instrument as the
+	// trades before the start were not seen
+	first map[string]time.Time
+}
+
+func NewCandleService(
+	tradeRepo *repositories.TradeRepository,
+	candleRepo *repositories.CandleRepository,
+	leaseRepo *repositories.LeaseRepository,
+) ICandleService {
+	dirty := make(map[string]map[time.Time]map[string]bool)
+	for _, resolution := range candle.Stored {
+		dirty[resolution] = make(map[time.Time]map[string]bool)
+	}
+
+	return &candleService{
+		tradeRepo:  tradeRepo,
+		candleRepo: candleRepo,
+		leaseRepo:  leaseRepo,
+		owner:      primitive.NewObjectID().Hex(),
+		dirty:      dirty,
+		first:      make(map[string]time.Time),
+	}
+}
+
+// lock takes or renews the backfill lease
+func (svc *candleService) lock() bool {
+	now := time.Now()
+	return svc.leaseRepo.Acquire(candleLease, svc.owner, now, now.Add(leaseTTL))
+}
+
+// Run writes the candles closed since the latest stored ones, then the candles of the
+// instruments traded on this node as they close
+func (svc *candleService) Run() {
+	store, err := svc.candleRepo.Store()
+	if err == nil {
+		err = svc.candleRepo.EnsureCollection(store)
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("failed to create the candle store")
+		return
+	}
+
+	now := time.Now()
+	svc.mu.Lock()
+	for _, resolution := range candle.Stored {
+		svc.first[resolution] = candle.Start(now, candle.Resolutions[resolution])
+	}
+	svc.mu.Unlock()
+
+	// the other nodes starting meanwhile leave it to this one
+	if svc.lock() {
+		if err := svc.backfill(store, now); err != nil {
+			logs.Log.Error().Err(err).Msg("failed to backfill candles")
+		}
+		svc.leaseRepo.Release(candleLease, svc.owner)
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for _, resolution := range candle.Stored {
+			svc.flush(resolution, now)
+		}
+	}
+}
+
+// Rebuild writes the candles of all the trades into the store which is not in use and
+// swaps the stores, the gateways keep writing the new candles to the previous store
+// until they read the swap
+func (svc *candleService) Rebuild() error {
+	for !svc.lock() {
+		logs.Log.Info().Msg("waiting for the candle backfill of another node")
+		time.Sleep(leaseTTL / 4)
+	}
+	defer svc.leaseRepo.Release(candleLease, svc.owner)
+
+	previous, err := svc.candleRepo.Store()
+	if err != nil {
+		return err
+	}
+	store, err := svc.candleRepo.RebuildStore()
+	if err != nil {
+		return err
+	}
+
+	// a failed rebuild leaves its store behind
+	if err := svc.candleRepo.Drop(store); err != nil {
+		return err
+	}
+	if err := svc.candleRepo.EnsureCollection(store); err != nil {
+		return err
+	}
+
+	rebuilt := time.Now()
+	if err := svc.backfill(store, rebuilt); err != nil {
+		return err
+	}
+
+	if err := svc.candleRepo.Swap(store); err != nil {
+		return err
+	}
+
+	// the candles closed during the rebuild went to the previous store
+	time.Sleep(repositories.CandleStoreRefresh)
+
+	now := time.Now()
+	for _, resolution := range candle.Stored {
+		res := candle.Resolutions[resolution]
+		if _, err := svc.write(store, resolution, candle.Start(rebuilt, res), candle.Start(now, res), nil); err != nil {
+			return err
+		}
+	}
+
+	return svc.candleRepo.Drop(previous)
+}
+
+// backfill writes the candles of every traded instrument into the store, from the latest
+// stored candle of the instrument until the candle open at end
+func (svc *candleService) backfill(store string, end time.Time) error {
+	instruments, err := svc.tradeRepo.TradedInstruments()
+	if err != nil {
+		return err
+	}
+
+	for _, resolution := range candle.Stored {
+		res := candle.Resolutions[resolution]
+
+		last, err := svc.candleRepo.LastTicks(store, resolution)
+		if err != nil {
+			return err
+		}
+
+		written := 0
+		for _, instrument := range instruments {
+			from := candle.Start(instrument.First, res)
+			if tick, ok := last[instrument.Name]; ok {
+				from = tick.Add(res)
+			}
+
+			// nothing was traded after the candle of the last trade
+			to := candle.Start(end, res)
+			if after := candle.Start(instrument.Last, res).Add(res); after.Before(to) {
+				to = after
+			}
+
+			n, err := svc.write(store, resolution, from, to, []string{instrument.Name})
+			written += n
+			if err != nil {
+				return err
+			}
+		}
+
+		logs.Log.Info().Str("store", store).Str("resolution", resolution).Int("candles", written).Msg("candles backfilled")
+	}
+
+	return nil
+}
+
+// write aggregates the candles of [start, end) into the store a backfill range at a time,
+// for every traded instrument when instruments is nil
+func (svc *candleService) write(store, resolution string, start, end time.Time, instruments []string) (int, error) {
+	written := 0
+	for from := start; from.Before(end); from = from.Add(backfillRange) {
+		if !svc.lock() {
+			return written, errLeaseLost
+		}
+
+		to := from.Add(backfillRange)
+		if end.Before(to) {
+			to = end
+		}
+
+		candles, err := svc.tradeRepo.AggregateCandles(resolution, from, to, instruments)
+		if err != nil {
+			return written, err
+		}
+
+		if err := svc.candleRepo.Insert(store, candles); err != nil {
+			return written, err
+		}
+		written += len(candles)
+	}
+
+	return written, nil
+}
+
+// flush writes the candles closed a flush delay ago
+func (svc *candleService) flush(resolution string, now time.Time) {
+	res := candle.Resolutions[resolution]
+	closed := func(tick time.Time) bool {
+		return !now.Before(tick.Add(res).Add(candle.FlushDelay))
+	}
+
+	type job struct {
+		tick        time.Time
+		instruments []string
+	}
+	jobs := []job{}
+
+	var first *time.Time
+
+	svc.mu.Lock()
+	if tick, ok := svc.first[resolution]; ok && closed(tick) {
+		first = &tick
+		delete(svc.first, resolution)
+		delete(svc.dirty[resolution], tick)
+	}
+	for tick, traded := range svc.dirty[resolution] {
+		if !closed(tick) {
+			continue
+		}
+
+		instruments := make([]string, 0, len(traded))
+		for instrument := range traded {
+			instruments = append(instruments, instrument)
+		}
+		jobs = append(jobs, job{tick, instruments})
+		delete(svc.dirty[resolution], tick)
+	}
+	svc.mu.Unlock()
+
+	// every instrument, by a single node of the ones started during the candle
+	if first != nil {
+		lease := candleLease + ".first." + resolution
+		if svc.leaseRepo.Acquire(lease, svc.owner, now, first.Add(2*res).Add(candle.FlushDelay)) {
+			jobs = append(jobs, job{*first, nil})
+		}
+	}
+
+	if len(jobs) == 0 {
+		return
+	}
+
+	store, err := svc.candleRepo.Store()
+	if err != nil {
+		logs.Log.Error().Err(err).Str("resolution", resolution).Msg("failed to read the candle store")
+		return
+	}
+
+	for _, j := range jobs {
+		candles, err := svc.tradeRepo.AggregateCandles(resolution, j.tick, j.tick.Add(res), j.instruments)
+		if err == nil {
+			err = svc.candleRepo.Insert(store, candles)
+		}
+		if err != nil {
+			logs.Log.Error().Err(err).Str("resolution", resolution).Time("tick", j.tick).Msg("failed to write candles")
+		}
+	}
+}
+
+// HandleConsume marks the candles of the saved trades to be written when they close,
+// a trade of a written candle writes it again
+func (svc *candleService) HandleConsume(msg *sarama.ConsumerMessage) {
+	var data _engineType.EngineResponse
+	err := json.Unmarshal(msg.Value, &data)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return
+	}
+
+	if data.Matches == nil {
+		return
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	for _, trade := range data.Matches.Trades {
+		if len(trade.Contracts) == 0 {
+			continue
+		}
+		_instrument := trade.Underlying + "-" + trade.ExpiryDate + "-" + fmt.Sprintf("%.0f", trade.StrikePrice) + "-" + string(trade.Contracts[0])
+
+		for _, resolution := range candle.Stored {
+			tick := candle.Start(trade.CreatedAt, candle.Resolutions[resolution])
+			if first, ok := svc.first[resolution]; ok && first.Equal(tick) {
+				continue
+			}
+
+			if svc.dirty[resolution][tick] == nil {
+				svc.dirty[resolution][tick] = make(map[string]bool)
+			}
+			svc.dirty[resolution][tick][_instrument] = true
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package service
+
+import "github.com/Shopify/sarama"
+
+type ICandleService interface {
+	Run()
+	Rebuild() error
+	HandleConsume(msg *sarama.ConsumerMessage)
+}
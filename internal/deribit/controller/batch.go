@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"gateway/pkg/protocol"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"github.com/gin-gonic/gin"
+)
+
+// batchHandler runs every request of a JSON-RPC batch through the registered
+// handlers and answers with the array of responses, in the order of the requests
+func (h *DeribitHandler) batchHandler(r *gin.Context, body []byte) {
+	var requests []json.RawMessage
+	if err := json.Unmarshal(body, &requests); err != nil {
+		r.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(requests) == 0 {
+		r.AbortWithStatusJSON(http.StatusBadRequest, "empty batch request")
+		return
+	}
+
+	responses := make([]json.RawMessage, len(requests))
+
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, request json.RawMessage) {
+			defer wg.Done()
+			responses[i] = h.batchRequest(r, request)
+		}(i, request)
+	}
+	wg.Wait()
+
+	r.JSON(http.StatusOK, responses)
+}
+
+func (h *DeribitHandler) batchRequest(r *gin.Context, request json.RawMessage) json.RawMessage {
+	var dto struct {
+		Method string  `json:"method"`
+		Id     *uint64 `json:"id"`
+	}
+	if err := json.Unmarshal(request, &dto); err != nil || dto.Id == nil {
+		return batchErrorMessage(0, errors.New("invalid request"), validation_reason.PARSE_ERROR)
+	}
+
+	handler, ok := h.handlers[dto.Method]
+	if !ok {
+		return batchErrorMessage(*dto.Id, errors.New("method not found"), validation_reason.INVALID_PARAMS)
+	}
+
+	logs.Log.Info().Str("called_method", dto.Method).Msg("batch")
+
+	// every request gets its own context, the handler answers into the recorder
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = r.Request.Clone(r.Request.Context())
+	c.Set("body", []byte(request))
+	c.Set("userID", r.GetString("userID"))
+	c.Set("userRole", r.GetString("userRole"))
+
+	handler(c)
+
+	if w.Body.Len() == 0 {
+		return batchErrorMessage(*dto.Id, errors.New(http.StatusText(w.Code)), validation_reason.OTHER)
+	}
+
+	return w.Body.Bytes()
+}
+
+func batchErrorMessage(msgId uint64, err error, reason validation_reason.ValidationReason) json.RawMessage {
+	code, httpCode, codeStr := reason.Code()
+
+	m := protocol.RPCResponseMessage{
+		JSONRPC: "2.0",
+		ID:      msgId,
+		Error: &protocol.ErrorMessage{
+			Message: err.Error(),
+			Data: protocol.ReasonMessage{
+				Reason: codeStr,
+			},
+			Code:           code,
+			HttpStatusCode: httpCode,
+		},
+		Testnet: true,
+	}
+
+	res, _ := json.Marshal(m)
+	return res
+}
@@ -15,6 +15,7 @@ import (
 	"gateway/pkg/middleware"
 	"gateway/pkg/protocol"
 	"gateway/pkg/utils"
+	"gateway/pkg/ws"
 
 	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
 	cors "github.com/rs/cors/wrapper/gin"
@@ -64,6 +65,13 @@ func (h *DeribitHandler) RegisterHandler(method string, handler gin.HandlerFunc)
 }
 
 func (h *DeribitHandler) ApiPostHandler(r *gin.Context) {
+	if body, ok := r.Get("body"); ok {
+		if b, ok := body.([]byte); ok && ws.IsBatchPayload(b) {
+			h.batchHandler(r, b)
+			return
+		}
+	}
+
 	type Params struct{}
 
 	var dto deribitModel.RequestDto[Params]
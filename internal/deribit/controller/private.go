@@ -91,7 +91,14 @@ func (h *DeribitHandler) buy(r *gin.Context) {
 		Side:           types.BUY,
 		MaxShow:        *msg.Params.MaxShow,
 		ReduceOnly:     msg.Params.ReduceOnly,
+		Trigger:        msg.Params.Trigger,
+		TriggerPrice:   msg.Params.TriggerPrice,
+		TriggerOffset:  msg.Params.TriggerOffset,
+		ExpireAt:       msg.Params.ExpireAt,
 		PostOnly:       msg.Params.PostOnly,
+
+		LinkedOrderType: msg.Params.LinkedOrderType,
+		OtocoConfig:     msg.Params.OtocoConfig,
 	})
 
 	if err != nil {
@@ -165,7 +172,14 @@ func (h *DeribitHandler) sell(r *gin.Context) {
 		Side:           types.SELL,
 		MaxShow:        *msg.Params.MaxShow,
 		ReduceOnly:     msg.Params.ReduceOnly,
+		Trigger:        msg.Params.Trigger,
+		TriggerPrice:   msg.Params.TriggerPrice,
+		TriggerOffset:  msg.Params.TriggerOffset,
+		ExpireAt:       msg.Params.ExpireAt,
 		PostOnly:       msg.Params.PostOnly,
+
+		LinkedOrderType: msg.Params.LinkedOrderType,
+		OtocoConfig:     msg.Params.OtocoConfig,
 	})
 	if err != nil {
 		if validation != nil {
@@ -808,6 +822,7 @@ func (h *DeribitHandler) getOrderBook(r *gin.Context) {
 	result := h.svc.GetOrderBook(context.TODO(), deribitModel.DeribitGetOrderBookRequest{
 		InstrumentName: msg.Params.InstrumentName,
 		Depth:          msg.Params.Depth,
+		Interval:       msg.Params.Interval,
 		UserId:         userId,
 	})
 
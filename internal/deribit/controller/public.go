@@ -27,6 +27,10 @@ func (handler *DeribitHandler) RegisterPublic() {
 	handler.RegisterHandler("public/get_index_price", handler.getIndexPrice)
 	handler.RegisterHandler("public/get_last_trades_by_instrument", handler.getLastTradesByInstrument)
 	handler.RegisterHandler("public/get_delivery_prices", handler.getDeliveryPrices)
+	handler.RegisterHandler("public/get_option_chain", handler.getOptionChain)
+	handler.RegisterHandler("public/get_volatility_surface", handler.getVolatilitySurface)
+	handler.RegisterHandler("public/get_historical_volatility", handler.getHistoricalVolatility)
+	handler.RegisterHandler("public/get_volatility_index_data", handler.getVolatilityIndexData)
 	handler.RegisterHandler("public/get_time", handler.getTime)
 }
 
@@ -334,6 +338,185 @@ func (h *DeribitHandler) getDeliveryPrices(r *gin.Context) {
 	return
 }
 
+func (h *DeribitHandler) getOptionChain(r *gin.Context) {
+	var msg deribitModel.RequestDto[deribitModel.GetOptionChainParams]
+	if err := utils.UnmarshalAndValidate(r, &msg); err != nil {
+		errMsg := protocol.ErrorMessage{
+			Message:        err.Error(),
+			Data:           protocol.ReasonMessage{},
+			HttpStatusCode: http.StatusBadRequest,
+		}
+		m := protocol.RPCResponseMessage{
+			JSONRPC: "2.0",
+			ID:      msg.Id,
+			Error:   &errMsg,
+			Testnet: true,
+		}
+		r.AbortWithStatusJSON(http.StatusBadRequest, m)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, r)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+			return
+		} else {
+			sendInvalidRequestMessage(err, msg.Id, *reason, r)
+		}
+		return
+	}
+
+	if _, _, err := utils.ParseExpiry(msg.Params.Currency, msg.Params.Expiry, false); err != nil {
+		protocol.SendValidationMsg(connKey,
+			validation_reason.INVALID_PARAMS, err)
+		return
+	}
+
+	result := h.svc.GetOptionChain(context.TODO(), deribitModel.GetOptionChainRequest{
+		Currency: msg.Params.Currency,
+		Expiry:   msg.Params.Expiry,
+	})
+
+	protocol.SendSuccessMsg(connKey, result)
+	return
+}
+
+func (h *DeribitHandler) getVolatilitySurface(r *gin.Context) {
+	var msg deribitModel.RequestDto[deribitModel.GetVolatilitySurfaceParams]
+	if err := utils.UnmarshalAndValidate(r, &msg); err != nil {
+		errMsg := protocol.ErrorMessage{
+			Message:        err.Error(),
+			Data:           protocol.ReasonMessage{},
+			HttpStatusCode: http.StatusBadRequest,
+		}
+		m := protocol.RPCResponseMessage{
+			JSONRPC: "2.0",
+			ID:      msg.Id,
+			Error:   &errMsg,
+			Testnet: true,
+		}
+		r.AbortWithStatusJSON(http.StatusBadRequest, m)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, r)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+			return
+		} else {
+			sendInvalidRequestMessage(err, msg.Id, *reason, r)
+		}
+		return
+	}
+
+	if _, err := utils.ParseCurrency(msg.Params.Currency); err != nil {
+		protocol.SendValidationMsg(connKey,
+			validation_reason.INVALID_PARAMS, err)
+		return
+	}
+
+	result := h.svc.GetVolatilitySurface(context.TODO(), deribitModel.GetVolatilitySurfaceRequest{
+		Currency: msg.Params.Currency,
+	})
+
+	protocol.SendSuccessMsg(connKey, result)
+	return
+}
+
+func (h *DeribitHandler) getHistoricalVolatility(r *gin.Context) {
+	var msg deribitModel.RequestDto[deribitModel.GetHistoricalVolatilityParams]
+	if err := utils.UnmarshalAndValidate(r, &msg); err != nil {
+		errMsg := protocol.ErrorMessage{
+			Message:        err.Error(),
+			Data:           protocol.ReasonMessage{},
+			HttpStatusCode: http.StatusBadRequest,
+		}
+		m := protocol.RPCResponseMessage{
+			JSONRPC: "2.0",
+			ID:      msg.Id,
+			Error:   &errMsg,
+			Testnet: true,
+		}
+		r.AbortWithStatusJSON(http.StatusBadRequest, m)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, r)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+			return
+		} else {
+			sendInvalidRequestMessage(err, msg.Id, *reason, r)
+		}
+		return
+	}
+
+	if _, err := utils.ParseCurrency(msg.Params.Currency); err != nil {
+		protocol.SendValidationMsg(connKey,
+			validation_reason.INVALID_PARAMS, err)
+		return
+	}
+
+	result := h.svc.GetHistoricalVolatility(context.TODO(), deribitModel.GetHistoricalVolatilityRequest{
+		Currency: msg.Params.Currency,
+	})
+
+	protocol.SendSuccessMsg(connKey, result)
+	return
+}
+
+func (h *DeribitHandler) getVolatilityIndexData(r *gin.Context) {
+	var msg deribitModel.RequestDto[deribitModel.GetVolatilityIndexDataParams]
+	if err := utils.UnmarshalAndValidate(r, &msg); err != nil {
+		errMsg := protocol.ErrorMessage{
+			Message:        err.Error(),
+			Data:           protocol.ReasonMessage{},
+			HttpStatusCode: http.StatusBadRequest,
+		}
+		m := protocol.RPCResponseMessage{
+			JSONRPC: "2.0",
+			ID:      msg.Id,
+			Error:   &errMsg,
+			Testnet: true,
+		}
+		r.AbortWithStatusJSON(http.StatusBadRequest, m)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, r)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+			return
+		} else {
+			sendInvalidRequestMessage(err, msg.Id, *reason, r)
+		}
+		return
+	}
+
+	result, reason, err := h.svc.GetVolatilityIndexData(context.TODO(), deribitModel.GetVolatilityIndexDataRequest{
+		Currency:       msg.Params.Currency,
+		StartTimestamp: msg.Params.StartTimestamp,
+		EndTimestamp:   msg.Params.EndTimestamp,
+		Resolution:     msg.Params.Resolution,
+	})
+	if err != nil {
+		if reason != nil {
+			protocol.SendValidationMsg(connKey, *reason, err)
+			return
+		}
+
+		protocol.SendErrMsg(connKey, err)
+		return
+	}
+
+	protocol.SendSuccessMsg(connKey, result)
+	return
+}
+
 func (h *DeribitHandler) test(r *gin.Context) {
 	r.JSON(http.StatusOK, gin.H{
 		"jsonrpc": "2.0",
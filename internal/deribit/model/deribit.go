@@ -30,6 +30,29 @@ type RequestParams struct {
 	ReduceOnly     bool              `json:"reduce_only,omitempty" form:"reduce_only,omitempty"`
 	TimeInForce    types.TimeInForce `json:"time_in_force" form:"time_in_force"`
 	Label          string            `json:"label" form:"label"`
+	Trigger        string            `json:"trigger,omitempty" form:"trigger,omitempty" description:"Defines the trigger type of the stop_limit, stop_market, take_limit and take_market orders: index_price, mark_price or last_price"`
+	TriggerPrice   float64           `json:"trigger_price,omitempty" form:"trigger_price,omitempty" description:"Trigger price of the stop_limit, stop_market, take_limit and take_market orders"`
+	TriggerOffset  float64           `json:"trigger_offset,omitempty" form:"trigger_offset,omitempty" description:"Distance of the trigger price of the trailing_stop orders to the index or mark price they follow"`
+	ExpireAt       int64             `json:"expire_at,omitempty" form:"expire_at,omitempty" description:"Expiry of the good_til_date orders, timestamp in milliseconds"`
+
+	LinkedOrderType string        `json:"linked_order_type,omitempty" form:"linked_order_type,omitempty" description:"The type of the linked order: one_cancels_other, one_triggers_other or one_triggers_one_cancels_other"`
+	OtocoConfig     []OtocoConfig `json:"otoco_config,omitempty" form:"otoco_config,omitempty" description:"The orders linked to the order, on the same instrument"`
+}
+
+// OtocoConfig is an order linked to the order of the request
+type OtocoConfig struct {
+	Amount        float64           `json:"amount" description:"Amount of the linked order"`
+	Direction     types.Side        `json:"direction" description:"Direction of the linked order: buy or sell"`
+	Type          types.Type        `json:"type" description:"Type of the linked order"`
+	Price         float64           `json:"price,omitempty" description:"Price of the linked order"`
+	TimeInForce   types.TimeInForce `json:"time_in_force,omitempty" description:"Time in force of the linked order"`
+	Label         string            `json:"label,omitempty" description:"Label of the linked order"`
+	PostOnly      bool              `json:"post_only,omitempty"`
+	ReduceOnly    bool              `json:"reduce_only,omitempty"`
+	Trigger       string            `json:"trigger,omitempty" description:"Trigger type of the linked trigger order"`
+	TriggerPrice  float64           `json:"trigger_price,omitempty" description:"Trigger price of the linked trigger order"`
+	TriggerOffset float64           `json:"trigger_offset,omitempty" description:"Trigger offset of the linked trailing_stop order"`
+	ExpireAt      int64             `json:"expire_at,omitempty" description:"Expiry of the linked good_til_date order, timestamp in milliseconds"`
 }
 
 type ChannelParams struct {
@@ -70,6 +93,8 @@ type GetInstrumentsParams struct {
 type GetOrderBookParams struct {
 	BaseParams
 	Depth int64 `json:"depth" form:"depth"`
+	// returns the public book of the book.{instrument_name}.{interval} channel with its change_id
+	Interval string `json:"interval" form:"interval" validate:"omitempty,oneof=raw 100ms agg2" description:"Snapshot of the book channel: raw, 100ms or agg2"`
 }
 
 type GetLastTradesByInstrumentParams struct {
@@ -196,16 +221,29 @@ type DeribitRequest struct {
 	ReduceOnly     bool              `json:"reduce_only"`
 	EnableCancel   bool              `json:"enable_cancel"`
 	ConnectionId   string            `json:"connectionId"`
+	Trigger        string            `json:"trigger"`
+	TriggerPrice   float64           `json:"trigger_price"`
+	TriggerOffset  float64           `json:"trigger_offset"`
+	ExpireAt       int64             `json:"expire_at"`
+
+	LinkedOrderType string        `json:"linked_order_type"`
+	OtocoConfig     []OtocoConfig `json:"otoco_config"`
+
+	// set by the gateway to follow the order it places, a new one is made without it
+	RequestID string `json:"-"`
 }
 
 type DeribitCancelRequest struct {
 	Id      string `json:"id" validate:"required"`
 	ClOrdID string `json:"clOrdID"`
+	// set by the gateway when it cancels the order itself, e.g. once it expired
+	CancelledReason string `json:"-"`
 }
 
 type DeribitCancelAllByConnectionId struct {
 	Side         string `json:"side"`
 	ConnectionId string `json:"connectionId"`
+	RequestID    string `json:"requestId,omitempty"`
 }
 
 type DeribitCancelAllRequest struct {
@@ -214,18 +252,22 @@ type DeribitCancelAllRequest struct {
 }
 
 type DeribitCancelResponse struct {
-	Id       string `json:"id"`
-	UserId   string `json:"userId"`
-	ClientId string `json:"clientId"`
-	Side     string `json:"side"`
-	ClOrdID  string `json:"clOrdID"`
+	Id        string `json:"id"`
+	UserId    string `json:"userId"`
+	ClientId  string `json:"clientId"`
+	Side      string `json:"side"`
+	ClOrdID   string `json:"clOrdID"`
+	RequestID string `json:"requestId,omitempty"`
+	// stored by the engine with the cancelled order
+	CancelledReason string `json:"cancelledReason,omitempty"`
 }
 
 type DeribitCancelAllResponse struct {
-	UserId   string `json:"userId"`
-	ClientId string `json:"clientId"`
-	Side     string `json:"side"`
-	ClOrdID  string `json:"clOrdID"`
+	UserId    string `json:"userId"`
+	ClientId  string `json:"clientId"`
+	Side      string `json:"side"`
+	ClOrdID   string `json:"clOrdID"`
+	RequestID string `json:"requestId,omitempty"`
 }
 
 type DeribitCancelByInstrumentResponse struct {
@@ -238,6 +280,7 @@ type DeribitCancelByInstrumentResponse struct {
 	Contracts      types.Contracts `json:"contracts"`
 	ClOrdID        string          `json:"clOrdID"`
 	Type           types.Type      `json:"type"`
+	RequestID      string          `json:"requestId,omitempty"`
 }
 
 type DeribitCancelByInstrumentRequest struct {
@@ -255,13 +298,14 @@ type DeribitEditRequest struct {
 }
 
 type DeribitEditResponse struct {
-	Id       string  `json:"id"`
-	UserId   string  `json:"userId"`
-	ClientId string  `json:"clientId"`
-	Side     string  `json:"side"`
-	Price    float64 `json:"price"`
-	Amount   float64 `json:"amount"`
-	ClOrdID  string  `json:"clOrdID"`
+	Id        string  `json:"id"`
+	UserId    string  `json:"userId"`
+	ClientId  string  `json:"clientId"`
+	Side      string  `json:"side"`
+	Price     float64 `json:"price"`
+	Amount    float64 `json:"amount"`
+	ClOrdID   string  `json:"clOrdID"`
+	RequestID string  `json:"requestId,omitempty"`
 }
 
 type DeribitResponse struct {
@@ -287,6 +331,9 @@ type DeribitResponse struct {
 	PostOnly       bool              `json:"postOnly,omitempty"`
 	ConnectionId   string            `json:"connectionId,omitempty"`
 	UserRole       types.UserRole    `json:"userRole"`
+	// the id of the command made by the gateway, the engine keeps it with the order and
+	// echoes it in its answers
+	RequestID string `json:"requestId,omitempty"`
 }
 
 type DeribitGetInstrumentsRequest struct {
@@ -314,6 +361,7 @@ type DeribitGetInstrumentsResponse struct {
 type DeribitGetOrderBookRequest struct {
 	InstrumentName string `json:"instrument_name"`
 	Depth          int64  `json:"depth"`
+	Interval       string `json:"interval"`
 	UserId         string `json:"-"`
 }
 
@@ -348,6 +396,8 @@ type DeribitGetOrderBookResponse struct {
 	UnderlyingIndex string                    `json:"underlying_index"`
 	MarkPrice       *float64                  `json:"mark_price"`
 	MarkIv          *float64                  `json:"mark_iv"`
+	ChangeId        *int                      `json:"change_id,omitempty"`
+	Checksum        *uint32                   `json:"checksum,omitempty"`
 }
 
 type TickerSubcriptionResponse struct {
@@ -471,6 +521,11 @@ type DeribitGetOpenOrdersByInstrumentResponse struct {
 	AveragePrice        *float64           `json:"average_price" bson:"priceAvg"`
 	CancelledReason     string             `json:"cancel_reason" bson:"cancelledReason"`
 	UserId              primitive.ObjectID `json:"-" bson:"userId"`
+	Trigger             string             `json:"trigger,omitempty" bson:"trigger"`
+	TriggerPrice        *float64           `json:"trigger_price,omitempty" bson:"triggerPrice"`
+	TriggerOffset       *float64           `json:"trigger_offset,omitempty" bson:"triggerOffset"`
+	OtoOrderIds         []string           `json:"oto_order_ids,omitempty" bson:"-"`
+	OcoRef              string             `json:"oco_ref,omitempty" bson:"-"`
 }
 
 type DeribitGetOrderHistoryByInstrumentRequest struct {
@@ -505,6 +560,8 @@ type DeribitGetOrderHistoryByInstrumentResponse struct {
 	Api                 bool     `json:"api" bson:"api"`
 	AveragePrice        *float64 `json:"average_price" bson:"priceAvg"`
 	CancelledReason     string   `json:"cancel_reason" bson:"cancelledReason"`
+	OtoOrderIds         []string `json:"oto_order_ids,omitempty" bson:"-"`
+	OcoRef              string   `json:"oco_ref,omitempty" bson:"-"`
 }
 
 type DeribitGetOrderStateRequest struct {
@@ -530,6 +587,8 @@ type DeribitGetOrderStateResponse struct {
 	Api                 bool     `json:"api" bson:"api"`
 	AveragePrice        *float64 `json:"average_price" bson:"priceAvg"`
 	CancelledReason     string   `json:"cancel_reason" bson:"cancelledReason"`
+	OtoOrderIds         []string `json:"oto_order_ids,omitempty" bson:"-"`
+	OcoRef              string   `json:"oco_ref,omitempty" bson:"-"`
 }
 
 type DeribitGetOrderStateByLabelRequest struct {
@@ -558,6 +617,8 @@ type DeribitGetOrderStateByLabelResponse struct {
 	Api                 bool     `json:"api" bson:"api"`
 	AveragePrice        *float64 `json:"average_price,omitempty" bson:"priceAvg"`
 	CancelledReason     string   `json:"cancel_reason" bson:"cancelledReason"`
+	OtoOrderIds         []string `json:"oto_order_ids,omitempty" bson:"-"`
+	OcoRef              string   `json:"oco_ref,omitempty" bson:"-"`
 }
 
 type DeliveryPricesParams struct {
@@ -613,3 +674,114 @@ type GetCancelOnDisconnectResponse struct {
 	Scope   string `json:"scope"`
 	Enabled bool   `json:"enabled"`
 }
+
+type GetOptionChainParams struct {
+	Currency string `json:"currency" validate:"required" form:"currency" description:"The currency symbol"`
+	Expiry   string `json:"expiry" validate:"required" form:"expiry" description:"The expiry date of the options, e.g. 28JAN22"`
+}
+
+type GetOptionChainRequest struct {
+	Currency string `json:"currency"`
+	Expiry   string `json:"expiry"`
+}
+
+type OptionChainQuote struct {
+	InstrumentName string         `json:"instrument_name"`
+	BestBidPrice   float64        `json:"best_bid_price"`
+	BestBidAmount  float64        `json:"best_bid_amount"`
+	BestAskPrice   float64        `json:"best_ask_price"`
+	BestAskAmount  float64        `json:"best_ask_amount"`
+	Bids_iv        float64        `json:"bid_iv"`
+	Asks_iv        float64        `json:"ask_iv"`
+	MarkPrice      *float64       `json:"mark_price"`
+	MarkIv         *float64       `json:"mark_iv"`
+	Greeks         OrderBookGreek `json:"greeks"`
+	OpenInterest   float64        `json:"open_interest" description:"The open interest in contracts"`
+	Volume         float64        `json:"volume" description:"The volume of the last 24 hours in contracts"`
+}
+
+type OptionChainStrike struct {
+	Strike float64           `json:"strike"`
+	Call   *OptionChainQuote `json:"call"`
+	Put    *OptionChainQuote `json:"put"`
+}
+
+type GetOptionChainResponse struct {
+	Currency            string              `json:"currency"`
+	Expiry              string              `json:"expiry"`
+	ExpirationTimestamp int64               `json:"expiration_timestamp"`
+	IndexPrice          *float64            `json:"index_price"`
+	Timestamp           int64               `json:"timestamp"`
+	Strikes             []OptionChainStrike `json:"strikes"`
+}
+
+type GetVolatilitySurfaceParams struct {
+	Currency string `json:"currency" validate:"required" form:"currency" description:"The currency symbol"`
+}
+
+type GetVolatilitySurfaceRequest struct {
+	Currency string `json:"currency"`
+}
+
+type VolatilitySurfaceStrike struct {
+	Strike         float64  `json:"strike"`
+	InstrumentName string   `json:"instrument_name" description:"The out of the money option of the strike, or the other one when it has no bid or ask"`
+	Iv             *float64 `json:"iv" description:"The implied volatility of the mid price"`
+	BidIv          float64  `json:"bid_iv"`
+	AskIv          float64  `json:"ask_iv"`
+	Delta          float64  `json:"delta" description:"The forward delta of the call of the strike"`
+}
+
+type VolatilitySurfaceDelta struct {
+	Bucket string  `json:"bucket" description:"10P, 25P, ATM, 25C or 10C"`
+	Delta  float64 `json:"delta" description:"The forward delta of the call of the bucket"`
+	Iv     float64 `json:"iv"`
+}
+
+type VolatilitySurfaceExpiry struct {
+	Expiry              string                    `json:"expiry"`
+	ExpirationTimestamp int64                     `json:"expiration_timestamp"`
+	Forward             float64                   `json:"forward"`
+	Strikes             []VolatilitySurfaceStrike `json:"strikes"`
+	Deltas              []VolatilitySurfaceDelta  `json:"deltas"`
+}
+
+type GetVolatilitySurfaceResponse struct {
+	Currency   string                    `json:"currency"`
+	IndexPrice *float64                  `json:"index_price"`
+	Timestamp  int64                     `json:"timestamp"`
+	Expiries   []VolatilitySurfaceExpiry `json:"expiries"`
+}
+
+type GetHistoricalVolatilityParams struct {
+	Currency string `json:"currency" validate:"required" form:"currency" description:"The currency symbol"`
+}
+
+type GetHistoricalVolatilityRequest struct {
+	Currency string `json:"currency"`
+}
+
+type GetVolatilityIndexDataParams struct {
+	Currency       string `json:"currency" validate:"required" form:"currency" description:"The currency symbol"`
+	StartTimestamp int64  `json:"start_timestamp" validate:"required" form:"start_timestamp" description:"The earliest timestamp to return result from (milliseconds since the UNIX epoch)"`
+	EndTimestamp   int64  `json:"end_timestamp" validate:"required" form:"end_timestamp" description:"The most recent timestamp to return result from (milliseconds since the UNIX epoch)"`
+	Resolution     string `json:"resolution" validate:"required" form:"resolution" description:"Time resolution, one of 1, 60, 3600, 43200 or 1D"`
+}
+
+type GetVolatilityIndexDataRequest struct {
+	Currency       string `json:"currency"`
+	StartTimestamp int64  `json:"start_timestamp"`
+	EndTimestamp   int64  `json:"end_timestamp"`
+	Resolution     string `json:"resolution"`
+}
+
+type GetVolatilityIndexDataResponse struct {
+	Data         [][5]float64 `json:"data" description:"Candles as an array of [timestamp, open, high, low, close] of the volatility index in percent"`
+	Continuation *int64       `json:"continuation" description:"The end_timestamp of the next page of older candles, null when there are none"`
+}
+
+type InstrumentStateNotification struct {
+	InstrumentName string `json:"instrument_name"`
+	State          string `json:"state" description:"closed once the instrument expired"`
+	Timestamp      int64  `json:"timestamp"`
+}
@@ -23,7 +23,7 @@ type Order struct {
 	Price               float64            `json:"price" bson:"price" description:"Price in base currency"`
 	OrderId             primitive.ObjectID `json:"order_id" bson:"orderId" description:"Unique order identifier"`
 	Replaced            bool               `json:"replaced" description:"true if the order was edited, otherwise false"`
-	TimeInForce         types.TimeInForce  `json:"time_in_force" bson:"timeInForce" description:"Order time in force" oneof:"good_til_cancelled,good_til_day,fill_or_kill,immediate_or_cancel"`
+	TimeInForce         types.TimeInForce  `json:"time_in_force" bson:"timeInForce" description:"Order time in force" oneof:"good_til_cancelled,good_til_day,good_til_date,fill_or_kill,immediate_or_cancel"`
 	OrderType           types.Type         `json:"order_type" bson:"orderType" description:"Order type" oneof:"limit,market,stop_limit,stop_market"`
 	OrderState          types.OrderStatus  `json:"order_state" bson:"orderState" description:"Order state" oneof:"open,filled,rejected,cancelled,untriggered"`
 	MaxShow             float64            `json:"max_show" bson:"maxShow" description:"Maximum amount within an order to be shown to other customers"`
@@ -6,12 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"gateway/internal/deribit/model"
+	_optionsSvc "gateway/internal/options/service"
+	_outboxSvc "gateway/internal/outbox/service"
 	"gateway/internal/repositories"
+	_triggerSvc "gateway/internal/trigger/service"
 	"gateway/pkg/collector"
 	"gateway/pkg/constant"
-	"gateway/pkg/kafka/producer"
+	"gateway/pkg/expiry"
 	"gateway/pkg/memdb"
+	"gateway/pkg/pricing"
 	"gateway/pkg/redis"
+	"gateway/pkg/trigger"
 	"gateway/pkg/utils"
 	"log"
 	"strconv"
@@ -32,8 +37,13 @@ type deribitService struct {
 	orderRepo           *repositories.OrderRepository
 	rawPriceRepo        *repositories.RawPriceRepository
 	settlementPriceRepo *repositories.SettlementPriceRepository
+	linkedOrderRepo     *repositories.LinkedOrderRepository
+	orderExpirationRepo *repositories.OrderExpirationRepository
 
-	redis *redis.RedisConnectionPool
+	redis   *redis.RedisConnectionPool
+	outbox  _outboxSvc.IOutboxService
+	trigger _triggerSvc.ITriggerService
+	options _optionsSvc.IOptionsService
 }
 
 func NewDeribitService(
@@ -43,13 +53,24 @@ func NewDeribitService(
 	orderRepo *repositories.OrderRepository,
 	rawPriceRepo *repositories.RawPriceRepository,
 	settlementPriceRepo *repositories.SettlementPriceRepository,
+	linkedOrderRepo *repositories.LinkedOrderRepository,
+	orderExpirationRepo *repositories.OrderExpirationRepository,
+
+	outbox _outboxSvc.IOutboxService,
+	trigger _triggerSvc.ITriggerService,
+	options _optionsSvc.IOptionsService,
 ) IDeribitService {
 	return &deribitService{
 		tradeRepo,
 		orderRepo,
 		rawPriceRepo,
 		settlementPriceRepo,
+		linkedOrderRepo,
+		orderExpirationRepo,
 		redis,
+		outbox,
+		trigger,
+		options,
 	}
 }
 
@@ -58,6 +79,11 @@ func (svc deribitService) DeribitRequest(
 	userId string,
 	data model.DeribitRequest,
 ) (*model.DeribitResponse, *validation_reason.ValidationReason, error) {
+	// the order graph is held by the gateway, the order of the request is its primary order
+	if data.LinkedOrderType != "" {
+		return svc.placeLinked(ctx, userId, data)
+	}
+
 	instruments, err := utils.ParseInstruments(data.InstrumentName, true)
 	if err != nil {
 		reason := validation_reason.INVALID_PARAMS
@@ -103,6 +129,25 @@ func (svc deribitService) DeribitRequest(
 		_timeInForce = data.TimeInForce
 	}
 
+	// the gateway cancels the good_til_day and good_til_date orders once they expire, the
+	// engine holds them as good_til_cancelled
+	var expireAt time.Time
+	switch string(data.TimeInForce) {
+	case expiry.GoodTilDay:
+		expireAt = expiry.DayEnd(time.Now())
+	case expiry.GoodTilDate:
+		if data.ExpireAt <= 0 {
+			reason := validation_reason.INVALID_PARAMS
+			return nil, &reason, errors.New(constant.EXPIRE_AT_IS_REQUIRED)
+		}
+
+		expireAt = time.UnixMilli(data.ExpireAt)
+		if !expireAt.After(time.Now()) {
+			reason := validation_reason.INVALID_PARAMS
+			return nil, &reason, errors.New(constant.INVALID_EXPIRE_AT)
+		}
+	}
+
 	payload := model.DeribitResponse{
 		ID:             data.ID,
 		UserId:         userId,
@@ -122,10 +167,40 @@ func (svc deribitService) DeribitRequest(
 		ReduceOnly:     data.ReduceOnly,
 		PostOnly:       data.PostOnly,
 		UserRole:       userCast.Role,
+		RequestID:      data.RequestID,
+	}
+	if payload.RequestID == "" {
+		payload.RequestID = primitive.NewObjectID().Hex()
 	}
 	if data.EnableCancel {
 		payload.ConnectionId = data.ConnectionId
 	}
+
+	// the trigger orders are held by the gateway until their trigger price is reached
+	if trigger.IsTriggerType(string(payload.Type)) {
+		if reason, err := svc.trigger.Place(ctx, instruments.Name(), &payload, data.Trigger, data.TriggerPrice, data.TriggerOffset); err != nil {
+			return nil, reason, err
+		}
+
+		if !expireAt.IsZero() {
+			orderId, _ := primitive.ObjectIDFromHex(payload.ID)
+			if err := svc.saveExpiration(userId, string(data.TimeInForce), &payload, orderId, expireAt); err != nil {
+				// the order would never expire
+				svc.trigger.Cancel(ctx, userId, model.DeribitCancelRequest{Id: payload.ID, ClOrdID: payload.ClOrdID})
+				return nil, nil, err
+			}
+		}
+
+		return &payload, nil, nil
+	}
+
+	// the expiration is saved before the order reaches the engine
+	if !expireAt.IsZero() {
+		if err := svc.saveExpiration(userId, string(data.TimeInForce), &payload, primitive.NilObjectID, expireAt); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	out, err := json.Marshal(payload)
 	if err != nil {
 		logs.Log.Error().Err(err).Msg("")
@@ -136,8 +211,10 @@ func (svc deribitService) DeribitRequest(
 	// collector
 	go collector.StartKafkaDuration(payload.UserId, payload.ClOrdID)
 
-	//send to kafka
-	go producer.KafkaProducer(string(out), types.NEW_ORDER.String())
+	//send to kafka through the outbox
+	if err := svc.outbox.Publish(ctx, types.NEW_ORDER.String(), payload.RequestID, payload.UserId, payload.ClOrdID, out); err != nil {
+		return nil, nil, err
+	}
 
 	return &payload, nil, nil
 }
@@ -145,13 +222,14 @@ func (svc deribitService) DeribitRequest(
 func (svc deribitService) DeribitParseEdit(ctx context.Context, userId string, data model.DeribitEditRequest) (*model.DeribitEditResponse, *validation_reason.ValidationReason, error) {
 
 	edit := model.DeribitEditResponse{
-		Id:       data.Id,
-		UserId:   userId,
-		ClientId: "",
-		Side:     string(types.EDIT),
-		ClOrdID:  data.ClOrdID,
-		Price:    data.Price,
-		Amount:   data.Amount,
+		Id:        data.Id,
+		UserId:    userId,
+		ClientId:  "",
+		Side:      string(types.EDIT),
+		ClOrdID:   data.ClOrdID,
+		Price:     data.Price,
+		Amount:    data.Amount,
+		RequestID: primitive.NewObjectID().Hex(),
 	}
 
 	_edit, err := json.Marshal(edit)
@@ -161,19 +239,23 @@ func (svc deribitService) DeribitParseEdit(ctx context.Context, userId string, d
 		reason := validation_reason.OTHER
 		return nil, &reason, errors.New(reason.String())
 	}
-	//send to kafka
-	producer.KafkaProducer(string(_edit), "NEW_ORDER")
+	//send to kafka through the outbox
+	if err := svc.outbox.Publish(ctx, types.NEW_ORDER.String(), edit.RequestID, edit.UserId, edit.ClOrdID, _edit); err != nil {
+		return nil, nil, err
+	}
 
-	return &edit, nil, err
+	return &edit, nil, nil
 }
 
 func (svc deribitService) DeribitParseCancel(ctx context.Context, userId string, data model.DeribitCancelRequest) (*model.DeribitCancelResponse, error) {
 	cancel := model.DeribitCancelResponse{
-		Id:       data.Id,
-		UserId:   userId,
-		ClientId: "",
-		Side:     string(types.CANCEL),
-		ClOrdID:  data.ClOrdID,
+		Id:              data.Id,
+		UserId:          userId,
+		ClientId:        "",
+		Side:            string(types.CANCEL),
+		ClOrdID:         data.ClOrdID,
+		RequestID:       primitive.NewObjectID().Hex(),
+		CancelledReason: data.CancelledReason,
 	}
 
 	_cancel, err := json.Marshal(cancel)
@@ -183,6 +265,15 @@ func (svc deribitService) DeribitParseCancel(ctx context.Context, userId string,
 		return nil, err
 	}
 
+	// the untriggered orders are held by the gateway
+	if ok, err := svc.trigger.Cancel(ctx, userId, data); ok {
+		if err != nil {
+			return nil, err
+		}
+
+		return &cancel, nil
+	}
+
 	order, err := svc.orderRepo.GetOrderById(data.Id)
 	if err != nil {
 		logs.Log.Error().Err(err).Msg("")
@@ -201,8 +292,10 @@ func (svc deribitService) DeribitParseCancel(ctx context.Context, userId string,
 	// collector
 	collector.StartKafkaDuration(cancel.UserId, cancel.ClOrdID)
 
-	//send to kafka
-	producer.KafkaProducer(string(_cancel), "NEW_ORDER")
+	//send to kafka through the outbox
+	if err := svc.outbox.Publish(ctx, types.NEW_ORDER.String(), cancel.RequestID, cancel.UserId, cancel.ClOrdID, _cancel); err != nil {
+		return nil, err
+	}
 
 	return &cancel, nil
 }
@@ -223,8 +316,12 @@ func (svc deribitService) DeribitCancelByInstrument(ctx context.Context, userId
 		Side:           string(types.CANCEL_ALL_BY_INSTRUMENT),
 		ClOrdID:        data.ClOrdID,
 		Type:           data.Type,
+		RequestID:      primitive.NewObjectID().Hex(),
 	}
 
+	// the engine only cancels the orders it holds
+	svc.trigger.CancelAll(ctx, userId, instruments.Name(), string(data.Type))
+
 	_cancel, err := json.Marshal(cancel)
 	if err != nil {
 		logs.Log.Error().Err(err).Msg("")
@@ -235,20 +332,26 @@ func (svc deribitService) DeribitCancelByInstrument(ctx context.Context, userId
 	// collector
 	collector.StartKafkaDuration(cancel.UserId, cancel.ClOrdID)
 
-	//send to kafka
-	producer.KafkaProducer(string(_cancel), "NEW_ORDER")
+	//send to kafka through the outbox
+	if err := svc.outbox.Publish(ctx, types.NEW_ORDER.String(), cancel.RequestID, cancel.UserId, cancel.ClOrdID, _cancel); err != nil {
+		return nil, err
+	}
 
 	return &cancel, nil
 }
 
 func (svc deribitService) DeribitParseCancelAll(ctx context.Context, userId string, data model.DeribitCancelAllRequest) (*model.DeribitCancelAllResponse, error) {
 	cancel := model.DeribitCancelAllResponse{
-		UserId:   userId,
-		ClientId: "",
-		Side:     string(types.CANCEL_ALL),
-		ClOrdID:  data.ClOrdID,
+		UserId:    userId,
+		ClientId:  "",
+		Side:      string(types.CANCEL_ALL),
+		ClOrdID:   data.ClOrdID,
+		RequestID: primitive.NewObjectID().Hex(),
 	}
 
+	// the engine only cancels the orders it holds
+	svc.trigger.CancelAll(ctx, userId, "", "all")
+
 	_cancel, err := json.Marshal(cancel)
 	if err != nil {
 		logs.Log.Error().Err(err).Msg("")
@@ -259,8 +362,10 @@ func (svc deribitService) DeribitParseCancelAll(ctx context.Context, userId stri
 	// collector
 	collector.StartKafkaDuration(cancel.UserId, cancel.ClOrdID)
 
-	//send to kafka
-	producer.KafkaProducer(string(_cancel), "NEW_ORDER")
+	//send to kafka through the outbox
+	if err := svc.outbox.Publish(ctx, types.NEW_ORDER.String(), cancel.RequestID, cancel.UserId, cancel.ClOrdID, _cancel); err != nil {
+		return nil, err
+	}
 
 	return &cancel, nil
 }
@@ -302,7 +407,9 @@ func (svc *deribitService) DeribitGetLastTradesByInstrument(ctx context.Context,
 		var markIv float64
 
 		if markPrice != 0 {
-			markIv = svc.tradeRepo.GetMarkIv(markPrice, contracts, jsonDoc["expiryDate"].(string), jsonDoc["strikePrice"].(float64), jsonDoc["indexPrice"].(float64))
+			if option, err := pricing.NewOption(underlying, expiryDate, strikePrice, contracts == "C"); err == nil {
+				markIv = option.ImpliedVol(markPrice, jsonDoc["indexPrice"].(float64), jsonDoc["createdAt"].(primitive.DateTime).Time())
+			}
 		}
 
 		tradeObjectId := jsonDoc["_id"].(primitive.ObjectID)
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"gateway/internal/deribit/model"
+	_tradeType "gateway/internal/repositories/types"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// saveExpiration saves the expiry of the order of the payload with the time in force of the
+// request, the order id is only known for the trigger orders, the engine orders get theirs
+// once the engine reports the request
+func (svc deribitService) saveExpiration(userId, timeInForce string, payload *model.DeribitResponse, orderId primitive.ObjectID, expireAt time.Time) error {
+	uId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return svc.orderExpirationRepo.Insert(&_tradeType.OrderExpiration{
+		ID:          primitive.NewObjectID(),
+		UserID:      uId,
+		ClOrdID:     payload.ClOrdID,
+		RequestID:   payload.RequestID,
+		OrderID:     orderId,
+		TimeInForce: timeInForce,
+		ExpireAt:    expireAt,
+		Status:      _tradeType.EXPIRATION_PENDING,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
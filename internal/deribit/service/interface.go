@@ -23,7 +23,11 @@ type IDeribitService interface {
 	GetOrderBook(ctx context.Context, data model.DeribitGetOrderBookRequest) *model.DeribitGetOrderBookResponse
 	GetIndexPrice(ctx context.Context, data model.DeribitGetIndexPriceRequest) model.DeribitGetIndexPriceResponse
 	GetDeliveryPrices(ctx context.Context, request model.DeliveryPricesRequest) model.DeliveryPricesResponse
+	GetOptionChain(ctx context.Context, request model.GetOptionChainRequest) model.GetOptionChainResponse
+	GetVolatilitySurface(ctx context.Context, request model.GetVolatilitySurfaceRequest) model.GetVolatilitySurfaceResponse
 	GetTradingViewChartData(ctx context.Context, request model.GetTradingviewChartDataRequest) (model.GetTradingviewChartDataResponse, *validation_reason.ValidationReason, error)
+	GetHistoricalVolatility(ctx context.Context, request model.GetHistoricalVolatilityRequest) [][2]float64
+	GetVolatilityIndexData(ctx context.Context, request model.GetVolatilityIndexDataRequest) (model.GetVolatilityIndexDataResponse, *validation_reason.ValidationReason, error)
 
 	FetchUserBalance(currency string, userID string) model.GetAccountSummaryResult
 
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"gateway/internal/deribit/model"
+	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/constant"
+	"gateway/pkg/linked"
+	"strconv"
+	"time"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// placeLinked saves the order graph of the request and places its primary order, the
+// orders of the otoco config are placed with it or once it is filled
+func (svc deribitService) placeLinked(ctx context.Context, userId string, data model.DeribitRequest) (*model.DeribitResponse, *validation_reason.ValidationReason, error) {
+	linkedType := data.LinkedOrderType
+	if !linked.IsType(linkedType) {
+		reason := validation_reason.INVALID_PARAMS
+		return nil, &reason, errors.New(constant.INVALID_LINKED_ORDER_TYPE)
+	}
+
+	if len(data.OtocoConfig) == 0 {
+		reason := validation_reason.INVALID_PARAMS
+		return nil, &reason, errors.New(constant.INVALID_OTOCO_CONFIG)
+	}
+
+	uId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		reason := validation_reason.UNAUTHORIZED
+		return nil, &reason, errors.New(reason.String())
+	}
+
+	now := time.Now()
+	primary := &_tradeType.LinkedOrder{
+		ID:              primitive.NewObjectID(),
+		UserID:          uId,
+		Primary:         true,
+		LinkedOrderType: linkedType,
+		ClOrdID:         data.ClOrdID,
+		RequestID:       primitive.NewObjectID().Hex(),
+		Status:          _tradeType.LINK_OPEN,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	primary.PrimaryID = primary.ID
+
+	ocoRef := ""
+	if linked.LegsInOco(linkedType) {
+		ocoRef = primitive.NewObjectID().Hex()
+	}
+	if linked.PrimaryInOco(linkedType) {
+		primary.OcoRef = ocoRef
+	}
+
+	status := _tradeType.LINK_OPEN
+	if linked.Triggers(linkedType) {
+		status = _tradeType.LINK_PENDING
+	}
+
+	orders := []*_tradeType.LinkedOrder{primary}
+	for i, leg := range data.OtocoConfig {
+		if leg.Amount <= 0 || leg.Type == "" || (leg.Direction != types.BUY && leg.Direction != types.SELL) {
+			reason := validation_reason.INVALID_PARAMS
+			return nil, &reason, errors.New(constant.INVALID_OTOCO_CONFIG)
+		}
+
+		// the clients do not wait for the orders of the otoco config, their requests are
+		// made by the gateway
+		clOrdID := strconv.FormatInt(now.UnixNano()+int64(i)+1, 10)
+		requestID := primitive.NewObjectID().Hex()
+		orders = append(orders, &_tradeType.LinkedOrder{
+			ID:              primitive.NewObjectID(),
+			UserID:          uId,
+			PrimaryID:       primary.ID,
+			LinkedOrderType: linkedType,
+			OcoRef:          ocoRef,
+			ClOrdID:         clOrdID,
+			RequestID:       requestID,
+			Request: model.DeribitRequest{
+				ClientId:       data.ClientId,
+				InstrumentName: data.InstrumentName,
+				Amount:         leg.Amount,
+				Type:           leg.Type,
+				Price:          leg.Price,
+				ClOrdID:        clOrdID,
+				TimeInForce:    leg.TimeInForce,
+				Label:          leg.Label,
+				Side:           leg.Direction,
+				PostOnly:       leg.PostOnly,
+				ReduceOnly:     leg.ReduceOnly,
+				Trigger:        leg.Trigger,
+				TriggerPrice:   leg.TriggerPrice,
+				TriggerOffset:  leg.TriggerOffset,
+				ExpireAt:       leg.ExpireAt,
+				RequestID:      requestID,
+			},
+			Status:    status,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	// the graph is saved before the primary order reaches the engine
+	if err := svc.linkedOrderRepo.InsertMany(orders); err != nil {
+		return nil, nil, err
+	}
+
+	// the engine echoes the request id of the primary order with it
+	data.LinkedOrderType, data.OtocoConfig = "", nil
+	data.RequestID = primary.RequestID
+	response, reason, err := svc.DeribitRequest(ctx, userId, data)
+	if err != nil {
+		svc.linkedOrderRepo.DeleteGraph(primary.ID)
+		return nil, reason, err
+	}
+	svc.setLinkedOrderID(primary, response)
+
+	// the orders of the otoco config of an oco are placed with the primary order
+	if !linked.Triggers(linkedType) {
+		for _, order := range orders[1:] {
+			svc.placeLinkedLeg(ctx, order)
+		}
+	}
+
+	return response, nil, nil
+}
+
+func (svc deribitService) placeLinkedLeg(ctx context.Context, order *_tradeType.LinkedOrder) {
+	response, _, err := svc.DeribitRequest(ctx, order.UserID.Hex(), order.Request)
+	if err != nil {
+		logs.Log.Error().Err(err).Str("order_id", order.ID.Hex()).Msg("failed to place linked order")
+		svc.linkedOrderRepo.UpdateStatus(order.ID, []string{_tradeType.LINK_OPEN}, _tradeType.LINK_CANCELLED)
+		return
+	}
+
+	svc.setLinkedOrderID(order, response)
+}
+
+// setLinkedOrderID sets the id of a trigger order, the engine orders get theirs once the
+// engine placed them
+func (svc deribitService) setLinkedOrderID(order *_tradeType.LinkedOrder, response *model.DeribitResponse) {
+	if response == nil || response.ID == "" {
+		return
+	}
+
+	orderId, err := primitive.ObjectIDFromHex(response.ID)
+	if err != nil {
+		return
+	}
+
+	placed, err := svc.linkedOrderRepo.SetOrderID(order.ID, orderId)
+	if err != nil || placed == nil {
+		return
+	}
+
+	// the oco of the order was filled before it was placed
+	if placed.Status == _tradeType.LINK_CANCELLED {
+		svc.DeribitParseCancel(context.Background(), placed.UserID.Hex(), model.DeribitCancelRequest{
+			Id:      orderId.Hex(),
+			ClOrdID: strconv.FormatInt(time.Now().UnixNano(), 10),
+		})
+	}
+}
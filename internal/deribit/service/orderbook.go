@@ -6,14 +6,17 @@ import (
 	"fmt"
 	"gateway/internal/deribit/model"
 	_engineTypes "gateway/internal/engine/types"
+	"gateway/internal/orderbook/book"
 	_orderbookTypes "gateway/internal/orderbook/types"
 
 	"gateway/pkg/memdb"
+	"gateway/pkg/pricing"
 	"gateway/pkg/utils"
 	"strings"
 	"time"
 
 	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
 )
 
 func (svc deribitService) GetOrderBook(ctx context.Context, data model.DeribitGetOrderBookRequest) *model.DeribitGetOrderBookResponse {
@@ -81,6 +84,19 @@ func (svc deribitService) GetOrderBook(ctx context.Context, data model.DeribitGe
 		results.MarkIv = nil
 	}
 
+	// the book of the channel, the client checks its own with the change id and the checksum
+	if data.Interval != "" {
+		snapshot, err := book.GetSnapshot(svc.redis, data.InstrumentName, data.Interval)
+		if err != nil {
+			logs.Log.Error().Err(err).Msg("failed to get order book snapshot")
+		} else {
+			results.Bids = snapshot.Book.Bids
+			results.Asks = snapshot.Book.Asks
+			results.ChangeId = &snapshot.ChangeId
+			results.Checksum = &snapshot.Checksum
+		}
+	}
+
 	if len(indexPrice) > 0 {
 		results.IndexPrice = &indexPrice[0].Price
 		results.UnderlyingPrice = &indexPrice[0].Price
@@ -101,7 +117,7 @@ func (svc deribitService) GetOrderBook(ctx context.Context, data model.DeribitGe
 func (svc deribitService) GetDataQuote(order _orderbookTypes.GetOrderBook) (_orderbookTypes.QuoteMessage, _orderbookTypes.Orderbook) {
 
 	// Get initial data
-	_getOrderBook := svc.orderRepo.GetOrderBook(order)
+	_getOrderBook := book.Orderbook(order)
 
 	//count best Ask
 	maxAskPrice := 0.0
@@ -217,18 +233,6 @@ func (svc deribitService) GetDataOrderBook(_order _orderbookTypes.GetOrderBook,
 		_state = "open"
 	}
 
-	//TODO query to get expires time
-	expiredDate := dateString
-	currentDate := time.Now().Format("2006-01-02 15:04:05")
-	layoutExpired := "02Jan06"
-	layoutCurrent := "2006-01-02 15:04:05"
-
-	expired, _ := time.Parse(layoutExpired, expiredDate)
-	current, _ := time.Parse(layoutCurrent, currentDate)
-	calculate := float64(expired.Day()) - float64(current.Day())
-
-	dateValue := float64(calculate / 365)
-
 	//TODO query to trades collections
 	_getLastTrades := svc.tradeRepo.GetLastTrades(_order)
 	_lastPrice := 0.0
@@ -273,28 +277,29 @@ func (svc deribitService) GetDataOrderBook(_order _orderbookTypes.GetOrderBook,
 		underlyingPrice = float64(0)
 	}
 
-	//TODO query to get Option Price
-	str := _order.InstrumentName
-	parts := strings.Split(str, "-")
-	lastPart := parts[len(parts)-1]
-	optionPrice := ""
-	if string(lastPart[0]) == "C" {
-		optionPrice = "call"
-	} else {
-		optionPrice = "put"
+	// the options are priced with black-76 on the forward of the index
+	option, err := pricing.NewOption(_order.Underlying, _order.ExpiryDate, _order.StrikePrice, strings.HasSuffix(_order.InstrumentName, "C"))
+	if err != nil {
+		logs.Log.Error().Err(err).Str("instrument", _order.InstrumentName).Msg("failed to price option")
 	}
+	now := time.Now()
+
+	_getImpliedsAsk := option.ImpliedVol(dataQuote.BestAskPrice, underlyingPrice, now)
+	_getImpliedsBid := option.ImpliedVol(dataQuote.BestBidPrice, underlyingPrice, now)
+	_getImpliedsVolatility := option.ImpliedVol(_lastPrice, underlyingPrice, now)
 
-	//TODO query to get ask_iv and bid_iv
-	_getImpliedsAsk := svc.tradeRepo.GetImpliedVolatility(float64(dataQuote.BestAskAmount), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getImpliedsBid := svc.tradeRepo.GetImpliedVolatility(float64(dataQuote.BestBidAmount), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
+	markData := _orderbookTypes.MarkData{}
+	if dataQuote.BestAskPrice != 0 && dataQuote.BestBidPrice != 0 {
+		markData.MarkPrice = (dataQuote.BestAskPrice + dataQuote.BestBidPrice) / 2
+		markData.MarkIv = option.ImpliedVol(markData.MarkPrice, underlyingPrice, now)
+	}
 
-	//TODO query to get all greeks
-	_getImpliedsVolatility := svc.tradeRepo.GetImpliedVolatility(float64(_lastPrice), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksDelta := svc.tradeRepo.GetGreeks("delta", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksVega := svc.tradeRepo.GetGreeks("vega", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksGamma := svc.tradeRepo.GetGreeks("gamma", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksTetha := svc.tradeRepo.GetGreeks("tetha", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksRho := svc.tradeRepo.GetGreeks("rho", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
+	// the greeks are at the mark volatility, at the last price one without a mark
+	vol := markData.MarkIv
+	if vol == 0 {
+		vol = _getImpliedsVolatility
+	}
+	greeks := option.Greeks(underlyingPrice, vol, now)
 
 	value := _orderbookTypes.OrderBookData{
 		State:        _state,
@@ -305,18 +310,49 @@ func (svc deribitService) GetDataOrderBook(_order _orderbookTypes.GetOrderBook,
 		ImpliedAsk:   _getImpliedsAsk,
 		ImpliedBid:   _getImpliedsBid,
 		VolumeAmount: _volumeAmount,
-		GreeksDelta:  _getGreeksDelta,
-		GreeksVega:   _getGreeksVega,
-		GreeksGamma:  _getGreeksGamma,
-		GreeksTetha:  _getGreeksTetha,
-		GreeksRho:    _getGreeksRho,
+		GreeksDelta:  greeks.Delta,
+		GreeksVega:   greeks.Vega,
+		GreeksGamma:  greeks.Gamma,
+		GreeksTetha:  greeks.Theta,
+		GreeksRho:    greeks.Rho,
 	}
 
-	markData := _orderbookTypes.MarkData{}
-	if dataQuote.BestAskPrice != 0 && dataQuote.BestBidPrice != 0 {
-		markData.MarkPrice = (dataQuote.BestAskPrice + dataQuote.BestBidPrice) / 2
-		markData.MarkIv = svc.tradeRepo.GetImpliedVolatility(float64(markData.MarkPrice), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
+	return value, _getIndexPrice, markData
+}
+
+// GetOptionChain returns the call and put quotes of every strike of the expiry
+func (svc deribitService) GetOptionChain(ctx context.Context, request model.GetOptionChainRequest) model.GetOptionChainResponse {
+	return svc.options.GetOptionChain(ctx, request)
+}
+
+// GetVolatilitySurface returns the implied volatilities of the active expiries of the
+// currency by strike and by delta bucket
+func (svc deribitService) GetVolatilitySurface(ctx context.Context, request model.GetVolatilitySurfaceRequest) model.GetVolatilitySurfaceResponse {
+	return svc.options.GetVolatilitySurface(ctx, request)
+}
+
+func (svc deribitService) GetHistoricalVolatility(ctx context.Context, request model.GetHistoricalVolatilityRequest) [][2]float64 {
+	underlying, err := utils.ParseCurrency(request.Currency)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return [][2]float64{}
 	}
 
-	return value, _getIndexPrice, markData
+	result, err := svc.rawPriceRepo.GetHistoricalVolatility(underlying)
+	if err != nil {
+		return [][2]float64{}
+	}
+
+	return result
+}
+
+func (svc deribitService) GetVolatilityIndexData(ctx context.Context, request model.GetVolatilityIndexDataRequest) (model.GetVolatilityIndexDataResponse, *validation_reason.ValidationReason, error) {
+	underlying, err := utils.ParseCurrency(request.Currency)
+	if err != nil {
+		reason := validation_reason.INVALID_PARAMS
+		return model.GetVolatilityIndexDataResponse{}, &reason, err
+	}
+	request.Currency = underlying
+
+	return svc.rawPriceRepo.GetVolatilityIndexData(request)
 }
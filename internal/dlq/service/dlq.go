@@ -0,0 +1,65 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+
+	"gateway/pkg/kafka/consumer"
+	"gateway/pkg/middleware/api"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/gin-gonic/gin"
+)
+
+type dlqService struct {
+	r *gin.Engine
+}
+
+func NewDLQService(r *gin.Engine) IDLQService {
+	svc := dlqService{r}
+	svc.RegisterRoutes()
+
+	return &svc
+}
+
+func (svc *dlqService) RegisterRoutes() {
+	internalAPI := svc.r.Group("api/internal")
+	internalAPI.Use(api.IPWhitelist(), api.BasicAuth())
+
+	internalAPI.POST("/dlq/replay", svc.handleReplay)
+}
+
+func (svc *dlqService) Replay(limit int) (int, error) {
+	return consumer.ReplayDeadLetters(limit)
+}
+
+// @BasePath /api/internal
+
+// Replay dead letters godoc
+// @Summary Replay the kafka messages of the dead letter topic
+// @Schemes
+// @Description runs the dead letters through the consumer handlers again, from the last replayed one
+// @Tags internal
+// @Produce json
+// @Param limit query int false "maximum number of messages, all by default"
+// @Success 200 {object} map[string]int
+// @Router /dlq/replay [post]
+func (svc *dlqService) handleReplay(c *gin.Context) {
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	replayed, err := svc.Replay(limit)
+	if err != nil {
+		logs.Log.Error().Err(err).Int("replayed", replayed).Msg("failed to replay dead letters")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "replayed": replayed})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"replayed": replayed})
+}
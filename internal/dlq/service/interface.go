@@ -0,0 +1,5 @@
+package service
+
+type IDLQService interface {
+	Replay(limit int) (int, error)
+}
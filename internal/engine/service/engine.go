@@ -27,18 +27,20 @@ import (
 )
 
 type engineHandler struct {
-	redis     *redis.RedisConnectionPool
-	tradeRepo *repositories.TradeRepository
-	wsOBSvc   wsService.IwsOrderbookService
+	redis           *redis.RedisConnectionPool
+	tradeRepo       *repositories.TradeRepository
+	linkedOrderRepo *repositories.LinkedOrderRepository
+	wsOBSvc         wsService.IwsOrderbookService
 }
 
 func NewEngineHandler(
 	r *gin.Engine,
 	redis *redis.RedisConnectionPool,
 	tradeRepo *repositories.TradeRepository,
+	linkedOrderRepo *repositories.LinkedOrderRepository,
 	wsOBSvc wsService.IwsOrderbookService,
 ) IEngineService {
-	return &engineHandler{redis, tradeRepo, wsOBSvc}
+	return &engineHandler{redis, tradeRepo, linkedOrderRepo, wsOBSvc}
 
 }
 func (svc engineHandler) HandleConsume(msg *sarama.ConsumerMessage) {
@@ -115,16 +117,15 @@ func (svc engineHandler) HandleConsume(msg *sarama.ConsumerMessage) {
 	ws.GetEngineSocket().BroadcastMessage(_instrument, data)
 }
 
-func (svc engineHandler) HandleConsumeQuote(msg *sarama.ConsumerMessage) {
+func (svc engineHandler) HandleConsumeQuote(msg *sarama.ConsumerMessage) error {
 	var data _engineType.EngineResponse
 	err := json.Unmarshal(msg.Value, &data)
 	if err != nil {
-		logs.Log.Error().Err(err).Msg("")
-		return
+		return err
 	}
 
 	if data.Matches == nil && len(data.Matches.TakerOrder.Contracts) > 0 {
-		return
+		return nil
 	}
 
 	//convert instrument name
@@ -142,8 +143,7 @@ func (svc engineHandler) HandleConsumeQuote(msg *sarama.ConsumerMessage) {
 	//convert redisDataArray to json
 	jsonBytes, err := json.Marshal(initData)
 	if err != nil {
-		logs.Log.Error().Err(err).Msg("")
-		return
+		return err
 	}
 
 	//save to redis
@@ -157,15 +157,16 @@ func (svc engineHandler) HandleConsumeQuote(msg *sarama.ConsumerMessage) {
 	}
 	method := "subscription"
 	ws.GetQuoteSocket().BroadcastMessage(_instrument, method, params)
+
+	return nil
 }
 
-func (svc engineHandler) HandleConsumeQuoteCancel(msg *sarama.ConsumerMessage) {
+func (svc engineHandler) HandleConsumeQuoteCancel(msg *sarama.ConsumerMessage) error {
 	var data kafka.CancelledOrder
 
 	err := json.Unmarshal(msg.Value, &data)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	for _, order := range data.Data {
@@ -184,8 +185,7 @@ func (svc engineHandler) HandleConsumeQuoteCancel(msg *sarama.ConsumerMessage) {
 		//convert redisDataArray to json
 		jsonBytes, err := json.Marshal(initData)
 		if err != nil {
-			logs.Log.Error().Err(err).Msg("")
-			return
+			return err
 		}
 
 		//save to redis
@@ -200,6 +200,8 @@ func (svc engineHandler) HandleConsumeQuoteCancel(msg *sarama.ConsumerMessage) {
 		method := "subscription"
 		ws.GetQuoteSocket().BroadcastMessage(_instrument, method, params)
 	}
+
+	return nil
 }
 
 func checkDateToRemoveRedis(_expiryDate string, _instrument string, svc engineHandler) {
@@ -273,6 +275,10 @@ func (svc engineHandler) PublishOrder(data _engineType.EngineResponse) {
 		ReduceOnly:          data.Matches.TakerOrder.ReduceOnly,
 	}
 
+	// the links of the order when it belongs to a linked order graph
+	ref := svc.linkedOrderRepo.Ref(data.Matches.TakerOrder.ID, data.Matches.TakerOrder.RequestID)
+	order.OtoOrderIds, order.OcoRef = ref.OtoOrderIds, ref.OcoRef
+
 	ID, _ := strconv.ParseUint(data.Matches.TakerOrder.ClOrdID, 0, 64)
 	connKey := utils.GetKeyFromIdUserID(ID, data.Matches.TakerOrder.UserID.Hex())
 
@@ -6,6 +6,6 @@ import (
 
 type IEngineService interface {
 	HandleConsume(message *sarama.ConsumerMessage)
-	HandleConsumeQuote(message *sarama.ConsumerMessage)
-	HandleConsumeQuoteCancel(message *sarama.ConsumerMessage)
+	HandleConsumeQuote(message *sarama.ConsumerMessage) error
+	HandleConsumeQuoteCancel(message *sarama.ConsumerMessage) error
 }
@@ -77,6 +77,11 @@ type BuySellEditCancelOrder struct {
 	MaxShow             float64            `json:"max_show"`
 	PostOnly            bool               `json:"post_only"`
 	ReduceOnly          bool               `json:"reduce_only"`
+	Trigger             string             `json:"trigger,omitempty"`
+	TriggerPrice        *float64           `json:"trigger_price,omitempty"`
+	TriggerOffset       *float64           `json:"trigger_offset,omitempty"`
+	OtoOrderIds         []string           `json:"oto_order_ids,omitempty"`
+	OcoRef              string             `json:"oco_ref,omitempty"`
 }
 
 type BuySellEditTrade struct {
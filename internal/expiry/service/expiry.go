@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"gateway/internal/deribit/model"
+	_deribitSvc "gateway/internal/deribit/service"
+	_engineType "gateway/internal/engine/types"
+	"gateway/internal/repositories"
+	_tradeType "gateway/internal/repositories/types"
+	_triggerSvc "gateway/internal/trigger/service"
+	"gateway/pkg/constant"
+	"gateway/pkg/expiry"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// the expirations are checked once per interval, a node holds the expirations it cancels
+// until the lock ends
+const (
+	expiryInterval = time.Second
+	expiryLock     = 30 * time.Second
+	expiryBatch    = 100
+)
+
+type expiryService struct {
+	repo        *repositories.OrderExpirationRepository
+	triggerRepo *repositories.TriggerOrderRepository
+	deribitSvc  _deribitSvc.IDeribitService
+	triggerSvc  _triggerSvc.ITriggerService
+}
+
+func NewExpiryService(
+	repo *repositories.OrderExpirationRepository,
+	triggerRepo *repositories.TriggerOrderRepository,
+	deribitSvc _deribitSvc.IDeribitService,
+	triggerSvc _triggerSvc.ITriggerService,
+) IExpiryService {
+	return &expiryService{repo, triggerRepo, deribitSvc, triggerSvc}
+}
+
+// Run cancels the orders whose expiry is reached once per interval, the expirations are
+// kept with their status so the ones reached while the gateway was down are cancelled on
+// the first run
+func (svc expiryService) Run() {
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		expirations, err := svc.repo.FindDue(now, expiryBatch)
+		if err != nil {
+			continue
+		}
+
+		for _, expiration := range expirations {
+			svc.expire(expiration, now)
+		}
+	}
+}
+
+// expire cancels the order of the expiration once, a node which lost the race for the
+// expiration skips it
+func (svc expiryService) expire(expiration *_tradeType.OrderExpiration, now time.Time) {
+	locked, err := svc.repo.Lock(expiration.ID, now, now.Add(expiryLock))
+	if err != nil || locked == nil {
+		return
+	}
+
+	// the engine did not report the order yet, the expiration is retried once the lock ends
+	if locked.OrderID.IsZero() {
+		return
+	}
+
+	// the trigger orders are held by the gateway until they are released
+	if order, _ := svc.triggerRepo.FindById(locked.OrderID.Hex()); order != nil {
+		switch {
+		case svc.triggerSvc.Expire(context.Background(), order.ID.Hex()):
+			svc.repo.UpdateStatus(locked.ID, _tradeType.EXPIRATION_PENDING, _tradeType.EXPIRATION_EXPIRED)
+		case order.Status == _tradeType.TRIGGERED:
+			// the released order gets the expiration once the engine reports it
+		default:
+			svc.repo.UpdateStatus(locked.ID, _tradeType.EXPIRATION_PENDING, _tradeType.EXPIRATION_CLOSED)
+		}
+		return
+	}
+
+	_, err = svc.deribitSvc.DeribitParseCancel(context.Background(), locked.UserID.Hex(), model.DeribitCancelRequest{
+		Id:              locked.OrderID.Hex(),
+		ClOrdID:         strconv.FormatInt(time.Now().UnixNano(), 10),
+		CancelledReason: expiry.CancelReason,
+	})
+	switch {
+	case err == nil:
+		svc.repo.UpdateStatus(locked.ID, _tradeType.EXPIRATION_PENDING, _tradeType.EXPIRATION_EXPIRED)
+	case err.Error() == constant.ORDER_ALREADY_CLOSED || errors.Is(err, mongo.ErrNoDocuments):
+		svc.repo.UpdateStatus(locked.ID, _tradeType.EXPIRATION_PENDING, _tradeType.EXPIRATION_CLOSED)
+	default:
+		logs.Log.Error().Err(err).Str("order_id", locked.OrderID.Hex()).Msg("failed to cancel expired order")
+	}
+}
+
+// HandleConsumeEngineSaved sets the order id of the expirations of the orders the engine
+// placed, the engine echoes the request id the gateway sent the order with. The engine
+// holds the orders as good_til_cancelled, the expirations are found by request id alone
+func (svc expiryService) HandleConsumeEngineSaved(msg *sarama.ConsumerMessage) {
+	var data _engineType.EngineResponse
+	if err := json.Unmarshal(msg.Value, &data); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return
+	}
+
+	if data.Matches == nil || data.Matches.TakerOrder == nil {
+		return
+	}
+
+	taker := data.Matches.TakerOrder
+	if taker.RequestID == "" {
+		return
+	}
+
+	// the order never rests in the book
+	if data.Status == types.ORDER_REJECTED {
+		svc.repo.CloseRequest(taker.RequestID)
+		return
+	}
+
+	if !taker.ID.IsZero() {
+		svc.repo.SetOrderID(taker.RequestID, taker.ID)
+	}
+}
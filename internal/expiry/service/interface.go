@@ -0,0 +1,10 @@
+package service
+
+import (
+	"github.com/Shopify/sarama"
+)
+
+type IExpiryService interface {
+	Run()
+	HandleConsumeEngineSaved(msg *sarama.ConsumerMessage)
+}
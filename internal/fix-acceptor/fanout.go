@@ -0,0 +1,39 @@
+package ordermatch
+
+import (
+	"encoding/json"
+
+	"gateway/internal/engine/types"
+	"gateway/pkg/fanout"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+// userSession only holds the FIX sessions logged on this node, the engine events
+// are replayed on every node so each one answers its own sessions
+const (
+	fanoutEngine      = "fix.engine"
+	fanoutEngineSaved = "fix.engine_saved"
+)
+
+func (a *Application) registerFanout() {
+	fanout.Register(fanoutEngine, func(payload json.RawMessage) {
+		var data types.EngineResponse
+		if err := json.Unmarshal(payload, &data); err != nil {
+			logs.Log.Error().Err(err).Msg("Error parsing JSON")
+			return
+		}
+
+		a.onEngineReceived(data)
+	})
+
+	fanout.Register(fanoutEngineSaved, func(payload json.RawMessage) {
+		var data types.EngineResponse
+		if err := json.Unmarshal(payload, &data); err != nil {
+			logs.Log.Error().Err(err).Msg("Error parsing JSON")
+			return
+		}
+
+		a.onEngineSavedReceived(data)
+	})
+}
@@ -23,8 +23,10 @@ import (
 	_deribitModel "gateway/internal/deribit/model"
 	_deribitSvc "gateway/internal/deribit/service"
 	"gateway/internal/engine/types"
+	"gateway/internal/orderbook/book"
 	"gateway/internal/repositories"
 	_wsSvc "gateway/internal/ws/service"
+	"gateway/pkg/fanout"
 	"gateway/pkg/redis"
 	"gateway/pkg/utils"
 	"io"
@@ -210,6 +212,7 @@ func newApplication(deribit _deribitSvc.IDeribitService, wsSvc _wsSvc.IwsOrderbo
 	app.AddRoute(securitylistrequest.Route(app.onSecurityListRequest))
 	app.AddRoute(tradecapturereportrequest.Route(app.OnTradeCaptureReportRequest))
 	app.AddRoute(quotestatusrequest.Route(app.OnQuoteStatusRequest))
+	app.registerFanout()
 	return app
 }
 
@@ -353,7 +356,8 @@ func (a *Application) onNewOrderSingle(msg newordersingle.NewOrderSingle, sessio
 	}
 
 	tifType := _utilitiesType.GOOD_TIL_CANCELLED
-	if tif == enum.TimeInForce_GOOD_TILL_CANCEL {
+	// the day orders are cancelled by the gateway at the end of the trading day
+	if tif == enum.TimeInForce_DAY {
 		tifType = _utilitiesType.GOOD_TIL_DAY
 	} else if tif == enum.TimeInForce_IMMEDIATE_OR_CANCEL {
 		tifType = _utilitiesType.IMMEDIATE_OR_CANCEL
@@ -703,7 +707,7 @@ func (a *Application) onMarketDataRequest(msg marketdatarequest.MarketDataReques
 			ExpiryDate:     splits[1],
 			StrikePrice:    price,
 		}
-		_orderbook := a.OrderRepository.GetOrderBook(_order)
+		_orderbook := book.Orderbook(_order)
 
 		// 0 means BID / BUY
 		if utils.ArrContains(entries, "0") {
@@ -794,15 +798,44 @@ func (a *Application) onMarketDataRequest(msg marketdatarequest.MarketDataReques
 
 // Hook when ENGINE_SAVED topic received
 func (a *Application) OnEngineSavedReceived(data types.EngineResponse) {
+	a.onEngineSavedReceived(data)
+
+	// the FIX sessions logged on the other gateway nodes
+	fanout.Publish(fanoutEngineSaved, data)
+}
+
+func (a *Application) onEngineSavedReceived(data types.EngineResponse) {
 	// Broadcast Best Bid and Best Ask (market price)
 	takerOrder := data.Matches.TakerOrder
 	instrumentName := takerOrder.Underlying + "-" + takerOrder.ExpiryDate + "-" + fmt.Sprintf("%.0f", takerOrder.StrikePrice) + "-" + string(takerOrder.Contracts[0])
 	a.BroadcastQuoteStatusReport(instrumentName)
 
+	// Broadcast order book, the book in memory has the orders of the event once saved
+	a.BroadcastOrderBook(instrumentName)
+
 	// Broadcast NEW Security List / Instrument Name
 	a.BroadcastSecurityList(instrumentName)
 }
 
+// Hook when ENGINE topic received
+func (a *Application) OnEngineReceived(data types.EngineResponse) {
+	a.onEngineReceived(data)
+
+	// the FIX sessions logged on the other gateway nodes
+	fanout.Publish(fanoutEngine, data)
+}
+
+func (a *Application) onEngineReceived(data types.EngineResponse) {
+	// Handle taker order (if FIX user is subscribing)
+	go a.OrderConfirmation(data)
+
+	// Handle maker order (if FIX user is subscribing)
+	go a.MakerConfirmation(data)
+
+	// FIX Subscription
+	go a.OnTradeHappens(data)
+}
+
 // Hook when Engine topic received
 func (a *Application) OnTradeHappens(data types.EngineResponse) {
 	// Handle Ticks
@@ -812,12 +845,6 @@ func (a *Application) OnTradeHappens(data types.EngineResponse) {
 	// Broadcast LastPx and LastQty
 	// TradeCaptureReport - tradecapturereport.go
 	a.BroadcastTradeCaptureReport(data.Matches.Trades)
-
-	takerOrder := data.Matches.TakerOrder
-	instrumentName := takerOrder.Underlying + "-" + takerOrder.ExpiryDate + "-" + fmt.Sprintf("%.0f", takerOrder.StrikePrice) + "-" + string(takerOrder.Contracts[0])
-
-	// Broadcast order book
-	a.BroadcastOrderBook(instrumentName)
 }
 
 // Broadcast whole order book
@@ -835,7 +862,7 @@ func (a *Application) BroadcastOrderBook(instrument string) {
 		ExpiryDate:     splits[1],
 		StrikePrice:    price,
 	}
-	_orderbook := a.OrderRepository.GetOrderBook(_order)
+	_orderbook := book.Orderbook(_order)
 
 	// Loop Order Book Bids
 	for _, bid := range _orderbook.Bids {
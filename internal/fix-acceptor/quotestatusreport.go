@@ -127,6 +127,7 @@ func (a *Application) OnQuoteStatusRequestSnapshot(symbol string, responseID str
 	}
 
 	orderbook := _orderbookType.GetOrderBook{}
+	orderbook.InstrumentName = symbol
 	orderbook.Underlying = instruments.Underlying
 	orderbook.StrikePrice = instruments.Strike
 	orderbook.ExpiryDate = instruments.ExpDate
@@ -0,0 +1,274 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"gateway/internal/deribit/service"
+	"gateway/internal/repositories"
+	"gateway/pkg/constant"
+	"gateway/pkg/middleware"
+	"gateway/pkg/protocol"
+	"gateway/pkg/utils"
+
+	authService "gateway/internal/user/service"
+	userType "gateway/internal/user/types"
+	wsService "gateway/internal/ws/service"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"github.com/ulule/limiter/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const serviceName = "gateway.Deribit"
+
+type unaryHandler func(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage
+type streamHandler func(method string, input json.RawMessage, stream grpc.ServerStream) error
+
+type grpcHandler struct {
+	deribitSvc service.IDeribitService
+	wsOBSvc    wsService.IwsOrderbookService
+	wsOSvc     wsService.IwsOrderService
+	wsTradeSvc wsService.IwsTradeService
+	userRepo   *repositories.UserRepository
+	limiter    *middleware.MiddlewareGrpc
+
+	methods []grpc.MethodDesc
+	streams []grpc.StreamDesc
+}
+
+func NewGrpcHandler(
+	srv *grpc.Server,
+	deribitSvc service.IDeribitService,
+	wsOBSvc wsService.IwsOrderbookService,
+	wsOSvc wsService.IwsOrderService,
+	wsTradeSvc wsService.IwsTradeService,
+	userRepo *repositories.UserRepository,
+	limiter *limiter.Limiter,
+) {
+	handler := &grpcHandler{
+		deribitSvc: deribitSvc,
+		wsOBSvc:    wsOBSvc,
+		wsOSvc:     wsOSvc,
+		wsTradeSvc: wsTradeSvc,
+		userRepo:   userRepo,
+		limiter:    middleware.RateLimiterGrpc(limiter),
+	}
+
+	handler.RegisterPrivate()
+	handler.RegisterPublic()
+	handler.RegisterStream()
+
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: serviceName,
+		HandlerType: (*interface{})(nil),
+		Methods:     handler.methods,
+		Streams:     handler.streams,
+	}, handler)
+}
+
+// RegisterHandler exposes the json-rpc method as an unary rpc, e.g. /gateway.Deribit/Buy
+func (h *grpcHandler) RegisterHandler(name, method string, fn unaryHandler) {
+	h.methods = append(h.methods, grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			var input json.RawMessage
+			if err := dec(&input); err != nil {
+				return nil, err
+			}
+
+			logs.Log.Info().Str("called_method", method).Msg("")
+
+			if res := h.limiter.Handle(ctx, requestID(input)); res != nil {
+				return *res, nil
+			}
+
+			if interceptor == nil {
+				return fn(ctx, method, input), nil
+			}
+
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + serviceName + "/" + name,
+			}
+			return interceptor(ctx, input, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return fn(ctx, method, req.(json.RawMessage)), nil
+			})
+		},
+	})
+}
+
+// RegisterStreamHandler exposes the subscription as a server streaming rpc
+func (h *grpcHandler) RegisterStreamHandler(name, method string, fn streamHandler) {
+	h.streams = append(h.streams, grpc.StreamDesc{
+		StreamName:    name,
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			var input json.RawMessage
+			if err := stream.RecvMsg(&input); err != nil {
+				return err
+			}
+
+			logs.Log.Info().Str("called_method", method).Msg("")
+
+			if res := h.limiter.Handle(stream.Context(), requestID(input)); res != nil {
+				return stream.SendMsg(*res)
+			}
+
+			return fn(method, input, stream)
+		},
+	})
+}
+
+// requestID reads the json-rpc id of the request, 0 when it has none
+func requestID(input json.RawMessage) uint64 {
+	var msg struct {
+		Id uint64 `json:"id"`
+	}
+	json.Unmarshal(input, &msg)
+
+	return msg.Id
+}
+
+func requestHelper(
+	ctx context.Context,
+	msgID uint64,
+	method string,
+	accessToken *string,
+) (claim userType.JwtClaim, connKey string, result chan protocol.RPCResponseMessage, reason *validation_reason.ValidationReason, err error) {
+	result = make(chan protocol.RPCResponseMessage, 1)
+
+	key := utils.GetKeyFromIdUserID(msgID, "")
+	if isDuplicateConnection := protocol.RegisterProtocolRequest(
+		key, protocol.ProtocolRequest{Grpc: result, Protocol: protocol.GRPC, Method: method},
+	); isDuplicateConnection {
+		validation := validation_reason.DUPLICATED_REQUEST_ID
+		reason = &validation
+
+		err = errors.New(validation.String())
+		return
+	}
+
+	if accessToken == nil {
+		connKey = key
+		return
+	}
+
+	token := *accessToken
+	if token == "" {
+		token = tokenFromMetadata(ctx)
+	}
+
+	claim, err = authService.ClaimJWT(nil, token)
+	if err != nil {
+		connKey = key
+		validation := validation_reason.UNAUTHORIZED
+		reason = &validation
+		return
+	}
+
+	connKey = utils.GetKeyFromIdUserID(msgID, claim.UserID)
+	if isDuplicateConnection := protocol.UpgradeProtocol(key, connKey); isDuplicateConnection {
+		validation := validation_reason.DUPLICATED_REQUEST_ID
+		reason = &validation
+
+		err = errors.New(validation.String())
+		return
+	}
+
+	return
+}
+
+// tokenFromMetadata reads the access token from the `authorization: Bearer <token>` metadata
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	for _, value := range md.Get("authorization") {
+		if strings.HasPrefix(strings.ToLower(value), "bearer ") {
+			return strings.TrimSpace(value[len("bearer "):])
+		}
+	}
+
+	return ""
+}
+
+// waitResponse waits until the response is sent through the protocol registry,
+// the request is answered with a time out validation after constant.TIMEOUT
+func waitResponse(ctx context.Context, msgId uint64, connKey string, result chan protocol.RPCResponseMessage) protocol.RPCResponseMessage {
+	ctx, cancel := context.WithTimeout(ctx, constant.TIMEOUT)
+	defer cancel()
+
+	select {
+	case res := <-result:
+		return res
+	case <-ctx.Done():
+		return sendValidationMsg(msgId, connKey, result, validation_reason.TIME_OUT, errors.New(validation_reason.TIME_OUT.String()))
+	}
+}
+
+// sendValidationMsg answers the request through the protocol registry and reads back the response
+func sendValidationMsg(
+	msgId uint64,
+	connKey string,
+	result chan protocol.RPCResponseMessage,
+	reason validation_reason.ValidationReason,
+	err error,
+) protocol.RPCResponseMessage {
+	protocol.SendValidationMsg(connKey, reason, err)
+
+	// doSend writes into the buffered channel before returning, if the connection
+	// was already answered (or taken over) the response is built here instead
+	select {
+	case res := <-result:
+		return res
+	default:
+		return invalidRequestMessage(err, msgId, reason)
+	}
+}
+
+func sendSuccessMsg(msgId uint64, connKey string, result chan protocol.RPCResponseMessage, res any) protocol.RPCResponseMessage {
+	protocol.SendSuccessMsg(connKey, res)
+
+	select {
+	case m := <-result:
+		return m
+	default:
+		return protocol.RPCResponseMessage{JSONRPC: "2.0", ID: msgId, Result: res, Testnet: true}
+	}
+}
+
+func sendErrMsg(msgId uint64, connKey string, result chan protocol.RPCResponseMessage, err error) protocol.RPCResponseMessage {
+	protocol.SendErrMsg(connKey, err)
+
+	select {
+	case res := <-result:
+		return res
+	default:
+		return invalidRequestMessage(err, msgId, validation_reason.OTHER)
+	}
+}
+
+func invalidRequestMessage(err error, msgId uint64, reason validation_reason.ValidationReason) protocol.RPCResponseMessage {
+	code, httpCode, codeStr := reason.Code()
+
+	return protocol.RPCResponseMessage{
+		JSONRPC: "2.0",
+		ID:      msgId,
+		Error: &protocol.ErrorMessage{
+			Message: err.Error(),
+			Data: protocol.ReasonMessage{
+				Reason: codeStr,
+			},
+			Code:           code,
+			HttpStatusCode: httpCode,
+		},
+		Testnet: true,
+	}
+}
@@ -0,0 +1,545 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	deribitModel "gateway/internal/deribit/model"
+	"gateway/pkg/constant"
+	"gateway/pkg/protocol"
+	"gateway/pkg/utils"
+
+	confType "github.com/Undercurrent-Technologies/kprime-utilities/config/types"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (handler *grpcHandler) RegisterPrivate() {
+	handler.RegisterHandler("Buy", "private/buy", handler.order(types.BUY))
+	handler.RegisterHandler("Sell", "private/sell", handler.order(types.SELL))
+	handler.RegisterHandler("Edit", "private/edit", handler.edit)
+	handler.RegisterHandler("Cancel", "private/cancel", handler.cancel)
+	handler.RegisterHandler("CancelAllByInstrument", "private/cancel_all_by_instrument", handler.cancelByInstrument)
+	handler.RegisterHandler("CancelAll", "private/cancel_all", handler.cancelAll)
+	handler.RegisterHandler("GetUserTradesByInstrument", "private/get_user_trades_by_instrument", handler.getUserTradesByInstrument)
+	handler.RegisterHandler("GetOpenOrdersByInstrument", "private/get_open_orders_by_instrument", handler.getOpenOrdersByInstrument)
+	handler.RegisterHandler("GetOrderHistoryByInstrument", "private/get_order_history_by_instrument", handler.getOrderHistoryByInstrument)
+	handler.RegisterHandler("GetOrderStateByLabel", "private/get_order_state_by_label", handler.getOrderStateByLabel)
+	handler.RegisterHandler("GetOrderState", "private/get_order_state", handler.getOrderState)
+	handler.RegisterHandler("GetUserTradesByOrder", "private/get_user_trades_by_order", handler.getUserTradesByOrder)
+	handler.RegisterHandler("GetAccountSummary", "private/get_account_summary", handler.getAccountSummary)
+
+	handler.RegisterHandler("GetInstruments", "private/get_instruments", handler.getInstruments)
+	handler.RegisterHandler("GetOrderBook", "private/get_order_book", handler.getOrderBook)
+	handler.RegisterHandler("GetTradingviewChartData", "private/get_tradingview_chart_data", handler.getTradingviewChartData)
+}
+
+func (h *grpcHandler) order(side types.Side) unaryHandler {
+	return func(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+		var msg deribitModel.RequestDto[deribitModel.RequestParams]
+		if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+			return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+		}
+
+		maxShow := 0.1
+		if msg.Params.MaxShow == nil {
+			msg.Params.MaxShow = &maxShow
+		}
+
+		if strings.ToLower(string(msg.Params.Type)) == string(types.LIMIT) && msg.Params.Price == 0 {
+			err := errors.New(validation_reason.PRICE_IS_REQUIRED.String())
+			return invalidRequestMessage(err, msg.Id, validation_reason.PRICE_IS_REQUIRED)
+		}
+
+		if err := utils.ValidateDeribitRequestParam(msg.Params); err != nil {
+			return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+		}
+
+		claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+		if err != nil {
+			if connKey != "" {
+				return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+			}
+			return invalidRequestMessage(err, msg.Id, *reason)
+		}
+
+		_, validation, err := h.deribitSvc.DeribitRequest(ctx, claim.UserID, deribitModel.DeribitRequest{
+			InstrumentName: msg.Params.InstrumentName,
+			Amount:         msg.Params.Amount,
+			Type:           msg.Params.Type,
+			Price:          msg.Params.Price,
+			ClOrdID:        strconv.FormatUint(msg.Id, 10),
+			TimeInForce:    msg.Params.TimeInForce,
+			Label:          msg.Params.Label,
+			Side:           side,
+			MaxShow:        *msg.Params.MaxShow,
+			ReduceOnly:     msg.Params.ReduceOnly,
+			Trigger:        msg.Params.Trigger,
+			TriggerPrice:   msg.Params.TriggerPrice,
+			TriggerOffset:  msg.Params.TriggerOffset,
+			ExpireAt:       msg.Params.ExpireAt,
+			PostOnly:       msg.Params.PostOnly,
+
+			LinkedOrderType: msg.Params.LinkedOrderType,
+			OtocoConfig:     msg.Params.OtocoConfig,
+		})
+		if err != nil {
+			if validation != nil {
+				return sendValidationMsg(msg.Id, connKey, result, *validation, err)
+			}
+
+			return sendErrMsg(msg.Id, connKey, result, err)
+		}
+
+		// the engine reply reaches the caller through the protocol registry
+		return waitResponse(ctx, msg.Id, connKey, result)
+	}
+}
+
+func (h *grpcHandler) edit(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.EditParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	// Validate order id, make sure it's a valid order id (Mongodb object id)
+	if _, err := primitive.ObjectIDFromHex(msg.Params.OrderId); err != nil {
+		return invalidRequestMessage(errors.New(constant.INVALID_ORDER_ID), msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	_, reason, err = h.deribitSvc.DeribitParseEdit(ctx, claim.UserID, deribitModel.DeribitEditRequest{
+		Id:      msg.Params.OrderId,
+		Price:   msg.Params.Price,
+		Amount:  msg.Params.Amount,
+		ClOrdID: strconv.FormatUint(msg.Id, 10),
+	})
+	if err != nil {
+		if reason != nil {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+
+		return sendErrMsg(msg.Id, connKey, result, err)
+	}
+
+	return waitResponse(ctx, msg.Id, connKey, result)
+}
+
+func (h *grpcHandler) cancel(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.CancelParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	// Validate order id, make sure it's a valid order id (Mongodb object id)
+	if _, err := primitive.ObjectIDFromHex(msg.Params.OrderId); err != nil {
+		return invalidRequestMessage(errors.New(constant.INVALID_ORDER_ID), msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	_, err = h.deribitSvc.DeribitParseCancel(ctx, claim.UserID, deribitModel.DeribitCancelRequest{
+		Id:      msg.Params.OrderId,
+		ClOrdID: strconv.FormatUint(msg.Id, 10),
+	})
+	if err != nil {
+		return sendErrMsg(msg.Id, connKey, result, err)
+	}
+
+	return waitResponse(ctx, msg.Id, connKey, result)
+}
+
+func (h *grpcHandler) cancelByInstrument(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.CancelByInstrumentParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	if _, err := utils.ParseInstruments(msg.Params.InstrumentName, false); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	_, err = h.deribitSvc.DeribitCancelByInstrument(ctx, claim.UserID, deribitModel.DeribitCancelByInstrumentRequest{
+		InstrumentName: msg.Params.InstrumentName,
+		Type:           types.Type(msg.Params.Type),
+		ClOrdID:        strconv.FormatUint(msg.Id, 10),
+	})
+	if err != nil {
+		return sendErrMsg(msg.Id, connKey, result, err)
+	}
+
+	return waitResponse(ctx, msg.Id, connKey, result)
+}
+
+func (h *grpcHandler) cancelAll(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.CancelOnDisconnectParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	_, err = h.deribitSvc.DeribitParseCancelAll(ctx, claim.UserID, deribitModel.DeribitCancelAllRequest{
+		ClOrdID: strconv.FormatUint(msg.Id, 10),
+	})
+	if err != nil {
+		return sendErrMsg(msg.Id, connKey, result, err)
+	}
+
+	return waitResponse(ctx, msg.Id, connKey, result)
+}
+
+func (h *grpcHandler) getUserTradesByInstrument(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetUserTradesByInstrumentParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	// Number of requested items, default - 10
+	if msg.Params.Count <= 0 {
+		msg.Params.Count = 10
+	}
+
+	if _, err = utils.ParseInstruments(msg.Params.InstrumentName, false); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+
+	res := h.deribitSvc.DeribitGetUserTradesByInstrument(
+		ctx,
+		claim.UserID,
+		deribitModel.DeribitGetUserTradesByInstrumentsRequest{
+			InstrumentName: msg.Params.InstrumentName,
+			Count:          msg.Params.Count,
+			StartTimestamp: msg.Params.StartTimestamp,
+			EndTimestamp:   msg.Params.EndTimestamp,
+			Sorting:        msg.Params.Sorting,
+		},
+	)
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getOpenOrdersByInstrument(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetOpenOrdersByInstrumentParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if _, err = utils.ParseInstruments(msg.Params.InstrumentName, false); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+
+	if msg.Params.Type == "" {
+		msg.Params.Type = "all"
+	}
+
+	res := h.deribitSvc.DeribitGetOpenOrdersByInstrument(
+		ctx,
+		claim.UserID,
+		deribitModel.DeribitGetOpenOrdersByInstrumentRequest{
+			InstrumentName: msg.Params.InstrumentName,
+			Type:           msg.Params.Type,
+		},
+	)
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getOrderHistoryByInstrument(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetOrderHistoryByInstrumentParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	// Number of requested items, default - 20
+	if msg.Params.Count <= 0 {
+		msg.Params.Count = 20
+	}
+
+	if _, err = utils.ParseInstruments(msg.Params.InstrumentName, false); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+
+	res := h.deribitSvc.DeribitGetOrderHistoryByInstrument(
+		ctx,
+		claim.UserID,
+		deribitModel.DeribitGetOrderHistoryByInstrumentRequest{
+			InstrumentName:  msg.Params.InstrumentName,
+			Count:           msg.Params.Count,
+			Offset:          msg.Params.Offset,
+			IncludeOld:      msg.Params.IncludeOld,
+			IncludeUnfilled: msg.Params.IncludeUnfilled,
+		},
+	)
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getOrderStateByLabel(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.DeribitGetOrderStateByLabelRequest]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.PARSE_ERROR)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	currency, ok := confType.Pair(msg.Params.Currency).CurrencyCheck()
+	if !ok {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid currency"))
+	}
+
+	msg.Params.UserId = claim.UserID
+	msg.Params.Currency = currency
+
+	res := h.deribitSvc.DeribitGetOrderStateByLabel(ctx, msg.Params)
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getOrderState(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetOrderStateParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.PARSE_ERROR)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if _, err = primitive.ObjectIDFromHex(msg.Params.OrderId); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid order_id"))
+	}
+
+	res := h.deribitSvc.DeribitGetOrderState(
+		ctx,
+		claim.UserID,
+		deribitModel.DeribitGetOrderStateRequest{
+			OrderId: msg.Params.OrderId,
+		},
+	)
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getUserTradesByOrder(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetUserTradesByOrderParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.PARSE_ERROR)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if _, err = primitive.ObjectIDFromHex(msg.Params.OrderId); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid order_id"))
+	}
+
+	res := h.deribitSvc.DeribitGetUserTradesByOrder(
+		ctx,
+		claim.UserID,
+		deribitModel.DeribitGetUserTradesByOrderRequest{
+			OrderId: msg.Params.OrderId,
+			Sorting: msg.Params.Sorting,
+		},
+	)
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getAccountSummary(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetAccountSummary]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	res := h.deribitSvc.FetchUserBalance(msg.Params.Currency, claim.UserID)
+	balance, _ := strconv.ParseFloat(res.Balance, 64)
+
+	resp := deribitModel.GetAccountSummaryResponse{
+		Id:                claim.UserID,
+		Currency:          msg.Params.Currency,
+		Balance:           balance,
+		MarginBalance:     balance,
+		CreationTimestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+
+	if user, _ := h.userRepo.FindById(ctx, claim.UserID); user != nil {
+		resp.Email = user.Email
+	}
+
+	return sendSuccessMsg(msg.Id, connKey, result, resp)
+}
+
+func (h *grpcHandler) getInstruments(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetInstrumentsParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	currency, ok := confType.Pair(msg.Params.Currency).CurrencyCheck()
+	if !ok {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid currency"))
+	}
+
+	if msg.Params.Kind != "" && strings.ToLower(msg.Params.Kind) != "option" {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid value of kind"))
+	}
+
+	if msg.Params.IncludeSpots {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid value of include_spots"))
+	}
+
+	res := h.deribitSvc.DeribitGetInstruments(ctx, deribitModel.DeribitGetInstrumentsRequest{
+		Currency: currency,
+		Expired:  msg.Params.Expired,
+		UserId:   claim.UserID,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getOrderBook(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetOrderBookParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.PARSE_ERROR)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if instruments, _ := utils.ParseInstruments(msg.Params.InstrumentName, false); instruments == nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("instrument not found"))
+	}
+
+	res := h.deribitSvc.GetOrderBook(ctx, deribitModel.DeribitGetOrderBookRequest{
+		InstrumentName: msg.Params.InstrumentName,
+		Depth:          msg.Params.Depth,
+		Interval:       msg.Params.Interval,
+		UserId:         claim.UserID,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getTradingviewChartData(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetTradingviewChartDataRequest]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, &msg.Params.AccessToken)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if _, err = utils.ParseInstruments(msg.Params.InstrumentName, false); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+	msg.Params.UserId = claim.UserID
+
+	res, reason, err := h.deribitSvc.GetTradingViewChartData(ctx, msg.Params)
+	if err != nil {
+		if reason != nil {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+
+		return sendErrMsg(msg.Id, connKey, result, err)
+	}
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
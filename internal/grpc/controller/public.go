@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	deribitModel "gateway/internal/deribit/model"
+	"gateway/pkg/protocol"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/config/types"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+)
+
+func (handler *grpcHandler) RegisterPublic() {
+	handler.RegisterHandler("GetIndexPrice", "public/get_index_price", handler.getIndexPrice)
+	handler.RegisterHandler("GetLastTradesByInstrument", "public/get_last_trades_by_instrument", handler.getLastTradesByInstrument)
+	handler.RegisterHandler("GetDeliveryPrices", "public/get_delivery_prices", handler.getDeliveryPrices)
+	handler.RegisterHandler("GetOptionChain", "public/get_option_chain", handler.getOptionChain)
+	handler.RegisterHandler("GetVolatilitySurface", "public/get_volatility_surface", handler.getVolatilitySurface)
+	handler.RegisterHandler("GetHistoricalVolatility", "public/get_historical_volatility", handler.getHistoricalVolatility)
+	handler.RegisterHandler("GetVolatilityIndexData", "public/get_volatility_index_data", handler.getVolatilityIndexData)
+	handler.RegisterHandler("GetTime", "public/get_time", handler.getTime)
+}
+
+func (h *grpcHandler) getIndexPrice(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetIndexPriceParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	_, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, nil)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if !types.Pair(msg.Params.IndexName).IsValid() {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid index_name"))
+	}
+
+	res := h.deribitSvc.GetIndexPrice(ctx, deribitModel.DeribitGetIndexPriceRequest{
+		IndexName: msg.Params.IndexName,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getLastTradesByInstrument(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetLastTradesByInstrumentParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	_, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, nil)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	instruments, err := utils.ParseInstruments(msg.Params.InstrumentName, false)
+	if err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+
+	if instruments == nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid instrument_name"))
+	}
+
+	res := h.deribitSvc.DeribitGetLastTradesByInstrument(ctx, deribitModel.DeribitGetLastTradesByInstrumentRequest{
+		InstrumentName: msg.Params.InstrumentName,
+		StartSeq:       msg.Params.StartSeq,
+		EndSeq:         msg.Params.EndSeq,
+		StartTimestamp: msg.Params.StartTimestamp,
+		EndTimestamp:   msg.Params.EndTimestamp,
+		Count:          msg.Params.Count,
+		Sorting:        msg.Params.Sorting,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getDeliveryPrices(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.DeliveryPricesParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	_, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, nil)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if !types.Pair(msg.Params.IndexName).IsValid() {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, errors.New("invalid index_name"))
+	}
+
+	res := h.deribitSvc.GetDeliveryPrices(ctx, deribitModel.DeliveryPricesRequest{
+		IndexName: msg.Params.IndexName,
+		Offset:    msg.Params.Offset,
+		Count:     msg.Params.Count,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getOptionChain(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetOptionChainParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	_, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, nil)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if _, _, err := utils.ParseExpiry(msg.Params.Currency, msg.Params.Expiry, false); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+
+	res := h.deribitSvc.GetOptionChain(ctx, deribitModel.GetOptionChainRequest{
+		Currency: msg.Params.Currency,
+		Expiry:   msg.Params.Expiry,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getVolatilitySurface(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetVolatilitySurfaceParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	_, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, nil)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if _, err := utils.ParseCurrency(msg.Params.Currency); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+
+	res := h.deribitSvc.GetVolatilitySurface(ctx, deribitModel.GetVolatilitySurfaceRequest{
+		Currency: msg.Params.Currency,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getHistoricalVolatility(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetHistoricalVolatilityParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	_, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, nil)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	if _, err := utils.ParseCurrency(msg.Params.Currency); err != nil {
+		return sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err)
+	}
+
+	res := h.deribitSvc.GetHistoricalVolatility(ctx, deribitModel.GetHistoricalVolatilityRequest{
+		Currency: msg.Params.Currency,
+	})
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getVolatilityIndexData(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[deribitModel.GetVolatilityIndexDataParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	_, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, nil)
+	if err != nil {
+		if connKey != "" {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+		return invalidRequestMessage(err, msg.Id, *reason)
+	}
+
+	res, reason, err := h.deribitSvc.GetVolatilityIndexData(ctx, deribitModel.GetVolatilityIndexDataRequest{
+		Currency:       msg.Params.Currency,
+		StartTimestamp: msg.Params.StartTimestamp,
+		EndTimestamp:   msg.Params.EndTimestamp,
+		Resolution:     msg.Params.Resolution,
+	})
+	if err != nil {
+		if reason != nil {
+			return sendValidationMsg(msg.Id, connKey, result, *reason, err)
+		}
+
+		return sendErrMsg(msg.Id, connKey, result, err)
+	}
+
+	return sendSuccessMsg(msg.Id, connKey, result, res)
+}
+
+func (h *grpcHandler) getTime(ctx context.Context, method string, input json.RawMessage) protocol.RPCResponseMessage {
+	var msg deribitModel.RequestDto[interface{}]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		return invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS)
+	}
+
+	return protocol.RPCResponseMessage{
+		JSONRPC: "2.0",
+		ID:      msg.Id,
+		Result:  time.Now().UnixMilli(),
+		Testnet: true,
+	}
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	deribitModel "gateway/internal/deribit/model"
+	wsService "gateway/internal/ws/service"
+	"gateway/pkg/constant"
+	"gateway/pkg/utils"
+	"gateway/pkg/ws"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"google.golang.org/grpc"
+)
+
+func (handler *grpcHandler) RegisterStream() {
+	handler.RegisterStreamHandler("SubscribeBook", "public/subscribe", handler.subscribe("book", false))
+	handler.RegisterStreamHandler("SubscribeTicker", "public/subscribe", handler.subscribe("ticker", false))
+	handler.RegisterStreamHandler("SubscribeTrades", "public/subscribe", handler.subscribe("trades", false))
+	handler.RegisterStreamHandler("SubscribeUserOrders", "private/subscribe", handler.subscribe("user.orders", true))
+}
+
+// subscribe streams the notifications of the channels prefixed by kind, the first
+// message is the json-rpc answer with the subscribed channels
+func (h *grpcHandler) subscribe(kind string, private bool) streamHandler {
+	return func(method string, input json.RawMessage, stream grpc.ServerStream) error {
+		ctx := stream.Context()
+
+		var msg deribitModel.RequestDto[deribitModel.ChannelParams]
+		if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+			return stream.SendMsg(invalidRequestMessage(err, msg.Id, validation_reason.INVALID_PARAMS))
+		}
+
+		var accessToken *string
+		if private {
+			accessToken = &msg.Params.AccessToken
+		}
+
+		claim, connKey, result, reason, err := requestHelper(ctx, msg.Id, method, accessToken)
+		if err != nil {
+			if connKey != "" {
+				return stream.SendMsg(sendValidationMsg(msg.Id, connKey, result, *reason, err))
+			}
+			return stream.SendMsg(invalidRequestMessage(err, msg.Id, *reason))
+		}
+
+		channels := []string{}
+		for _, channel := range msg.Params.Channels {
+			if err := validateChannel(kind, channel); err != nil {
+				return stream.SendMsg(sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err))
+			}
+			channels = append(channels, channel)
+		}
+
+		if len(channels) == 0 {
+			err := fmt.Errorf("no channel for '%s'", kind)
+			return stream.SendMsg(sendValidationMsg(msg.Id, connKey, result, validation_reason.INVALID_PARAMS, err))
+		}
+
+		if err := stream.SendMsg(sendSuccessMsg(msg.Id, connKey, result, channels)); err != nil {
+			return err
+		}
+
+		c := ws.NewStreamClient()
+		defer func() {
+			c.CloseStream()
+
+			// release broadcasts which are still sending to the closed stream
+			go func() {
+				timer := time.NewTimer(constant.TIMEOUT)
+				defer timer.Stop()
+				for {
+					select {
+					case <-c.Messages():
+					case <-timer.C:
+						return
+					}
+				}
+			}()
+		}()
+
+		for _, channel := range channels {
+			s := strings.Split(channel, ".")
+
+			switch kind {
+			case "book":
+				if len(s) == 5 {
+					h.wsOBSvc.SubscribeBookGroup(c, channel, s[1], s[2], s[3], s[4])
+				} else {
+					h.wsOBSvc.SubscribeBook(c, channel, s[1], s[2])
+				}
+			case "ticker":
+				h.wsOBSvc.SubscribeTicker(c, channel, s[1], s[2])
+			case "trades":
+				h.wsTradeSvc.SubscribeTrades(c, channel)
+			case "user.orders":
+				h.wsOSvc.SubscribeUserOrder(c, channel, claim.UserID)
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case m := <-c.Messages():
+				if err := stream.SendMsg(m); err != nil {
+					logs.Log.Error().Err(err).Msg("")
+					return err
+				}
+			}
+		}
+	}
+}
+
+func validateChannel(kind, channel string) error {
+	const t = true
+	interval := map[string]bool{"raw": t, "100ms": t, "agg2": t}
+
+	s := strings.Split(channel, ".")
+
+	// book.{instrument}.{group}.{depth}.{interval}
+	if kind == "book" && len(s) == 5 && s[0] == kind {
+		if _, err := utils.ParseInstruments(s[1], true); err != nil {
+			return err
+		}
+
+		_, _, _, err := wsService.ParseBookGroup(s[2], s[3], s[4])
+		return err
+	}
+
+	// book.{instrument}.{interval} or user.orders.{instrument}.{interval}
+	size := len(strings.Split(kind, ".")) + 2
+	if !strings.HasPrefix(channel, kind+".") {
+		return fmt.Errorf("unrecognize channel for '%s'", channel)
+	}
+
+	switch {
+	case len(s) == size:
+		if _, err := utils.ParseInstruments(s[size-2], true); err != nil {
+			return err
+		}
+	// trades.{kind}.{currency}.{interval} or user.orders.{kind}.{currency}.{interval}
+	case len(s) == size+1 && (kind == "trades" || kind == "user.orders"):
+		if _, err := wsService.ParseScope(s[size-2], s[size-1]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unrecognize channel for '%s'", channel)
+	}
+
+	if _, ok := interval[s[len(s)-1]]; !ok {
+		return errors.New(constant.INVALID_INTERVAL)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"gateway/internal/deribit/model"
+	_deribitSvc "gateway/internal/deribit/service"
+	_orderbookType "gateway/internal/orderbook/types"
+	"gateway/internal/repositories"
+	_triggerSvc "gateway/internal/trigger/service"
+	_wsSvc "gateway/internal/ws/service"
+	"gateway/pkg/constant"
+	"gateway/pkg/expiry"
+	"gateway/pkg/pricing"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+// the state published on the instrument.state channels once an instrument expired
+const instrumentClosed = "closed"
+
+// the instruments are read again once per refresh, a node holds the instrument it closes
+// until the lock ends, the cancels of the orders still resting by then are sent again
+const (
+	instrumentRefresh = time.Minute
+	instrumentLock    = 5 * time.Minute
+)
+
+// instrumentOrders are the orders of the instruments, held by the order repository
+type instrumentOrders interface {
+	GetUnderlyings() ([]string, error)
+	GetCurrencyInstruments(underlying string) ([]*utils.Instruments, error)
+	GetRestingInstruments() ([]*utils.Instruments, error)
+	GetRestingOrders(instrument utils.Instruments) ([]*_orderbookType.Order, error)
+}
+
+// untriggeredOrders are the trigger orders held by the gateway
+type untriggeredOrders interface {
+	GetUntriggeredInstruments() ([]string, error)
+}
+
+// instrumentExpiries are the closed instruments, held by the instrument expiry repository
+type instrumentExpiries interface {
+	Lock(instrumentName string, now, until time.Time) bool
+	Close(instrumentName string, now time.Time) bool
+	Closed() (map[string]bool, error)
+}
+
+type instrumentService struct {
+	orderRepo   instrumentOrders
+	triggerRepo untriggeredOrders
+	repo        instrumentExpiries
+	deribitSvc  _deribitSvc.IDeribitService
+	triggerSvc  _triggerSvc.ITriggerService
+	wsOBSvc     _wsSvc.IwsOrderbookService
+}
+
+func NewInstrumentService(
+	orderRepo *repositories.OrderRepository,
+	triggerRepo *repositories.TriggerOrderRepository,
+	repo *repositories.InstrumentExpiryRepository,
+	deribitSvc _deribitSvc.IDeribitService,
+	triggerSvc _triggerSvc.ITriggerService,
+	wsOBSvc _wsSvc.IwsOrderbookService,
+) IInstrumentService {
+	return &instrumentService{orderRepo, triggerRepo, repo, deribitSvc, triggerSvc, wsOBSvc}
+}
+
+// Run closes the instruments at their expiry, it wakes up at the earliest expiry or once
+// per refresh for the instruments ordered in the meantime, the instruments which expired
+// while the gateway was down are closed on the first run
+func (svc instrumentService) Run() {
+	for {
+		now := time.Now()
+		wait := instrumentRefresh
+		if next := svc.closeExpired(now); !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+// closeExpired closes the expired instruments, it returns the earliest expiry still ahead.
+// A closed instrument is visited again only for the orders still resting on it
+func (svc instrumentService) closeExpired(now time.Time) (next time.Time) {
+	instruments := make(map[string]*utils.Instruments)
+
+	underlyings, err := svc.orderRepo.GetUnderlyings()
+	if err != nil {
+		return
+	}
+	for _, underlying := range underlyings {
+		ordered, err := svc.orderRepo.GetCurrencyInstruments(underlying)
+		if err != nil {
+			return
+		}
+		for _, instrument := range ordered {
+			instruments[instrument.Name()] = instrument
+		}
+	}
+
+	resting := make(map[string]bool)
+	restingInstruments, err := svc.orderRepo.GetRestingInstruments()
+	if err != nil {
+		return
+	}
+	for _, instrument := range restingInstruments {
+		instruments[instrument.Name()] = instrument
+		resting[instrument.Name()] = true
+	}
+
+	// the untriggered orders are held by the gateway
+	names, err := svc.triggerRepo.GetUntriggeredInstruments()
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		resting[name] = true
+		if _, ok := instruments[name]; ok {
+			continue
+		}
+		if instrument, err := utils.ParseInstruments(name, false); err == nil {
+			instruments[name] = instrument
+		}
+	}
+
+	closed, err := svc.repo.Closed()
+	if err != nil {
+		return
+	}
+
+	for name, instrument := range instruments {
+		at, err := pricing.Expiry(instrument.ExpDate)
+		if err != nil {
+			logs.Log.Error().Err(err).Str("instrument", name).Msg("failed to read instrument expiry")
+			continue
+		}
+
+		if now.Before(at) {
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+			continue
+		}
+
+		if closed[name] && !resting[name] {
+			continue
+		}
+
+		svc.close(instrument, now)
+	}
+
+	return
+}
+
+// close cancels the resting orders of the expired instrument and publishes its state once,
+// a node which lost the race for the instrument skips it
+func (svc instrumentService) close(instrument *utils.Instruments, now time.Time) {
+	name := instrument.Name()
+	if !svc.repo.Lock(name, now, now.Add(instrumentLock)) {
+		return
+	}
+
+	svc.triggerSvc.CloseInstrument(context.Background(), name)
+
+	// the orders are cancelled one by one, the cancel by instrument rejects the expired
+	// instruments
+	orders, err := svc.orderRepo.GetRestingOrders(*instrument)
+	if err != nil {
+		return
+	}
+	for _, order := range orders {
+		_, err := svc.deribitSvc.DeribitParseCancel(context.Background(), order.UserID.Hex(), model.DeribitCancelRequest{
+			Id:              order.ID.Hex(),
+			ClOrdID:         strconv.FormatInt(time.Now().UnixNano(), 10),
+			CancelledReason: expiry.InstrumentCancelReason,
+		})
+		if err != nil && err.Error() != constant.ORDER_ALREADY_CLOSED {
+			logs.Log.Error().Err(err).Str("order_id", order.ID.Hex()).Msg("failed to cancel order of expired instrument")
+		}
+	}
+
+	if svc.repo.Close(name, now) {
+		svc.wsOBSvc.PublishInstrumentState(name, instrumentClosed, now.UnixMilli())
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gateway/internal/deribit/model"
+	_deribitSvc "gateway/internal/deribit/service"
+	_orderbookType "gateway/internal/orderbook/types"
+	_triggerSvc "gateway/internal/trigger/service"
+	_wsSvc "gateway/internal/ws/service"
+	"gateway/pkg/expiry"
+	"gateway/pkg/pricing"
+	"gateway/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeOrders holds the ordered instruments, the resting orders and the untriggered orders
+// by instrument
+type fakeOrders struct {
+	ordered     []string
+	resting     map[string][]*_orderbookType.Order
+	untriggered []string
+}
+
+func (f *fakeOrders) GetUnderlyings() ([]string, error) {
+	return []string{"BTC", "ETH"}, nil
+}
+
+func (f *fakeOrders) GetCurrencyInstruments(underlying string) ([]*utils.Instruments, error) {
+	instruments := []*utils.Instruments{}
+	for _, name := range f.ordered {
+		if instrument, _ := utils.ParseInstruments(name, false); instrument.Underlying == underlying {
+			instruments = append(instruments, instrument)
+		}
+	}
+
+	return instruments, nil
+}
+
+func (f *fakeOrders) GetRestingInstruments() ([]*utils.Instruments, error) {
+	instruments := []*utils.Instruments{}
+	for name := range f.resting {
+		instrument, _ := utils.ParseInstruments(name, false)
+		instruments = append(instruments, instrument)
+	}
+
+	return instruments, nil
+}
+
+func (f *fakeOrders) GetRestingOrders(instrument utils.Instruments) ([]*_orderbookType.Order, error) {
+	return f.resting[instrument.Name()], nil
+}
+
+func (f *fakeOrders) GetUntriggeredInstruments() ([]string, error) {
+	return f.untriggered, nil
+}
+
+// fakeExpiries holds the instruments locked by another node and the closed ones
+type fakeExpiries struct {
+	held   map[string]bool
+	closed map[string]bool
+}
+
+func (f *fakeExpiries) Lock(instrumentName string, now, until time.Time) bool {
+	return !f.held[instrumentName]
+}
+
+func (f *fakeExpiries) Close(instrumentName string, now time.Time) bool {
+	if f.closed[instrumentName] {
+		return false
+	}
+
+	f.closed[instrumentName] = true
+	return true
+}
+
+func (f *fakeExpiries) Closed() (map[string]bool, error) {
+	closed := make(map[string]bool, len(f.closed))
+	for name := range f.closed {
+		closed[name] = true
+	}
+
+	return closed, nil
+}
+
+type fakeDeribit struct {
+	_deribitSvc.IDeribitService
+
+	cancelled []model.DeribitCancelRequest
+}
+
+func (f *fakeDeribit) DeribitParseCancel(ctx context.Context, userID string, data model.DeribitCancelRequest) (*model.DeribitCancelResponse, error) {
+	f.cancelled = append(f.cancelled, data)
+	return &model.DeribitCancelResponse{}, nil
+}
+
+type fakeTrigger struct {
+	_triggerSvc.ITriggerService
+
+	closed []string
+}
+
+func (f *fakeTrigger) CloseInstrument(ctx context.Context, instrumentName string) {
+	f.closed = append(f.closed, instrumentName)
+}
+
+type fakeWs struct {
+	_wsSvc.IwsOrderbookService
+
+	states []string
+}
+
+func (f *fakeWs) PublishInstrumentState(instrument, state string, timestamp int64) {
+	f.states = append(f.states, instrument+" "+state)
+}
+
+func restingOrder() *_orderbookType.Order {
+	order := &_orderbookType.Order{}
+	order.ID = primitive.NewObjectID()
+	order.UserID = primitive.NewObjectID()
+
+	return order
+}
+
+func TestCloseExpired(t *testing.T) {
+	expired, held, ahead := "BTC-31JAN23-20000-C", "BTC-31JAN23-25000-P", "BTC-29DEC23-30000-C"
+	untriggered, empty := "ETH-31JAN23-1500-P", "ETH-31JAN23-2000-C"
+	order := restingOrder()
+
+	orders := &fakeOrders{
+		ordered: []string{expired, held, ahead, untriggered, empty},
+		resting: map[string][]*_orderbookType.Order{
+			expired: {order},
+			held:    {restingOrder()},
+			ahead:   {restingOrder()},
+		},
+		untriggered: []string{expired, untriggered},
+	}
+	expiries := &fakeExpiries{held: map[string]bool{held: true}, closed: map[string]bool{}}
+	deribit, trigger, ws := &fakeDeribit{}, &fakeTrigger{}, &fakeWs{}
+	svc := instrumentService{orders, orders, expiries, deribit, trigger, ws}
+
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	next := svc.closeExpired(now)
+
+	// the earliest expiry still ahead
+	at, err := pricing.Expiry("29DEC23")
+	assert.NoError(t, err)
+	assert.Equal(t, at, next)
+
+	// the orders of the instrument held by another node are left to it, an instrument
+	// without orders is closed all the same
+	assert.ElementsMatch(t, []string{expired, untriggered, empty}, trigger.closed)
+	if assert.Len(t, deribit.cancelled, 1) {
+		assert.Equal(t, order.ID.Hex(), deribit.cancelled[0].Id)
+		assert.Equal(t, expiry.InstrumentCancelReason, deribit.cancelled[0].CancelledReason)
+	}
+	assert.ElementsMatch(t, []string{expired + " closed", untriggered + " closed", empty + " closed"}, ws.states)
+
+	// the state is published once, only the closed instruments with orders still resting
+	// are visited again
+	svc.closeExpired(now.Add(instrumentLock))
+	assert.Len(t, deribit.cancelled, 2)
+	assert.Len(t, ws.states, 3)
+	assert.ElementsMatch(t, []string{expired, untriggered, empty, expired, untriggered}, trigger.closed)
+}
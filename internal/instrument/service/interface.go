@@ -0,0 +1,5 @@
+package service
+
+type IInstrumentService interface {
+	Run()
+}
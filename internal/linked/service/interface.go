@@ -0,0 +1,10 @@
+package service
+
+import (
+	"github.com/Shopify/sarama"
+)
+
+type ILinkedOrderService interface {
+	HandleConsumeEngineSaved(msg *sarama.ConsumerMessage)
+	HandleConsumeCancelledOrderSaved(msg *sarama.ConsumerMessage)
+}
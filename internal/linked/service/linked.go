@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gateway/internal/deribit/model"
+	_deribitSvc "gateway/internal/deribit/service"
+	_engineType "gateway/internal/engine/types"
+	_orderbookType "gateway/internal/orderbook/types"
+	"gateway/internal/repositories"
+	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/linked"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// linkedOrders are the orders of the graphs, held by the linked order repository
+type linkedOrders interface {
+	FindOrder(orderId primitive.ObjectID, requestId string) (*_tradeType.LinkedOrder, error)
+	FindByOrderIds(orderIds []primitive.ObjectID) ([]*_tradeType.LinkedOrder, error)
+	Legs(primaryId primitive.ObjectID) ([]*_tradeType.LinkedOrder, error)
+	OcoPartners(order *_tradeType.LinkedOrder) ([]*_tradeType.LinkedOrder, error)
+	SetOrderID(id, orderId primitive.ObjectID) (*_tradeType.LinkedOrder, error)
+	UpdateStatus(id primitive.ObjectID, from []string, to string) (*_tradeType.LinkedOrder, error)
+}
+
+type linkedOrderService struct {
+	repo       linkedOrders
+	deribitSvc _deribitSvc.IDeribitService
+}
+
+func NewLinkedOrderService(
+	repo *repositories.LinkedOrderRepository,
+	deribitSvc _deribitSvc.IDeribitService,
+) ILinkedOrderService {
+	return &linkedOrderService{repo, deribitSvc}
+}
+
+// HandleConsumeEngineSaved follows the fills and the cancels of the orders of the graphs,
+// the node which moves an order first sends its follow-up orders
+func (svc linkedOrderService) HandleConsumeEngineSaved(msg *sarama.ConsumerMessage) {
+	var data _engineType.EngineResponse
+	if err := json.Unmarshal(msg.Value, &data); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return
+	}
+
+	if data.Matches == nil || data.Matches.TakerOrder == nil {
+		return
+	}
+
+	taker := data.Matches.TakerOrder
+	matched := len(data.Matches.Trades) > 0
+	if order := svc.find(taker); order != nil {
+		switch {
+		case matched:
+			svc.filled(order, taker.Status == types.FILLED)
+		case data.Status == types.ORDER_REJECTED:
+			svc.rejected(order)
+		case data.Status == types.ORDER_CANCELLED:
+			svc.cancelled(order)
+		}
+	}
+
+	if !matched {
+		return
+	}
+
+	full := make(map[primitive.ObjectID]bool, len(data.Matches.MakerOrders))
+	orderIds := make([]primitive.ObjectID, 0, len(data.Matches.MakerOrders))
+	for _, maker := range data.Matches.MakerOrders {
+		orderIds = append(orderIds, maker.ID)
+		full[maker.ID] = maker.Status == types.FILLED
+	}
+	makers, err := svc.repo.FindByOrderIds(orderIds)
+	if err != nil {
+		return
+	}
+	for _, order := range makers {
+		svc.filled(order, full[order.OrderID])
+	}
+}
+
+// HandleConsumeCancelledOrderSaved follows the orders of the graphs cancelled at once
+func (svc linkedOrderService) HandleConsumeCancelledOrderSaved(msg *sarama.ConsumerMessage) {
+	var data _orderbookType.CancelledOrder
+	if err := json.Unmarshal(msg.Value, &data); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return
+	}
+
+	orderIds := make([]primitive.ObjectID, 0, len(data.Data))
+	for _, order := range data.Data {
+		orderIds = append(orderIds, order.ID)
+	}
+	orders, err := svc.repo.FindByOrderIds(orderIds)
+	if err != nil {
+		return
+	}
+	for _, order := range orders {
+		svc.cancelled(order)
+	}
+}
+
+// find returns the order of a graph of the taker order, nil when it is not linked. The
+// engine echoes the request id the gateway sent the order with, a released trigger order
+// keeps the one of its trigger order. The order id is set the first time the engine
+// reports the order
+func (svc linkedOrderService) find(taker *_orderbookType.Order) *_tradeType.LinkedOrder {
+	order, err := svc.repo.FindOrder(taker.ID, taker.RequestID)
+	if err != nil || order == nil {
+		return nil
+	}
+
+	if !taker.ID.IsZero() && order.OrderID != taker.ID {
+		if placed := svc.setOrderID(order, taker.ID); placed != nil {
+			order = placed
+		}
+	}
+
+	return order
+}
+
+// setOrderID sets the id of the placed order, an order whose oco was filled before it was
+// placed is cancelled right away
+func (svc linkedOrderService) setOrderID(order *_tradeType.LinkedOrder, orderId primitive.ObjectID) *_tradeType.LinkedOrder {
+	placed, err := svc.repo.SetOrderID(order.ID, orderId)
+	if err != nil || placed == nil {
+		return nil
+	}
+
+	if placed.Status == _tradeType.LINK_CANCELLED {
+		svc.cancel(placed)
+	}
+
+	return placed
+}
+
+// filled follows a fill of the order, its oco partners are cancelled on its first fill
+// and the orders of its otoco config are placed once it is fully filled
+func (svc linkedOrderService) filled(order *_tradeType.LinkedOrder, full bool) {
+	if !full {
+		svc.cancelPartners(order)
+		return
+	}
+
+	moved, err := svc.repo.UpdateStatus(order.ID, []string{_tradeType.LINK_OPEN}, _tradeType.LINK_FILLED)
+	if err != nil || moved == nil {
+		return
+	}
+
+	if order.Primary && linked.Triggers(order.LinkedOrderType) {
+		svc.placeLegs(order)
+	}
+
+	svc.cancelPartners(order)
+}
+
+// rejected cancels the orders linked to an order the engine did not accept
+func (svc linkedOrderService) rejected(order *_tradeType.LinkedOrder) {
+	svc.cancelled(order)
+	svc.cancelPartners(order)
+}
+
+func (svc linkedOrderService) cancelPartners(order *_tradeType.LinkedOrder) {
+	partners, err := svc.repo.OcoPartners(order)
+	if err != nil {
+		return
+	}
+	for _, partner := range partners {
+		moved, err := svc.repo.UpdateStatus(partner.ID, []string{_tradeType.LINK_PENDING, _tradeType.LINK_OPEN}, _tradeType.LINK_CANCELLED)
+		if err != nil || moved == nil {
+			continue
+		}
+
+		// a pending order was never placed
+		if moved.Status == _tradeType.LINK_OPEN && !moved.OrderID.IsZero() {
+			svc.cancel(moved)
+		}
+	}
+}
+
+func (svc linkedOrderService) cancelled(order *_tradeType.LinkedOrder) {
+	moved, err := svc.repo.UpdateStatus(order.ID, []string{_tradeType.LINK_OPEN}, _tradeType.LINK_CANCELLED)
+	if err != nil || moved == nil {
+		return
+	}
+
+	if !order.Primary || !linked.Triggers(order.LinkedOrderType) {
+		return
+	}
+
+	// the orders of the otoco config are not placed without a fill of the primary order
+	legs, err := svc.repo.Legs(order.ID)
+	if err != nil {
+		return
+	}
+	for _, leg := range legs {
+		svc.repo.UpdateStatus(leg.ID, []string{_tradeType.LINK_PENDING}, _tradeType.LINK_CANCELLED)
+	}
+}
+
+func (svc linkedOrderService) placeLegs(primary *_tradeType.LinkedOrder) {
+	legs, err := svc.repo.Legs(primary.ID)
+	if err != nil {
+		return
+	}
+
+	for _, leg := range legs {
+		moved, err := svc.repo.UpdateStatus(leg.ID, []string{_tradeType.LINK_PENDING}, _tradeType.LINK_OPEN)
+		if err != nil || moved == nil {
+			continue
+		}
+
+		response, _, err := svc.deribitSvc.DeribitRequest(context.Background(), leg.UserID.Hex(), leg.Request)
+		if err != nil {
+			logs.Log.Error().Err(err).Str("order_id", leg.ID.Hex()).Msg("failed to place linked order")
+			svc.repo.UpdateStatus(leg.ID, []string{_tradeType.LINK_OPEN}, _tradeType.LINK_CANCELLED)
+			continue
+		}
+
+		// the trigger orders get their id from the gateway
+		if orderId, err := primitive.ObjectIDFromHex(response.ID); err == nil {
+			svc.setOrderID(leg, orderId)
+		}
+	}
+}
+
+func (svc linkedOrderService) cancel(order *_tradeType.LinkedOrder) {
+	_, err := svc.deribitSvc.DeribitParseCancel(context.Background(), order.UserID.Hex(), model.DeribitCancelRequest{
+		Id:      order.OrderID.Hex(),
+		ClOrdID: strconv.FormatInt(time.Now().UnixNano(), 10),
+	})
+	if err != nil {
+		logs.Log.Error().Err(err).Str("order_id", order.OrderID.Hex()).Msg("failed to cancel linked order")
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gateway/internal/deribit/model"
+	_deribitSvc "gateway/internal/deribit/service"
+	_engineType "gateway/internal/engine/types"
+	_orderbookType "gateway/internal/orderbook/types"
+	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/linked"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeLinkedOrders holds the orders of the graphs in memory, the orders are copied in and
+// out as the documents of the repository
+type fakeLinkedOrders struct {
+	orders []*_tradeType.LinkedOrder
+}
+
+func (f *fakeLinkedOrders) get(id primitive.ObjectID) *_tradeType.LinkedOrder {
+	for _, o := range f.orders {
+		if o.ID == id {
+			return o
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeLinkedOrders) FindOrder(orderId primitive.ObjectID, requestId string) (*_tradeType.LinkedOrder, error) {
+	for _, o := range f.orders {
+		if (!orderId.IsZero() && o.OrderID == orderId) || (requestId != "" && o.RequestID == requestId) {
+			c := *o
+			return &c, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeLinkedOrders) FindByOrderIds(orderIds []primitive.ObjectID) ([]*_tradeType.LinkedOrder, error) {
+	orders := []*_tradeType.LinkedOrder{}
+	for _, o := range f.orders {
+		for _, id := range orderIds {
+			if !id.IsZero() && o.OrderID == id {
+				c := *o
+				orders = append(orders, &c)
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+func (f *fakeLinkedOrders) Legs(primaryId primitive.ObjectID) ([]*_tradeType.LinkedOrder, error) {
+	orders := []*_tradeType.LinkedOrder{}
+	for _, o := range f.orders {
+		if o.PrimaryID == primaryId && !o.Primary {
+			c := *o
+			orders = append(orders, &c)
+		}
+	}
+
+	return orders, nil
+}
+
+func (f *fakeLinkedOrders) OcoPartners(order *_tradeType.LinkedOrder) ([]*_tradeType.LinkedOrder, error) {
+	orders := []*_tradeType.LinkedOrder{}
+	for _, o := range f.orders {
+		if order.OcoRef != "" && o.OcoRef == order.OcoRef && o.ID != order.ID {
+			c := *o
+			orders = append(orders, &c)
+		}
+	}
+
+	return orders, nil
+}
+
+func (f *fakeLinkedOrders) SetOrderID(id, orderId primitive.ObjectID) (*_tradeType.LinkedOrder, error) {
+	o := f.get(id)
+	if o == nil || o.OrderID == orderId {
+		return nil, nil
+	}
+
+	o.OrderID = orderId
+	c := *o
+	return &c, nil
+}
+
+func (f *fakeLinkedOrders) UpdateStatus(id primitive.ObjectID, from []string, to string) (*_tradeType.LinkedOrder, error) {
+	o := f.get(id)
+	if o == nil {
+		return nil, nil
+	}
+
+	for _, status := range from {
+		if o.Status == status {
+			before := *o
+			o.Status = to
+			return &before, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// fakeDeribit records the orders the service places and cancels, the engine orders get
+// their id once the engine reports them
+type fakeDeribit struct {
+	_deribitSvc.IDeribitService
+
+	placed    []model.DeribitRequest
+	cancelled []string
+}
+
+func (f *fakeDeribit) DeribitRequest(ctx context.Context, userID string, data model.DeribitRequest) (*model.DeribitResponse, *validation_reason.ValidationReason, error) {
+	f.placed = append(f.placed, data)
+	return &model.DeribitResponse{}, nil, nil
+}
+
+func (f *fakeDeribit) DeribitParseCancel(ctx context.Context, userID string, data model.DeribitCancelRequest) (*model.DeribitCancelResponse, error) {
+	f.cancelled = append(f.cancelled, data.Id)
+	return &model.DeribitCancelResponse{}, nil
+}
+
+// newGraph returns the graph of a placed primary order and two orders in its otoco config
+func newGraph(linkedType string) (*fakeLinkedOrders, *_tradeType.LinkedOrder, []*_tradeType.LinkedOrder) {
+	userId := primitive.NewObjectID()
+	primary := &_tradeType.LinkedOrder{
+		ID:              primitive.NewObjectID(),
+		UserID:          userId,
+		Primary:         true,
+		LinkedOrderType: linkedType,
+		RequestID:       primitive.NewObjectID().Hex(),
+		OrderID:         primitive.NewObjectID(),
+		Status:          _tradeType.LINK_OPEN,
+	}
+	primary.PrimaryID = primary.ID
+
+	ocoRef := ""
+	if linked.LegsInOco(linkedType) {
+		ocoRef = primitive.NewObjectID().Hex()
+	}
+	if linked.PrimaryInOco(linkedType) {
+		primary.OcoRef = ocoRef
+	}
+
+	orders := []*_tradeType.LinkedOrder{primary}
+	legs := []*_tradeType.LinkedOrder{}
+	for i := 0; i < 2; i++ {
+		leg := &_tradeType.LinkedOrder{
+			ID:              primitive.NewObjectID(),
+			UserID:          userId,
+			PrimaryID:       primary.ID,
+			LinkedOrderType: linkedType,
+			OcoRef:          ocoRef,
+			RequestID:       primitive.NewObjectID().Hex(),
+			Status:          _tradeType.LINK_PENDING,
+		}
+		leg.Request.RequestID = leg.RequestID
+		if !linked.Triggers(linkedType) {
+			leg.OrderID = primitive.NewObjectID()
+			leg.Status = _tradeType.LINK_OPEN
+		}
+
+		orders = append(orders, leg)
+		legs = append(legs, leg)
+	}
+
+	return &fakeLinkedOrders{orders}, primary, legs
+}
+
+func engineOrder(id primitive.ObjectID, requestId string, status types.OrderStatus) *_orderbookType.Order {
+	order := &_orderbookType.Order{RequestID: requestId}
+	order.ID = id
+	order.Status = status
+
+	return order
+}
+
+func engineSaved(t *testing.T, status types.EngineStatus, taker *_orderbookType.Order, makers []*_orderbookType.Order, trades int) *sarama.ConsumerMessage {
+	data := _engineType.EngineResponse{
+		Status: status,
+		Matches: &_engineType.Matches{
+			TakerOrder:  taker,
+			MakerOrders: makers,
+			Trades:      []*_engineType.Trade{},
+		},
+	}
+	for i := 0; i < trades; i++ {
+		data.Matches.Trades = append(data.Matches.Trades, &_engineType.Trade{})
+	}
+
+	value, err := json.Marshal(data)
+	assert.NoError(t, err)
+
+	return &sarama.ConsumerMessage{Value: value}
+}
+
+func TestLinkedFill(t *testing.T) {
+	repo, primary, legs := newGraph(linked.OneTriggersOneCancelsOther)
+	deribit := &fakeDeribit{}
+	svc := linkedOrderService{repo, deribit}
+
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_FILLED, engineOrder(primary.OrderID, primary.RequestID, types.FILLED), nil, 1))
+
+	assert.Equal(t, _tradeType.LINK_FILLED, repo.get(primary.ID).Status)
+	if assert.Len(t, deribit.placed, 2) {
+		assert.Equal(t, legs[0].RequestID, deribit.placed[0].RequestID)
+		assert.Equal(t, legs[1].RequestID, deribit.placed[1].RequestID)
+	}
+
+	// the engine reports the legs with the request ids they were sent with
+	for _, leg := range legs {
+		orderId := primitive.NewObjectID()
+		svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_ADDED, engineOrder(orderId, leg.RequestID, types.OPEN), nil, 0))
+		assert.Equal(t, orderId, repo.get(leg.ID).OrderID)
+		assert.Equal(t, _tradeType.LINK_OPEN, repo.get(leg.ID).Status)
+	}
+
+	// the fill of a leg as a maker cancels the other one
+	taker := engineOrder(primitive.NewObjectID(), primitive.NewObjectID().Hex(), types.FILLED)
+	maker := engineOrder(repo.get(legs[0].ID).OrderID, legs[0].RequestID, types.FILLED)
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_FILLED, taker, []*_orderbookType.Order{maker}, 1))
+
+	assert.Equal(t, _tradeType.LINK_FILLED, repo.get(legs[0].ID).Status)
+	assert.Equal(t, _tradeType.LINK_CANCELLED, repo.get(legs[1].ID).Status)
+	assert.Equal(t, []string{repo.get(legs[1].ID).OrderID.Hex()}, deribit.cancelled)
+}
+
+func TestLinkedPartialFill(t *testing.T) {
+	repo, primary, legs := newGraph(linked.OneTriggersOther)
+	deribit := &fakeDeribit{}
+	svc := linkedOrderService{repo, deribit}
+
+	// the legs wait for the primary order to be fully filled
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_PARTIALLY_FILLED, engineOrder(primary.OrderID, primary.RequestID, types.PARTIALLY_FILLED), nil, 1))
+	assert.Equal(t, _tradeType.LINK_OPEN, repo.get(primary.ID).Status)
+	assert.Empty(t, deribit.placed)
+	for _, leg := range legs {
+		assert.Equal(t, _tradeType.LINK_PENDING, repo.get(leg.ID).Status)
+	}
+
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_FILLED, engineOrder(primary.OrderID, primary.RequestID, types.FILLED), nil, 1))
+	assert.Equal(t, _tradeType.LINK_FILLED, repo.get(primary.ID).Status)
+	assert.Len(t, deribit.placed, 2)
+
+	// the first fill of an order cancels its oco partners
+	repo, primary, legs = newGraph(linked.OneCancelsOther)
+	deribit = &fakeDeribit{}
+	svc = linkedOrderService{repo, deribit}
+
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_PARTIALLY_FILLED, engineOrder(primary.OrderID, primary.RequestID, types.PARTIALLY_FILLED), nil, 1))
+	assert.Equal(t, _tradeType.LINK_OPEN, repo.get(primary.ID).Status)
+	assert.Empty(t, deribit.placed)
+	for _, leg := range legs {
+		assert.Equal(t, _tradeType.LINK_CANCELLED, repo.get(leg.ID).Status)
+	}
+	assert.Len(t, deribit.cancelled, 2)
+}
+
+func TestLinkedReject(t *testing.T) {
+	repo, primary, legs := newGraph(linked.OneTriggersOther)
+	deribit := &fakeDeribit{}
+	svc := linkedOrderService{repo, deribit}
+
+	// the engine rejects the primary order before it gets an id
+	repo.get(primary.ID).OrderID = primitive.NilObjectID
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_REJECTED, engineOrder(primitive.NilObjectID, primary.RequestID, types.REJECTED), nil, 0))
+
+	assert.Equal(t, _tradeType.LINK_CANCELLED, repo.get(primary.ID).Status)
+	for _, leg := range legs {
+		assert.Equal(t, _tradeType.LINK_CANCELLED, repo.get(leg.ID).Status)
+	}
+	assert.Empty(t, deribit.placed)
+	assert.Empty(t, deribit.cancelled)
+}
+
+func TestLinkedCancelRace(t *testing.T) {
+	repo, _, legs := newGraph(linked.OneTriggersOneCancelsOther)
+	deribit := &fakeDeribit{}
+	svc := linkedOrderService{repo, deribit}
+
+	// both legs are placed, the engine reports the fill of the first one before it
+	// reports the other one
+	for _, leg := range legs {
+		repo.get(leg.ID).Status = _tradeType.LINK_OPEN
+	}
+
+	filled := primitive.NewObjectID()
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_FILLED, engineOrder(filled, legs[0].RequestID, types.FILLED), nil, 1))
+	assert.Equal(t, _tradeType.LINK_FILLED, repo.get(legs[0].ID).Status)
+	assert.Equal(t, _tradeType.LINK_CANCELLED, repo.get(legs[1].ID).Status)
+
+	// the engine did not report the other leg yet, it is cancelled once it does
+	assert.Empty(t, deribit.cancelled)
+	other := primitive.NewObjectID()
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_ADDED, engineOrder(other, legs[1].RequestID, types.OPEN), nil, 0))
+	assert.Equal(t, []string{other.Hex()}, deribit.cancelled)
+
+	// the cancel reported for it does not move the graph again
+	svc.HandleConsumeEngineSaved(engineSaved(t, types.ORDER_CANCELLED, engineOrder(other, legs[1].RequestID, types.CANCELLED), nil, 0))
+	assert.Equal(t, _tradeType.LINK_FILLED, repo.get(legs[0].ID).Status)
+	assert.Equal(t, _tradeType.LINK_CANCELLED, repo.get(legs[1].ID).Status)
+	assert.Len(t, deribit.cancelled, 1)
+}
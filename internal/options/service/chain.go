@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"gateway/internal/deribit/model"
+	"gateway/internal/orderbook/book"
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/internal/repositories"
+	"gateway/pkg/pricing"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+)
+
+// GetOptionChain returns the call and put quotes of every strike of the expiry, from the
+// best prices of the books and the latest index price
+func (svc *optionsService) GetOptionChain(ctx context.Context, request model.GetOptionChainRequest) model.GetOptionChainResponse {
+	underlying, expiryDate, err := utils.ParseExpiry(request.Currency, request.Expiry, false)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return model.GetOptionChainResponse{}
+	}
+
+	now := time.Now()
+	result := model.GetOptionChainResponse{
+		Currency:  underlying,
+		Expiry:    expiryDate,
+		Timestamp: now.UnixNano() / int64(time.Millisecond),
+		Strikes:   []model.OptionChainStrike{},
+	}
+	if expiry, err := pricing.Expiry(expiryDate); err == nil {
+		result.ExpirationTimestamp = expiry.UnixMilli()
+	}
+
+	data, err := svc.expiry(underlying, expiryDate, now)
+	if err != nil {
+		return result
+	}
+
+	var index float64
+	if indexPrice := svc.rawPriceRepo.GetLatestIndexPrice(_orderbookTypes.GetOrderBook{Underlying: underlying}); len(indexPrice) > 0 {
+		index = indexPrice[0].Price
+		result.IndexPrice = &index
+	}
+
+	result.Strikes = optionChainStrikes(data, book.Orderbook, index, now)
+
+	return result
+}
+
+// optionChainStrikes groups the quotes of the instruments of the expiry by strike
+func optionChainStrikes(data *expiryData, orderbook func(_orderbookTypes.GetOrderBook) _orderbookTypes.Orderbook, index float64, now time.Time) []model.OptionChainStrike {
+	strikes := []model.OptionChainStrike{}
+	for _, instrument := range data.instruments {
+		name := instrument.Name()
+		option, err := pricing.NewOption(instrument.Underlying, instrument.ExpDate, instrument.Strike, instrument.Contracts == types.CALL)
+		if err != nil {
+			logs.Log.Error().Err(err).Str("instrument", name).Msg("failed to price option")
+			continue
+		}
+
+		ob := orderbook(_orderbookTypes.GetOrderBook{
+			InstrumentName: name,
+			Underlying:     instrument.Underlying,
+			ExpiryDate:     instrument.ExpDate,
+			StrikePrice:    instrument.Strike,
+		})
+		quote := chainQuote(name, option, ob, data.trades[name], index, now)
+		quote.OpenInterest = data.openInterest[name]
+
+		// the instruments are sorted by strike then contracts
+		last := len(strikes) - 1
+		if last < 0 || strikes[last].Strike != instrument.Strike {
+			strikes = append(strikes, model.OptionChainStrike{Strike: instrument.Strike})
+			last++
+		}
+		if instrument.Contracts == types.CALL {
+			strikes[last].Call = quote
+		} else {
+			strikes[last].Put = quote
+		}
+	}
+
+	return strikes
+}
+
+// chainQuote returns the quote of the option from the best levels of its book, the mark
+// is the mid of the best bid and ask. The greeks are at the mark volatility, at the last
+// price one without a mark
+func chainQuote(name string, option pricing.Option, ob _orderbookTypes.Orderbook, trades repositories.InstrumentTrades, index float64, now time.Time) *model.OptionChainQuote {
+	quote := &model.OptionChainQuote{
+		InstrumentName: name,
+		Volume:         trades.Volume,
+	}
+
+	// the levels are sorted by price
+	if n := len(ob.Bids); n > 0 {
+		quote.BestBidPrice = ob.Bids[n-1].Price
+		quote.BestBidAmount = ob.Bids[n-1].Amount
+	}
+	if len(ob.Asks) > 0 {
+		quote.BestAskPrice = ob.Asks[0].Price
+		quote.BestAskAmount = ob.Asks[0].Amount
+	}
+
+	quote.Bids_iv = option.ImpliedVol(quote.BestBidPrice, index, now)
+	quote.Asks_iv = option.ImpliedVol(quote.BestAskPrice, index, now)
+
+	var vol float64
+	if quote.BestBidPrice != 0 && quote.BestAskPrice != 0 {
+		markPrice := (quote.BestBidPrice + quote.BestAskPrice) / 2
+		markIv := option.ImpliedVol(markPrice, index, now)
+		quote.MarkPrice = &markPrice
+		quote.MarkIv = &markIv
+		vol = markIv
+	}
+	if vol == 0 {
+		vol = option.ImpliedVol(trades.LastPrice, index, now)
+	}
+
+	greeks := option.Greeks(index, vol, now)
+	quote.Greeks = model.OrderBookGreek{
+		Delta: greeks.Delta,
+		Vega:  greeks.Vega,
+		Gamma: greeks.Gamma,
+		Tetha: greeks.Theta,
+		Rho:   greeks.Rho,
+	}
+
+	return quote
+}
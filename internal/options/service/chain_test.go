@@ -0,0 +1,134 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/internal/repositories"
+	"gateway/pkg/pricing"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const chainIndex = 50000.0
+
+func chainExpiry(now time.Time) string {
+	return strings.ToUpper(now.AddDate(0, 3, 0).Format("02Jan06"))
+}
+
+func levels(prices ...float64) []*_orderbookTypes.WsOrder {
+	orders := []*_orderbookTypes.WsOrder{}
+	for _, price := range prices {
+		orders = append(orders, &_orderbookTypes.WsOrder{Price: price, Amount: 1})
+	}
+
+	return orders
+}
+
+func TestChainQuote(t *testing.T) {
+	now := time.Now()
+	option, err := pricing.NewOption("BTC", chainExpiry(now), 50000, true)
+	assert.NoError(t, err)
+
+	bid := option.Price(chainIndex, 0.5, now)
+	ask := option.Price(chainIndex, 0.7, now)
+	last := option.Price(chainIndex, 0.6, now)
+
+	tests := []struct {
+		name   string
+		book   _orderbookTypes.Orderbook
+		trades repositories.InstrumentTrades
+		bidIv  float64
+		askIv  float64
+		mark   bool
+		vol    float64
+	}{
+		{
+			name:  "two sided",
+			book:  _orderbookTypes.Orderbook{Bids: levels(bid-100, bid), Asks: levels(ask, ask+100)},
+			bidIv: 0.5,
+			askIv: 0.7,
+			mark:  true,
+		},
+		{
+			name:   "one sided",
+			book:   _orderbookTypes.Orderbook{Bids: levels(bid)},
+			trades: repositories.InstrumentTrades{LastPrice: last, Volume: 3},
+			bidIv:  0.5,
+			vol:    0.6,
+		},
+		{
+			name: "empty",
+			book: _orderbookTypes.Orderbook{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := chainQuote("BTC-"+chainExpiry(now)+"-50000-C", option, tt.book, tt.trades, chainIndex, now)
+
+			assert.InDelta(t, tt.bidIv, quote.Bids_iv, 1e-4)
+			assert.InDelta(t, tt.askIv, quote.Asks_iv, 1e-4)
+			assert.Equal(t, tt.trades.Volume, quote.Volume)
+
+			if !tt.mark {
+				assert.Nil(t, quote.MarkPrice)
+				assert.Nil(t, quote.MarkIv)
+				assert.InDelta(t, option.Greeks(chainIndex, tt.vol, now).Delta, quote.Greeks.Delta, 1e-4)
+				return
+			}
+
+			if !assert.NotNil(t, quote.MarkPrice) {
+				return
+			}
+			assert.Equal(t, (bid+ask)/2, *quote.MarkPrice)
+			assert.Equal(t, bid, quote.BestBidPrice)
+			assert.Equal(t, ask, quote.BestAskPrice)
+			assert.Equal(t, option.Greeks(chainIndex, *quote.MarkIv, now).Delta, quote.Greeks.Delta)
+		})
+	}
+}
+
+func TestOptionChainStrikes(t *testing.T) {
+	now := time.Now()
+	expiryDate := chainExpiry(now)
+
+	instrument := func(strike float64, contracts types.Contracts) *utils.Instruments {
+		return &utils.Instruments{Underlying: "BTC", ExpDate: expiryDate, Contracts: contracts, Strike: strike}
+	}
+	call := instrument(50000, types.CALL)
+	put := instrument(50000, types.PUT)
+	other := instrument(60000, types.CALL)
+
+	data := &expiryData{
+		instruments:  []*utils.Instruments{call, put, other},
+		openInterest: map[string]float64{call.Name(): 4},
+		trades:       map[string]repositories.InstrumentTrades{put.Name(): {LastPrice: 2000, Volume: 2}},
+	}
+
+	requested := []string{}
+	orderbook := func(o _orderbookTypes.GetOrderBook) _orderbookTypes.Orderbook {
+		requested = append(requested, o.InstrumentName)
+		return _orderbookTypes.Orderbook{InstrumentName: o.InstrumentName}
+	}
+
+	strikes := optionChainStrikes(data, orderbook, chainIndex, now)
+	if !assert.Len(t, strikes, 2) {
+		return
+	}
+	assert.Equal(t, []string{call.Name(), put.Name(), other.Name()}, requested)
+
+	assert.Equal(t, 50000.0, strikes[0].Strike)
+	assert.Equal(t, call.Name(), strikes[0].Call.InstrumentName)
+	assert.Equal(t, 4.0, strikes[0].Call.OpenInterest)
+	assert.Equal(t, put.Name(), strikes[0].Put.InstrumentName)
+	assert.Equal(t, 2.0, strikes[0].Put.Volume)
+
+	assert.Equal(t, 60000.0, strikes[1].Strike)
+	assert.Equal(t, other.Name(), strikes[1].Call.InstrumentName)
+	assert.Nil(t, strikes[1].Put)
+}
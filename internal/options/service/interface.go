@@ -0,0 +1,12 @@
+package service
+
+import (
+	"context"
+
+	"gateway/internal/deribit/model"
+)
+
+type IOptionsService interface {
+	GetOptionChain(ctx context.Context, request model.GetOptionChainRequest) model.GetOptionChainResponse
+	GetVolatilitySurface(ctx context.Context, request model.GetVolatilitySurfaceRequest) model.GetVolatilitySurfaceResponse
+}
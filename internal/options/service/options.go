@@ -0,0 +1,139 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"gateway/internal/repositories"
+	"gateway/pkg/utils"
+)
+
+// the instruments, open interest and trades of an expiry are read again once per refresh,
+// the quotes are read from the books in memory on every call. An expiry which is not read
+// for the retention is dropped
+const (
+	expiryRefresh   = 5 * time.Second
+	expiryRetention = time.Minute
+)
+
+// the instruments of a currency are read again from the orders once per refresh
+const currencyRefresh = time.Minute
+
+// the volume of the quotes is the one of the last day
+const volumeWindow = 24 * time.Hour
+
+type optionsService struct {
+	orderRepo    *repositories.OrderRepository
+	tradeRepo    *repositories.TradeRepository
+	rawPriceRepo *repositories.RawPriceRepository
+
+	mu         sync.Mutex
+	expiries   map[string]*expiryData
+	currencies map[string]*currencyData
+}
+
+func NewOptionsService(
+	orderRepo *repositories.OrderRepository,
+	tradeRepo *repositories.TradeRepository,
+	rawPriceRepo *repositories.RawPriceRepository,
+) IOptionsService {
+	return &optionsService{
+		orderRepo:    orderRepo,
+		tradeRepo:    tradeRepo,
+		rawPriceRepo: rawPriceRepo,
+		expiries:     make(map[string]*expiryData),
+		currencies:   make(map[string]*currencyData),
+	}
+}
+
+// expiryData is what the quotes of an expiry read from the database
+type expiryData struct {
+	instruments  []*utils.Instruments
+	openInterest map[string]float64
+	trades       map[string]repositories.InstrumentTrades
+
+	refreshed time.Time
+	read      time.Time
+}
+
+// expiry returns the data of the expiry, read again once it is older than the refresh.
+// The previous data is kept when it can not be read
+func (svc *optionsService) expiry(underlying, expiryDate string, now time.Time) (*expiryData, error) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	key := underlying + "-" + expiryDate
+	data, ok := svc.expiries[key]
+	if ok && now.Sub(data.refreshed) < expiryRefresh {
+		data.read = now
+		return data, nil
+	}
+
+	for k, d := range svc.expiries {
+		if now.Sub(d.read) >= expiryRetention {
+			delete(svc.expiries, k)
+		}
+	}
+
+	instruments, err := svc.orderRepo.GetExpiryInstruments(underlying, expiryDate)
+	if err != nil {
+		if ok {
+			data.read = now
+			svc.expiries[key] = data
+			return data, nil
+		}
+		return nil, err
+	}
+
+	openInterest, err := svc.tradeRepo.GetOpenInterest(underlying, expiryDate)
+	if err != nil {
+		openInterest = map[string]float64{}
+	}
+
+	trades, err := svc.tradeRepo.GetExpiryTrades(underlying, expiryDate, now.Add(-volumeWindow))
+	if err != nil {
+		trades = map[string]repositories.InstrumentTrades{}
+	}
+
+	data = &expiryData{
+		instruments:  instruments,
+		openInterest: openInterest,
+		trades:       trades,
+		refreshed:    now,
+		read:         now,
+	}
+	svc.expiries[key] = data
+
+	return data, nil
+}
+
+// currencyData is the instruments of a currency, sorted by expiry date then strike then
+// contracts
+type currencyData struct {
+	instruments []*utils.Instruments
+	refreshed   time.Time
+}
+
+// currency returns the instruments of the currency, read again once they are older than
+// the refresh. The previous ones are kept when they can not be read
+func (svc *optionsService) currency(underlying string, now time.Time) ([]*utils.Instruments, error) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	data, ok := svc.currencies[underlying]
+	if ok && now.Sub(data.refreshed) < currencyRefresh {
+		return data.instruments, nil
+	}
+
+	instruments, err := svc.orderRepo.GetCurrencyInstruments(underlying)
+	if err != nil {
+		if ok {
+			return data.instruments, nil
+		}
+		return nil, err
+	}
+
+	svc.currencies[underlying] = &currencyData{instruments: instruments, refreshed: now}
+
+	return instruments, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"gateway/internal/deribit/model"
+	"gateway/internal/orderbook/book"
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/pkg/pricing"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+)
+
+// GetVolatilitySurface returns the implied volatilities of the active expiries of the
+// currency by strike and by delta bucket, from the mid of the best bid and ask
+func (svc *optionsService) GetVolatilitySurface(ctx context.Context, request model.GetVolatilitySurfaceRequest) model.GetVolatilitySurfaceResponse {
+	underlying, err := utils.ParseCurrency(request.Currency)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return model.GetVolatilitySurfaceResponse{}
+	}
+
+	now := time.Now()
+	result := model.GetVolatilitySurfaceResponse{
+		Currency:  underlying,
+		Timestamp: now.UnixNano() / int64(time.Millisecond),
+		Expiries:  []model.VolatilitySurfaceExpiry{},
+	}
+
+	indexPrice := svc.rawPriceRepo.GetLatestIndexPrice(_orderbookTypes.GetOrderBook{Underlying: underlying})
+	if len(indexPrice) == 0 {
+		return result
+	}
+	result.IndexPrice = &indexPrice[0].Price
+
+	instruments, err := svc.currency(underlying, now)
+	if err != nil {
+		return result
+	}
+
+	result.Expiries = volatilitySurfaceExpiries(underlying, instruments, book.BestPrices, indexPrice[0].Price, now)
+
+	return result
+}
+
+// volatilitySurfaceExpiries returns the smiles of the active expiries of the instruments,
+// which are sorted by expiry
+func volatilitySurfaceExpiries(underlying string, instruments []*utils.Instruments, bestPrices func(_orderbookTypes.GetOrderBook) (float64, float64), index float64, now time.Time) []model.VolatilitySurfaceExpiry {
+	var expiryDates []string
+	quotes := make(map[string][]pricing.Quote)
+	for _, instrument := range instruments {
+		if _, _, err := utils.ParseExpiry(underlying, instrument.ExpDate, true); err != nil {
+			continue
+		}
+
+		option, err := pricing.NewOption(underlying, instrument.ExpDate, instrument.Strike, instrument.Contracts == types.CALL)
+		if err != nil {
+			logs.Log.Error().Err(err).Str("instrument", instrument.Name()).Msg("failed to price option")
+			continue
+		}
+
+		bid, ask := bestPrices(_orderbookTypes.GetOrderBook{
+			InstrumentName: instrument.Name(),
+			Underlying:     instrument.Underlying,
+			ExpiryDate:     instrument.ExpDate,
+			StrikePrice:    instrument.Strike,
+		})
+
+		if _, ok := quotes[instrument.ExpDate]; !ok {
+			expiryDates = append(expiryDates, instrument.ExpDate)
+		}
+		quotes[instrument.ExpDate] = append(quotes[instrument.ExpDate], pricing.Quote{
+			Option: option,
+			Bid:    bid,
+			Ask:    ask,
+		})
+	}
+
+	expiries := []model.VolatilitySurfaceExpiry{}
+	for _, expiryDate := range expiryDates {
+		smile := pricing.NewSmile(quotes[expiryDate], index, now)
+		expiries = append(expiries, volatilitySurfaceExpiry(underlying, expiryDate, smile))
+	}
+
+	return expiries
+}
+
+func volatilitySurfaceExpiry(underlying, expiryDate string, smile pricing.Smile) model.VolatilitySurfaceExpiry {
+	expiry := model.VolatilitySurfaceExpiry{
+		Expiry:              expiryDate,
+		ExpirationTimestamp: smile.Expiry.UnixMilli(),
+		Forward:             smile.Forward,
+		Strikes:             []model.VolatilitySurfaceStrike{},
+		Deltas:              []model.VolatilitySurfaceDelta{},
+	}
+
+	for _, s := range smile.Strikes {
+		instrument := utils.Instruments{Underlying: underlying, ExpDate: expiryDate, Contracts: types.PUT, Strike: s.Strike}
+		if s.Call {
+			instrument.Contracts = types.CALL
+		}
+
+		strike := model.VolatilitySurfaceStrike{
+			Strike:         s.Strike,
+			InstrumentName: instrument.Name(),
+			BidIv:          s.BidVol,
+			AskIv:          s.AskVol,
+			Delta:          s.Delta,
+		}
+		if s.Vol > 0 {
+			vol := s.Vol
+			strike.Iv = &vol
+		}
+		expiry.Strikes = append(expiry.Strikes, strike)
+	}
+
+	for _, d := range smile.Deltas {
+		expiry.Deltas = append(expiry.Deltas, model.VolatilitySurfaceDelta{
+			Bucket: d.Bucket,
+			Delta:  d.Delta,
+			Iv:     d.Vol,
+		})
+	}
+
+	return expiry
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/pkg/pricing"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolatilitySurfaceExpiries(t *testing.T) {
+	now := time.Now()
+	expiryDate := func(months int) string {
+		return strings.ToUpper(now.AddDate(0, months, 0).Format("02Jan06"))
+	}
+
+	instruments := []*utils.Instruments{}
+	for _, expiry := range []string{expiryDate(-1), expiryDate(3), expiryDate(6)} {
+		for _, strike := range []float64{40000, 50000, 60000} {
+			for _, contracts := range []types.Contracts{types.CALL, types.PUT} {
+				instruments = append(instruments, &utils.Instruments{Underlying: "BTC", ExpDate: expiry, Contracts: contracts, Strike: strike})
+			}
+		}
+	}
+
+	// the books are quoted at a flat volatility
+	bestPrices := func(o _orderbookTypes.GetOrderBook) (float64, float64) {
+		option, err := pricing.ParseOption(o.InstrumentName)
+		if !assert.NoError(t, err) {
+			return 0, 0
+		}
+		return option.Price(chainIndex, 0.55, now), option.Price(chainIndex, 0.65, now)
+	}
+
+	expiries := volatilitySurfaceExpiries("BTC", instruments, bestPrices, chainIndex, now)
+	if !assert.Len(t, expiries, 2) {
+		return
+	}
+	assert.Equal(t, expiryDate(3), expiries[0].Expiry)
+	assert.Equal(t, expiryDate(6), expiries[1].Expiry)
+
+	for _, expiry := range expiries {
+		assert.Len(t, expiry.Strikes, 3)
+		for _, strike := range expiry.Strikes {
+			if assert.NotNil(t, strike.Iv) {
+				assert.InDelta(t, 0.6, *strike.Iv, 0.01)
+			}
+
+			// the out of the money option of the strike
+			contracts := "P"
+			if strike.Strike >= expiry.Forward {
+				contracts = "C"
+			}
+			assert.True(t, strings.HasSuffix(strike.InstrumentName, contracts), strike.InstrumentName)
+		}
+
+		atm := false
+		for _, delta := range expiry.Deltas {
+			if delta.Bucket == "ATM" {
+				atm = true
+				assert.InDelta(t, 0.6, delta.Iv, 0.01)
+			}
+		}
+		assert.True(t, atm)
+	}
+}
@@ -0,0 +1,440 @@
+package book
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	_orderbookType "gateway/internal/orderbook/types"
+	"gateway/internal/repositories"
+	"gateway/pkg/fanout"
+	"gateway/pkg/memdb"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+)
+
+// amounts below are float leftovers of a level which was emptied
+const epsilon = 1e-9
+
+// the closed orders are kept that long to drop the late updates of them, swept once
+// there are more than closedSweep of them
+const (
+	closedRetention = 10 * time.Minute
+	closedSweep     = 1024
+)
+
+type entry struct {
+	side     types.Side
+	price    float64
+	amount   float64
+	userID   string
+	userRole string
+
+	updatedAt time.Time
+}
+
+// Book is the L2 book of an instrument, it is loaded from mongo once and then kept up
+// to date with the orders of the engine events
+type Book struct {
+	instrument string
+
+	// closed once loaded, err is set when the load failed
+	ready chan struct{}
+	err   error
+
+	mu     sync.RWMutex
+	orders map[string]entry
+	bids   map[float64]float64
+	asks   map[float64]float64
+
+	// the last update of the orders which left the book
+	closed map[string]time.Time
+}
+
+var (
+	books     = make(map[string]*Book)
+	booksMu   sync.Mutex
+	orderRepo *repositories.OrderRepository
+
+	// the role of the user of an order new to the book
+	userRole = func(userID string) string {
+		user, _, err := memdb.MDBFindUserById(userID)
+		if err != nil {
+			return ""
+		}
+
+		return user.Role.String()
+	}
+)
+
+// Init sets the repository the books are loaded from
+func Init(repo *repositories.OrderRepository) {
+	orderRepo = repo
+
+	registerFanout()
+}
+
+// instrumentName returns the canonical name of the instrument, e.g. BTC-28JAN22-50000-C
+func instrumentName(underlying, expiryDate string, strikePrice float64, contracts types.Contracts) string {
+	return underlying + "-" + expiryDate + "-" + fmt.Sprintf("%.0f", strikePrice) + "-" + string(contracts[0])
+}
+
+func orderInstrument(order *_orderbookType.Order) (string, bool) {
+	if order == nil || len(order.Contracts) == 0 {
+		return "", false
+	}
+
+	return instrumentName(order.Underlying, order.ExpiryDate, order.StrikePrice, order.Contracts), true
+}
+
+// Get returns the book of the instrument, it is loaded on the first call
+func Get(instrument string) (*Book, error) {
+	ins, err := utils.ParseInstruments(instrument, false)
+	if err != nil {
+		return nil, err
+	}
+	name := instrumentName(ins.Underlying, ins.ExpDate, ins.Strike, ins.Contracts)
+
+	booksMu.Lock()
+	b, ok := books[name]
+	if !ok {
+		b = newBook(name)
+		books[name] = b
+	}
+	booksMu.Unlock()
+
+	if ok {
+		<-b.ready
+		return b, b.err
+	}
+
+	if err := b.load(ins); err != nil {
+		// the next call loads it again
+		booksMu.Lock()
+		delete(books, name)
+		booksMu.Unlock()
+
+		b.err = err
+		close(b.ready)
+		return nil, err
+	}
+
+	close(b.ready)
+	return b, nil
+}
+
+func newBook(instrument string) *Book {
+	return &Book{
+		instrument: instrument,
+		ready:      make(chan struct{}),
+		orders:     make(map[string]entry),
+		bids:       make(map[float64]float64),
+		asks:       make(map[float64]float64),
+		closed:     make(map[string]time.Time),
+	}
+}
+
+// loaded returns the book of the instrument when it is in memory, the orders of a book
+// which is not are read from mongo when it is loaded
+func loaded(instrument string) *Book {
+	booksMu.Lock()
+	b := books[instrument]
+	booksMu.Unlock()
+
+	if b == nil {
+		return nil
+	}
+
+	<-b.ready
+	if b.err != nil {
+		return nil
+	}
+
+	return b
+}
+
+func (b *Book) load(ins *utils.Instruments) error {
+	if orderRepo == nil {
+		return fmt.Errorf("order book of %s is not initialised", b.instrument)
+	}
+
+	orders, err := orderRepo.GetOpenOrders(_orderbookType.GetOrderBook{
+		InstrumentName: b.instrument,
+		Underlying:     ins.Underlying,
+		ExpiryDate:     ins.ExpDate,
+		StrikePrice:    ins.Strike,
+	}, ins.Contracts)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, order := range orders {
+		b.add(order.ID.Hex(), entry{
+			side:     order.Side,
+			price:    order.Price,
+			amount:   order.Amount,
+			userID:   order.UserID,
+			userRole: order.UserRole,
+
+			updatedAt: order.UpdatedAt,
+		})
+	}
+
+	logs.Log.Info().Str("instrument", b.instrument).Int("orders", len(orders)).Msg("order book loaded")
+
+	return nil
+}
+
+func (b *Book) levels(side types.Side) map[float64]float64 {
+	if side == types.BUY {
+		return b.bids
+	}
+
+	return b.asks
+}
+
+func (b *Book) add(id string, e entry) {
+	if e.amount <= epsilon {
+		return
+	}
+
+	b.orders[id] = e
+	b.levels(e.side)[e.price] += e.amount
+}
+
+func (b *Book) remove(id string) (entry, bool) {
+	e, ok := b.orders[id]
+	if !ok {
+		return e, false
+	}
+	delete(b.orders, id)
+
+	levels := b.levels(e.side)
+	if amount := levels[e.price] - e.amount; amount > epsilon {
+		levels[e.price] = amount
+	} else {
+		delete(levels, e.price)
+	}
+
+	return e, true
+}
+
+// stale tells whether the update is older than the state of the order in the book, the
+// events of an order may come out of order from the topics, the fanout and the load
+func (b *Book) stale(id string, updatedAt time.Time) bool {
+	if updatedAt.IsZero() {
+		return false
+	}
+
+	last, ok := b.closed[id]
+	if e, resting := b.orders[id]; resting {
+		last, ok = e.updatedAt, true
+	}
+
+	return ok && updatedAt.Before(last)
+}
+
+// close removes the order from the book and keeps its last update
+func (b *Book) close(id string, updatedAt time.Time) {
+	b.remove(id)
+
+	if len(b.closed) >= closedSweep {
+		for closedID, at := range b.closed {
+			if time.Since(at) > closedRetention {
+				delete(b.closed, closedID)
+			}
+		}
+	}
+	b.closed[id] = updatedAt
+}
+
+// apply sets the order to the state sent by the engine, replaying a state is a no-op and
+// a state older than the one in the book is dropped
+func (b *Book) apply(order *_orderbookType.Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := order.ID.Hex()
+	if b.stale(id, order.UpdatedAt) {
+		return
+	}
+
+	if order.Status != types.OPEN && order.Status != types.PARTIALLY_FILLED {
+		b.close(id, order.UpdatedAt)
+		return
+	}
+
+	old, existed := b.remove(id)
+
+	filled, _ := strconv.ParseFloat(order.FilledAmount, 64)
+
+	e := entry{
+		side:     order.Side,
+		price:    order.Price,
+		amount:   order.Amount - filled,
+		userID:   order.UserID.Hex(),
+		userRole: old.userRole,
+
+		updatedAt: order.UpdatedAt,
+	}
+	if !existed {
+		e.userRole = userRole(e.userID)
+	}
+
+	b.add(id, e)
+}
+
+func (b *Book) cancel(order *_orderbookType.Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := order.ID.Hex()
+	if b.stale(id, order.UpdatedAt) {
+		return
+	}
+
+	b.close(id, order.UpdatedAt)
+}
+
+func sorted(levels map[float64]float64) []*_orderbookType.WsOrder {
+	orders := make([]*_orderbookType.WsOrder, 0, len(levels))
+	for price, amount := range levels {
+		orders = append(orders, &_orderbookType.WsOrder{Price: price, Amount: amount})
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].Price < orders[j].Price
+	})
+
+	return orders
+}
+
+// Orderbook returns the levels of the book sorted by price, a client only sees the
+// orders of the market makers it does not exclude and its own orders
+func (b *Book) Orderbook(o _orderbookType.GetOrderBook) _orderbookType.Orderbook {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	bids, asks := b.bids, b.asks
+	if o.UserRole == types.CLIENT.String() {
+		excluded := make(map[string]bool, len(o.UserOrderExclusions))
+		for _, id := range o.UserOrderExclusions {
+			excluded[id] = true
+		}
+
+		bids = make(map[float64]float64)
+		asks = make(map[float64]float64)
+		for _, e := range b.orders {
+			visible := e.userID == o.UserId || (e.userRole == types.MARKET_MAKER.String() && !excluded[e.userID])
+			if !visible {
+				continue
+			}
+
+			if e.side == types.BUY {
+				bids[e.price] += e.amount
+			} else {
+				asks[e.price] += e.amount
+			}
+		}
+	}
+
+	return _orderbookType.Orderbook{
+		InstrumentName: o.InstrumentName,
+		Bids:           sorted(bids),
+		Asks:           sorted(asks),
+	}
+}
+
+// Orderbook returns the book of the instrument of the request, it is empty when the
+// book could not be loaded
+func Orderbook(o _orderbookType.GetOrderBook) _orderbookType.Orderbook {
+	b, err := Get(o.InstrumentName)
+	if err != nil {
+		logs.Log.Error().Err(err).Str("instrument", o.InstrumentName).Msg("failed to get order book")
+
+		return _orderbookType.Orderbook{
+			InstrumentName: o.InstrumentName,
+			Bids:           []*_orderbookType.WsOrder{},
+			Asks:           []*_orderbookType.WsOrder{},
+		}
+	}
+
+	return b.Orderbook(o)
+}
+
+// BestPrices returns the highest bid and the lowest ask of the book, 0 without one
+func BestPrices(o _orderbookType.GetOrderBook) (bid, ask float64) {
+	book := Orderbook(o)
+	if n := len(book.Bids); n > 0 {
+		bid = book.Bids[n-1].Price
+	}
+	if len(book.Asks) > 0 {
+		ask = book.Asks[0].Price
+	}
+
+	return bid, ask
+}
+
+// Aggregated returns the book with two adjacent levels merged into one, at the lowest
+// price of the two
+func Aggregated(o _orderbookType.GetOrderBook) _orderbookType.Orderbook {
+	book := Orderbook(o)
+	book.Bids = aggregate(book.Bids)
+	book.Asks = aggregate(book.Asks)
+
+	return book
+}
+
+func aggregate(levels []*_orderbookType.WsOrder) []*_orderbookType.WsOrder {
+	orders := make([]*_orderbookType.WsOrder, 0, (len(levels)+1)/2)
+	for i := 0; i < len(levels); i += 2 {
+		level := *levels[i]
+		if i+1 < len(levels) {
+			level.Amount += levels[i+1].Amount
+		}
+		orders = append(orders, &level)
+	}
+
+	return orders
+}
+
+// Apply updates the books in memory with the orders of an engine event
+func Apply(orders ...*_orderbookType.Order) {
+	apply(orders, false)
+
+	fanout.Publish(fanoutOrders, ordersMessage{Orders: orders})
+}
+
+// Cancel removes the cancelled orders from the books in memory
+func Cancel(orders ...*_orderbookType.Order) {
+	apply(orders, true)
+
+	fanout.Publish(fanoutOrders, ordersMessage{Orders: orders, Cancelled: true})
+}
+
+func apply(orders []*_orderbookType.Order, cancelled bool) {
+	for _, order := range orders {
+		instrument, ok := orderInstrument(order)
+		if !ok {
+			continue
+		}
+
+		b := loaded(instrument)
+		if b == nil {
+			continue
+		}
+
+		if cancelled {
+			b.cancel(order)
+		} else {
+			b.apply(order)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package book
+
+import (
+	"testing"
+	"time"
+
+	_orderbookType "gateway/internal/orderbook/types"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	userRole = func(string) string { return types.MARKET_MAKER.String() }
+}
+
+func bookOrder(id primitive.ObjectID, status types.OrderStatus, amount float64, filled string, updatedAt time.Time) *_orderbookType.Order {
+	order := &_orderbookType.Order{}
+	order.ID = id
+	order.UserID = primitive.NewObjectID()
+	order.Side = types.BUY
+	order.Price = 100
+	order.Amount = amount
+	order.FilledAmount = filled
+	order.Status = status
+	order.UpdatedAt = updatedAt
+
+	return order
+}
+
+func TestBookApply(t *testing.T) {
+	now := time.Now()
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name   string
+		orders []*_orderbookType.Order
+		bids   map[float64]float64
+	}{
+		{
+			name:   "open",
+			orders: []*_orderbookType.Order{bookOrder(id, types.OPEN, 5, "0", now)},
+			bids:   map[float64]float64{100: 5},
+		},
+		{
+			name: "partially filled",
+			orders: []*_orderbookType.Order{
+				bookOrder(id, types.OPEN, 5, "0", now),
+				bookOrder(id, types.PARTIALLY_FILLED, 5, "2", now.Add(time.Millisecond)),
+			},
+			bids: map[float64]float64{100: 3},
+		},
+		{
+			name: "replayed",
+			orders: []*_orderbookType.Order{
+				bookOrder(id, types.OPEN, 5, "0", now),
+				bookOrder(id, types.OPEN, 5, "0", now),
+			},
+			bids: map[float64]float64{100: 5},
+		},
+		{
+			name: "older partial fill dropped",
+			orders: []*_orderbookType.Order{
+				bookOrder(id, types.PARTIALLY_FILLED, 5, "3", now.Add(time.Millisecond)),
+				bookOrder(id, types.PARTIALLY_FILLED, 5, "1", now),
+			},
+			bids: map[float64]float64{100: 2},
+		},
+		{
+			name: "filled",
+			orders: []*_orderbookType.Order{
+				bookOrder(id, types.OPEN, 5, "0", now),
+				bookOrder(id, types.FILLED, 5, "5", now.Add(time.Millisecond)),
+			},
+			bids: map[float64]float64{},
+		},
+		{
+			name: "open after the fill dropped",
+			orders: []*_orderbookType.Order{
+				bookOrder(id, types.FILLED, 5, "5", now.Add(time.Millisecond)),
+				bookOrder(id, types.OPEN, 5, "0", now),
+			},
+			bids: map[float64]float64{},
+		},
+		{
+			name: "without update time",
+			orders: []*_orderbookType.Order{
+				bookOrder(id, types.OPEN, 5, "0", now),
+				bookOrder(id, types.PARTIALLY_FILLED, 5, "1", time.Time{}),
+			},
+			bids: map[float64]float64{100: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBook("BTC-28JAN22-50000-C")
+			for _, order := range tt.orders {
+				b.apply(order)
+			}
+
+			assert.Equal(t, tt.bids, b.bids)
+			assert.Empty(t, b.asks)
+		})
+	}
+}
+
+func TestBookCancel(t *testing.T) {
+	now := time.Now()
+	id := primitive.NewObjectID()
+
+	b := newBook("BTC-28JAN22-50000-C")
+	b.apply(bookOrder(id, types.OPEN, 5, "0", now))
+	b.cancel(bookOrder(id, types.CANCELLED, 5, "0", now.Add(time.Millisecond)))
+	assert.Empty(t, b.bids)
+	assert.Empty(t, b.orders)
+
+	// the open state consumed after the cancel does not bring the order back
+	b.apply(bookOrder(id, types.OPEN, 5, "0", now))
+	assert.Empty(t, b.bids)
+
+	// a cancel older than the state in the book is dropped
+	other := primitive.NewObjectID()
+	b.apply(bookOrder(other, types.PARTIALLY_FILLED, 5, "1", now.Add(time.Second)))
+	b.cancel(bookOrder(other, types.CANCELLED, 5, "0", now))
+	assert.Equal(t, map[float64]float64{100: 4}, b.bids)
+}
+
+func TestBookOrderbook(t *testing.T) {
+	now := time.Now()
+
+	b := newBook("BTC-28JAN22-50000-C")
+	b.apply(bookOrder(primitive.NewObjectID(), types.OPEN, 1, "0", now))
+	b.apply(bookOrder(primitive.NewObjectID(), types.OPEN, 2, "0", now))
+
+	sell := bookOrder(primitive.NewObjectID(), types.OPEN, 3, "0", now)
+	sell.Side = types.SELL
+	sell.Price = 110
+	b.apply(sell)
+
+	ob := b.Orderbook(_orderbookType.GetOrderBook{InstrumentName: "BTC-28JAN22-50000-C"})
+	assert.Equal(t, []*_orderbookType.WsOrder{{Price: 100, Amount: 3}}, ob.Bids)
+	assert.Equal(t, []*_orderbookType.WsOrder{{Price: 110, Amount: 3}}, ob.Asks)
+}
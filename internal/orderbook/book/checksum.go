@@ -0,0 +1,104 @@
+package book
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	_orderbookType "gateway/internal/orderbook/types"
+	"gateway/pkg/redis"
+	"gateway/pkg/utils"
+)
+
+// ChecksumDepth is the number of levels of each side covered by the checksum
+const ChecksumDepth = 10
+
+// Checksum is the CRC32 (IEEE) of the top levels of the book, the levels are listed from
+// the best one and a bid is followed by the ask of the same rank:
+// "bid_price:bid_amount:ask_price:ask_amount:..." with the numbers in their shortest
+// decimal form. A side with fewer levels is skipped once exhausted
+func Checksum(ob _orderbookType.Orderbook) uint32 {
+	parts := make([]string, 0, 4*ChecksumDepth)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	// the levels are sorted by price, the best bid is the last one
+	for i := 0; i < ChecksumDepth; i++ {
+		if i < len(ob.Bids) {
+			bid := ob.Bids[len(ob.Bids)-1-i]
+			parts = append(parts, format(bid.Price), format(bid.Amount))
+		}
+		if i < len(ob.Asks) {
+			ask := ob.Asks[i]
+			parts = append(parts, format(ask.Price), format(ask.Amount))
+		}
+	}
+
+	return crc32.ChecksumIEEE([]byte(strings.Join(parts, ":")))
+}
+
+// Snapshot is the public book of a book.{instrument}.{interval} channel with the
+// change_id of the last notification sent on it
+type Snapshot struct {
+	Book     _orderbookType.Orderbook
+	ChangeId int
+	Checksum uint32
+}
+
+// GetSnapshot returns the book the clients of the channel should have after the
+// notification of the change id. The changes are absolute amounts of a level so a
+// book which already has the next change still converges once it is applied
+func GetSnapshot(pool *redis.RedisConnectionPool, instrument, interval string) (Snapshot, error) {
+	var snapshot Snapshot
+
+	ins, err := utils.ParseInstruments(instrument, false)
+	if err != nil {
+		return snapshot, err
+	}
+	instrument = instrumentName(ins.Underlying, ins.ExpDate, ins.Strike, ins.Contracts)
+	o := _orderbookType.GetOrderBook{InstrumentName: instrument}
+
+	changeId := func() (int, error) {
+		res, err := pool.GetValue("CHANGEID-" + instrument)
+		if res == "" || err != nil {
+			return 0, err
+		}
+
+		var change _orderbookType.Change
+		if err := json.Unmarshal([]byte(res), &change); err != nil {
+			return 0, err
+		}
+
+		return change.Id, nil
+	}
+
+	// read again when a change was sent while the book was read
+	for i := 0; i < 3; i++ {
+		before, err := changeId()
+		if err != nil {
+			return snapshot, err
+		}
+
+		snapshot.ChangeId = before
+		if interval == "agg2" {
+			snapshot.Book = Aggregated(o)
+		} else {
+			snapshot.Book = Orderbook(o)
+		}
+
+		after, err := changeId()
+		if err != nil {
+			return snapshot, err
+		}
+
+		if before == after {
+			break
+		}
+	}
+
+	snapshot.Checksum = Checksum(snapshot.Book)
+
+	return snapshot, nil
+}
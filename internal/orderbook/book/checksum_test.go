@@ -0,0 +1,81 @@
+package book
+
+import (
+	"testing"
+
+	_orderbookType "gateway/internal/orderbook/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func levels(prices ...float64) []*_orderbookType.WsOrder {
+	orders := make([]*_orderbookType.WsOrder, 0, len(prices)/2)
+	for i := 0; i+1 < len(prices); i += 2 {
+		orders = append(orders, &_orderbookType.WsOrder{Price: prices[i], Amount: prices[i+1]})
+	}
+
+	return orders
+}
+
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		name string
+		book _orderbookType.Orderbook
+		want uint32
+	}{
+		{
+			name: "empty",
+			book: _orderbookType.Orderbook{},
+			want: 0,
+		},
+		{
+			// "101:2:102:3:100:1:103:4"
+			name: "best levels first",
+			book: _orderbookType.Orderbook{
+				Bids: levels(100, 1, 101, 2),
+				Asks: levels(102, 3, 103, 4),
+			},
+			want: 1296230084,
+		},
+		{
+			// "100.5:0.25:101:1.5"
+			name: "shortest decimals",
+			book: _orderbookType.Orderbook{
+				Bids: levels(100.5, 0.25),
+				Asks: levels(101, 1.5),
+			},
+			want: 3916012595,
+		},
+		{
+			// "101:2:100:1"
+			name: "one side",
+			book: _orderbookType.Orderbook{
+				Bids: levels(100, 1, 101, 2),
+			},
+			want: 1639775960,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Checksum(tt.book))
+		})
+	}
+}
+
+func TestChecksumDepth(t *testing.T) {
+	var bids, asks []float64
+	for i := 0; i < ChecksumDepth+2; i++ {
+		bids = append(bids, float64(100+i), 1)
+		asks = append(asks, float64(200+i), 1)
+	}
+
+	// the bids are sorted by price, the best ones are the last ones
+	book := _orderbookType.Orderbook{Bids: levels(bids...), Asks: levels(asks...)}
+	top := _orderbookType.Orderbook{
+		Bids: book.Bids[len(book.Bids)-ChecksumDepth:],
+		Asks: book.Asks[:ChecksumDepth],
+	}
+
+	assert.Equal(t, Checksum(top), Checksum(book))
+}
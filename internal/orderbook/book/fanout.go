@@ -0,0 +1,30 @@
+package book
+
+import (
+	"encoding/json"
+
+	_orderbookType "gateway/internal/orderbook/types"
+	"gateway/pkg/fanout"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+// the other nodes keep their books with the orders of the partitions they do not consume
+const fanoutOrders = "book.orders"
+
+type ordersMessage struct {
+	Orders    []*_orderbookType.Order `json:"orders"`
+	Cancelled bool                    `json:"cancelled"`
+}
+
+func registerFanout() {
+	fanout.Register(fanoutOrders, func(payload json.RawMessage) {
+		var msg ordersMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			logs.Log.Error().Err(err).Msg("failed to decode fanout orders")
+			return
+		}
+
+		apply(msg.Orders, msg.Cancelled)
+	})
+}
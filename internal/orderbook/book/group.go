@@ -0,0 +1,54 @@
+package book
+
+import (
+	"math"
+	"sort"
+
+	_orderbookType "gateway/internal/orderbook/types"
+)
+
+// Grouped buckets the levels by the group size and keeps the best depth levels of each
+// side, the bids are rounded down to the group and the asks up. The bids are sorted from
+// the highest price and the asks from the lowest one. A group of 0 keeps the prices
+func Grouped(ob _orderbookType.Orderbook, group float64, depth int) (bids, asks [][]float64) {
+	bucket := func(levels []*_orderbookType.WsOrder, round func(float64) float64) map[float64]float64 {
+		buckets := make(map[float64]float64)
+		for _, level := range levels {
+			price := level.Price
+			if group > 0 {
+				// the tolerance and the rounding absorb the float error of the division
+				price = math.Round(round(price/group)*group*1e8) / 1e8
+			}
+			buckets[price] += level.Amount
+		}
+
+		return buckets
+	}
+
+	top := func(buckets map[float64]float64, less func(a, b float64) bool) [][]float64 {
+		prices := make([]float64, 0, len(buckets))
+		for price := range buckets {
+			prices = append(prices, price)
+		}
+		sort.Slice(prices, func(i, j int) bool { return less(prices[i], prices[j]) })
+
+		if depth > 0 && len(prices) > depth {
+			prices = prices[:depth]
+		}
+
+		levels := make([][]float64, 0, len(prices))
+		for _, price := range prices {
+			levels = append(levels, []float64{price, buckets[price]})
+		}
+
+		return levels
+	}
+
+	floor := func(v float64) float64 { return math.Floor(v + epsilon) }
+	ceil := func(v float64) float64 { return math.Ceil(v - epsilon) }
+
+	bids = top(bucket(ob.Bids, floor), func(a, b float64) bool { return a > b })
+	asks = top(bucket(ob.Asks, ceil), func(a, b float64) bool { return a < b })
+
+	return bids, asks
+}
@@ -0,0 +1,67 @@
+package book
+
+import (
+	"testing"
+
+	_orderbookType "gateway/internal/orderbook/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrouped(t *testing.T) {
+	book := _orderbookType.Orderbook{
+		Bids: levels(98, 1, 99.5, 2, 101, 3, 104, 4),
+		Asks: levels(105, 1, 106.5, 2, 109, 3, 111, 4),
+	}
+
+	tests := []struct {
+		name  string
+		group float64
+		depth int
+		bids  [][]float64
+		asks  [][]float64
+	}{
+		{
+			name:  "no group",
+			group: 0,
+			depth: 0,
+			bids:  [][]float64{{104, 4}, {101, 3}, {99.5, 2}, {98, 1}},
+			asks:  [][]float64{{105, 1}, {106.5, 2}, {109, 3}, {111, 4}},
+		},
+		{
+			name:  "bids rounded down and asks up",
+			group: 5,
+			depth: 0,
+			bids:  [][]float64{{100, 7}, {95, 3}},
+			asks:  [][]float64{{105, 1}, {110, 5}, {115, 4}},
+		},
+		{
+			name:  "depth",
+			group: 5,
+			depth: 1,
+			bids:  [][]float64{{100, 7}},
+			asks:  [][]float64{{105, 1}},
+		},
+		{
+			name:  "decimal group",
+			group: 0.5,
+			depth: 2,
+			bids:  [][]float64{{104, 4}, {101, 3}},
+			asks:  [][]float64{{105, 1}, {106.5, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bids, asks := Grouped(book, tt.group, tt.depth)
+			assert.Equal(t, tt.bids, bids)
+			assert.Equal(t, tt.asks, asks)
+		})
+	}
+}
+
+func TestGroupedEmpty(t *testing.T) {
+	bids, asks := Grouped(_orderbookType.Orderbook{}, 10, 5)
+	assert.Empty(t, bids)
+	assert.Empty(t, asks)
+}
@@ -8,12 +8,14 @@ import (
 
 type IOrderbookService interface {
 	HandleConsume(message *sarama.ConsumerMessage)
-	HandleConsumeBook(message *sarama.ConsumerMessage)
-	HandleConsumeBookCancel(message *sarama.ConsumerMessage)
+	HandleConsumeOrders(message *sarama.ConsumerMessage) error
+	HandleConsumeOrdersCancel(message *sarama.ConsumerMessage) error
+	HandleConsumeBook(message *sarama.ConsumerMessage) error
+	HandleConsumeBookCancel(message *sarama.ConsumerMessage) error
 	HandleConsumeBookAgg(instrument string, order types.Order, isCancelledAll bool, cancelledBooks map[string]types.OrderbookMap)
 	HandleConsumeUserChange(message *sarama.ConsumerMessage)
-	HandleConsumeUserChangeCancel(message *sarama.ConsumerMessage)
-	HandleConsumeTicker(message *sarama.ConsumerMessage)
-	HandleConsumeTickerCancel(message *sarama.ConsumerMessage)
+	HandleConsumeUserChangeCancel(message *sarama.ConsumerMessage) error
+	HandleConsumeTicker(message *sarama.ConsumerMessage) error
+	HandleConsumeTickerCancel(message *sarama.ConsumerMessage) error
 	Handle100msInterval(instrument string)
 }
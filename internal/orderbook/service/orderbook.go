@@ -16,6 +16,7 @@ import (
 
 	_engineType "gateway/internal/engine/types"
 	ordermatch "gateway/internal/fix-acceptor"
+	"gateway/internal/orderbook/book"
 	"gateway/internal/orderbook/types"
 	wsService "gateway/internal/ws/service"
 
@@ -66,49 +67,81 @@ func (svc orderbookHandler) HandleConsume(msg *sarama.ConsumerMessage) {
 	ws.GetOrderBookSocket().BroadcastMessage(instrument, data)
 }
 
+// HandleConsumeOrders updates the book in memory, it runs before the handlers reading it
+func (svc orderbookHandler) HandleConsumeOrders(msg *sarama.ConsumerMessage) error {
+	var data _engineType.EngineResponse
+
+	err := json.Unmarshal(msg.Value, &data)
+	if err != nil {
+		return err
+	}
+
+	if data.Matches == nil || data.Matches.TakerOrder == nil {
+		return nil
+	}
+
+	book.Apply(append([]*types.Order{data.Matches.TakerOrder}, data.Matches.MakerOrders...)...)
+
+	return nil
+}
+
+func (svc orderbookHandler) HandleConsumeOrdersCancel(msg *sarama.ConsumerMessage) error {
+	var data types.CancelledOrder
+
+	err := json.Unmarshal(msg.Value, &data)
+	if err != nil {
+		return err
+	}
+
+	book.Cancel(data.Data...)
+
+	return nil
+}
+
 func (svc orderbookHandler) HandleConsumeUserChange(msg *sarama.ConsumerMessage) {
 	svc.wsOBSvc.HandleConsumeUserChange(msg)
 }
 
-func (svc orderbookHandler) HandleConsumeUserChangeCancel(msg *sarama.ConsumerMessage) {
+func (svc orderbookHandler) HandleConsumeUserChangeCancel(msg *sarama.ConsumerMessage) error {
 	var data kafka.CancelledOrder
 
 	err := json.Unmarshal(msg.Value, &data)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	for _, order := range data.Data {
 		svc.wsOBSvc.HandleConsumeUserChangeCancel(*order)
 	}
+
+	return nil
 }
 
-func (svc orderbookHandler) HandleConsumeTicker(msg *sarama.ConsumerMessage) {
+func (svc orderbookHandler) HandleConsumeTicker(msg *sarama.ConsumerMessage) error {
 	var data _engineType.EngineResponse
 
 	err := json.Unmarshal(msg.Value, &data)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	if data.Matches == nil && len(data.Matches.TakerOrder.Contracts) > 0 {
-		return
+		return nil
 	}
 
 	_instrument := data.Matches.TakerOrder.Underlying + "-" + data.Matches.TakerOrder.ExpiryDate + "-" + fmt.Sprintf("%.0f", data.Matches.TakerOrder.StrikePrice) + "-" + string(data.Matches.TakerOrder.Contracts[0])
 
 	svc.wsOBSvc.HandleConsumeTicker(_instrument, "raw")
+
+	return nil
 }
 
-func (svc orderbookHandler) HandleConsumeTickerCancel(msg *sarama.ConsumerMessage) {
+func (svc orderbookHandler) HandleConsumeTickerCancel(msg *sarama.ConsumerMessage) error {
 	var data kafka.CancelledOrder
 
 	err := json.Unmarshal(msg.Value, &data)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	keys := make(map[interface{}]bool)
@@ -125,20 +158,21 @@ func (svc orderbookHandler) HandleConsumeTickerCancel(msg *sarama.ConsumerMessag
 	for _, instrument := range instruments {
 		svc.wsOBSvc.HandleConsumeTicker(instrument, "raw")
 	}
+
+	return nil
 }
 
-func (svc orderbookHandler) HandleConsumeBook(msg *sarama.ConsumerMessage) {
+func (svc orderbookHandler) HandleConsumeBook(msg *sarama.ConsumerMessage) error {
 	var order types.Order
 	var data _engineType.EngineResponse
 
 	err := json.Unmarshal(msg.Value, &data)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	if data.Matches == nil {
-		return
+		return nil
 	}
 
 	order = *data.Matches.TakerOrder
@@ -165,12 +199,11 @@ func (svc orderbookHandler) HandleConsumeBook(msg *sarama.ConsumerMessage) {
 	} else {
 		err = json.Unmarshal([]byte(res), &changeId)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 	}
-	// Get latest data from db
-	orderBook := svc.wsOBSvc.GetOrderLatestTimestamp(_order, ts, false)
+	// Get latest data from the book in memory
+	orderBook := book.Orderbook(_order)
 
 	var bidsData = make([][]interface{}, 0)
 	var asksData = make([][]interface{}, 0)
@@ -339,8 +372,7 @@ func (svc orderbookHandler) HandleConsumeBook(msg *sarama.ConsumerMessage) {
 	//convert changeIdNew to json
 	jsonBytes, err := json.Marshal(changeIdNew)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	svc.redis.Set("CHANGEID-"+_instrument, string(jsonBytes))
 
@@ -379,6 +411,7 @@ func (svc orderbookHandler) HandleConsumeBook(msg *sarama.ConsumerMessage) {
 		PrevChangeId:   changeIdNew.IdPrev,
 		Bids:           bidsData,
 		Asks:           asksData,
+		Checksum:       book.Checksum(orderBook),
 	}
 
 	params := types.QuoteResponse{
@@ -388,16 +421,17 @@ func (svc orderbookHandler) HandleConsumeBook(msg *sarama.ConsumerMessage) {
 	method := "subscription"
 	broadcastId := fmt.Sprintf("%s-raw", _instrument)
 	ws.GetBookSocket().BroadcastMessage(broadcastId, method, params)
+
+	return nil
 }
 
-func (svc orderbookHandler) HandleConsumeBookCancel(msg *sarama.ConsumerMessage) {
+func (svc orderbookHandler) HandleConsumeBookCancel(msg *sarama.ConsumerMessage) error {
 	var _instrument string
 
 	var data types.CancelledOrder
 	err := json.Unmarshal(msg.Value, &data)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	orders := data.Data
 	books := make(map[string]types.OrderbookMap)
@@ -489,12 +523,11 @@ func (svc orderbookHandler) HandleConsumeBookCancel(msg *sarama.ConsumerMessage)
 		} else {
 			err = json.Unmarshal([]byte(res), &changeId)
 			if err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 		}
-		// Get latest data from db
-		orderBook := svc.wsOBSvc.GetOrderLatestTimestamp(_order, ts, false)
+		// Get latest data from the book in memory
+		orderBook := book.Orderbook(_order)
 
 		var askKeys = make(map[interface{}]bool)
 		var bidKeys = make(map[interface{}]bool)
@@ -623,8 +656,7 @@ func (svc orderbookHandler) HandleConsumeBookCancel(msg *sarama.ConsumerMessage)
 		//convert changeIdNew to json
 		jsonBytes, err := json.Marshal(changeIdNew)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		svc.redis.Set("CHANGEID-"+_instrument, string(jsonBytes))
 
@@ -665,6 +697,7 @@ func (svc orderbookHandler) HandleConsumeBookCancel(msg *sarama.ConsumerMessage)
 			PrevChangeId:   changeIdNew.IdPrev,
 			Bids:           bidsData,
 			Asks:           asksData,
+			Checksum:       book.Checksum(orderBook),
 		}
 
 		params := types.QuoteResponse{
@@ -675,6 +708,8 @@ func (svc orderbookHandler) HandleConsumeBookCancel(msg *sarama.ConsumerMessage)
 		broadcastId := fmt.Sprintf("%s-raw", _instrument)
 		ws.GetBookSocket().BroadcastMessage(broadcastId, method, params)
 	}
+
+	return nil
 }
 
 func (svc orderbookHandler) HandleConsumeBookAgg(_instrument string, order types.Order, isCancelledAll bool, cancelledBooks map[string]types.OrderbookMap) {
@@ -699,7 +734,7 @@ func (svc orderbookHandler) HandleConsumeBookAgg(_instrument string, order types
 	}
 
 	// Get data
-	orderBook := svc.wsOBSvc.GetOrderLatestTimestampAgg(_order, changeId.Timestamp)
+	orderBook := book.Aggregated(_order)
 
 	var askKeys = make(map[interface{}]bool)
 	var bidKeys = make(map[interface{}]bool)
@@ -926,6 +961,7 @@ func (svc orderbookHandler) HandleConsumeBookAgg(_instrument string, order types
 		PrevChangeId:   changeId.IdPrev,
 		Bids:           bidsData,
 		Asks:           asksData,
+		Checksum:       book.Checksum(orderBook),
 	}
 
 	params := types.QuoteResponse{
@@ -984,7 +1020,6 @@ func (svc orderbookHandler) Handle100msInterval(instrument string) {
 						StrikePrice:    _strikePrice,
 					}
 
-					ts := time.Now().UnixNano() / int64(time.Millisecond)
 					var changeId types.Change
 					// Get saved orderbook from redis
 					res, err := svc.redis.GetValue("CHANGEID-" + instrument)
@@ -997,8 +1032,8 @@ func (svc orderbookHandler) Handle100msInterval(instrument string) {
 							continue
 						}
 					}
-					// Get latest data from db
-					orderBook := svc.wsOBSvc.GetOrderLatestTimestamp(_order, ts, false)
+					// Get latest data from the book in memory
+					orderBook := book.Orderbook(_order)
 
 					var askKeys = make(map[interface{}]bool)
 					var bidKeys = make(map[interface{}]bool)
@@ -1223,6 +1258,7 @@ func (svc orderbookHandler) Handle100msInterval(instrument string) {
 						PrevChangeId:   prevId,
 						Bids:           bidsData,
 						Asks:           asksData,
+						Checksum:       book.Checksum(orderBook),
 					}
 
 					params := types.QuoteResponse{
@@ -8,6 +8,7 @@ import (
 
 	"github.com/Undercurrent-Technologies/kprime-utilities/models"
 	"github.com/shopspring/decimal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Message struct {
@@ -45,6 +46,17 @@ type WsOrder struct {
 	Amount float64 `json:"amount" bson:"amount"`
 }
 
+// BookOrder is the open part of an order resting in the book
+type BookOrder struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	UserID    string             `json:"userId" bson:"userId"`
+	UserRole  string             `json:"userRole" bson:"userRole"`
+	Side      types.Side         `json:"side" bson:"side"`
+	Price     float64            `json:"price" bson:"price"`
+	Amount    float64            `json:"amount" bson:"amount"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
 type Count struct {
 	Count int `json:"count" bson:"count"`
 }
@@ -54,6 +66,7 @@ type Order struct {
 	InstrumentName       string          `json:"instrumentName,omitempty" bson:"instrumentName"`
 	Symbol               string          `json:"symbol,omitempty" bson:"symbol"`
 	SenderCompID         string          `json:"sender_comp_id,omitempty" bson:"sender_comp_id"`
+	RequestID            string          `json:"requestId,omitempty" bson:"requestId,omitempty"`
 	InsertTime           time.Time       `json:"-"`
 	LastExecutedQuantity decimal.Decimal `json:"-"`
 	LastExecutedPrice    decimal.Decimal `json:"-"`
@@ -98,6 +111,16 @@ type BookData struct {
 	PrevChangeId   int             `json:"prev_change_id,omitempty"`
 	Bids           [][]interface{} `json:"bids"`
 	Asks           [][]interface{} `json:"asks"`
+	Checksum       uint32          `json:"checksum"`
+}
+
+// BookGroupData is the snapshot of a book.{instrument}.{group}.{depth}.{interval} channel
+type BookGroupData struct {
+	Timestamp      int64       `json:"timestamp"`
+	InstrumentName string      `json:"instrument_name"`
+	ChangeId       int         `json:"change_id"`
+	Bids           [][]float64 `json:"bids"`
+	Asks           [][]float64 `json:"asks"`
 }
 
 type Change struct {
@@ -0,0 +1,9 @@
+package service
+
+import "context"
+
+type IOutboxService interface {
+	Publish(ctx context.Context, topic, requestId, userId, clOrdId string, payload []byte) error
+	Acknowledge(requestId string) bool
+	Relay()
+}
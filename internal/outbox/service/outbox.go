@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"gateway/internal/outbox/types"
+	"gateway/internal/repositories"
+	"gateway/pkg/kafka/producer"
+	"gateway/pkg/middleware/api"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/gin-gonic/gin"
+)
+
+type outboxService struct {
+	r *gin.Engine
+
+	repo *repositories.OutboxRepository
+}
+
+func NewOutboxService(
+	r *gin.Engine,
+
+	repo *repositories.OutboxRepository,
+) IOutboxService {
+	svc := outboxService{r, repo}
+	svc.RegisterRoutes()
+
+	return &svc
+}
+
+func (svc *outboxService) RegisterRoutes() {
+	internalAPI := svc.r.Group("api/internal")
+	internalAPI.Use(api.IPWhitelist(), api.BasicAuth())
+
+	internalAPI.GET("/outbox", svc.handleStuck)
+}
+
+// Publish writes the command to the outbox then sends it to kafka. Once written the
+// command is accepted, when kafka is unreachable the relay sends it later. The request id
+// is the one of the payload, a command sent twice keeps it so the engine can drop the copy
+func (svc *outboxService) Publish(ctx context.Context, topic, requestId, userId, clOrdId string, payload []byte) error {
+	entry := types.Entry{
+		Topic:     topic,
+		RequestID: requestId,
+		Payload:   string(payload),
+		UserID:    userId,
+		ClOrdID:   clOrdId,
+	}
+
+	if err := svc.repo.Insert(&entry); err != nil {
+		logs.Log.Error().Err(err).Msg("failed to write outbox entry")
+		return err
+	}
+
+	svc.send(&entry)
+
+	return nil
+}
+
+// Acknowledge marks the command of the request once the engine answered it, it returns
+// false for another answer to a command which was already answered. The answers without
+// a request id are not from the outbox
+func (svc *outboxService) Acknowledge(requestId string) bool {
+	if requestId == "" {
+		return true
+	}
+
+	first, err := svc.repo.Acknowledge(requestId)
+	if err != nil {
+		logs.Log.Error().Err(err).Str("request_id", requestId).Msg("failed to acknowledge outbox entry")
+		return true
+	}
+
+	return first
+}
+
+// Relay sends the pending entries left by a failed send or a restart, in the order
+// they were written
+func (svc *outboxService) Relay() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		// a command pending for too long is not sent anymore, the client gave up on it
+		if dead, err := svc.repo.MarkDead(now.Add(-pendingTTL())); err != nil {
+			logs.Log.Error().Err(err).Msg("failed to update outbox entries")
+		} else if dead > 0 {
+			logs.Log.Error().Int64("entries", dead).Msg("outbox entries pending for too long")
+		}
+
+		// an entry is only retried once its send would have timed out
+		before := now.Add(-relayDelay())
+
+		for {
+			entry, err := svc.repo.ClaimPending(before)
+			if err != nil {
+				logs.Log.Error().Err(err).Msg("failed to claim outbox entry")
+				break
+			}
+
+			if entry == nil {
+				break
+			}
+
+			if !svc.send(entry) {
+				// keep the order, the next entries wait for this one
+				break
+			}
+		}
+	}
+}
+
+func (svc *outboxService) send(entry *types.Entry) bool {
+	if err := producer.KafkaProducer(entry.Payload, entry.Topic); err != nil {
+		logs.Log.Error().Err(err).Str("outbox_id", entry.ID.Hex()).Msg("outbox entry not sent")
+
+		if err := svc.repo.MarkFailed(entry.ID, err); err != nil {
+			logs.Log.Error().Err(err).Msg("failed to update outbox entry")
+		}
+		return false
+	}
+
+	if err := svc.repo.MarkPublished(entry.ID); err != nil {
+		logs.Log.Error().Err(err).Msg("failed to update outbox entry")
+	}
+
+	return true
+}
+
+func relayDelay() time.Duration {
+	timeout, err := strconv.Atoi(os.Getenv("KAFKA_PRODUCER_TIMEOUT"))
+	if err != nil || timeout < 1 {
+		timeout = 10
+	}
+
+	return time.Duration(timeout+5) * time.Second
+}
+
+func pendingTTL() time.Duration {
+	ttl, err := strconv.Atoi(os.Getenv("OUTBOX_PENDING_TTL"))
+	if err != nil || ttl < 1 {
+		ttl = 60
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+// @BasePath /api/internal
+
+// List stuck outbox entries godoc
+// @Summary List the outbox entries not acknowledged by the engine
+// @Schemes
+// @Description entries are stuck when they are pending or published for too long, dead entries were pending for longer than OUTBOX_PENDING_TTL
+// @Tags internal
+// @Produce json
+// @Param status query string false "PENDING, PUBLISHED or DEAD, PENDING and PUBLISHED by default"
+// @Param older_than query int false "age in seconds, 30 by default"
+// @Param limit query int false "100 by default"
+// @Success 200 {array} types.Entry
+// @Router /outbox [get]
+func (svc *outboxService) handleStuck(c *gin.Context) {
+	var req types.StuckRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := []types.Status{types.PENDING, types.PUBLISHED}
+	switch req.Status {
+	case "":
+	case types.PENDING, types.PUBLISHED, types.DEAD:
+		status = []types.Status{req.Status}
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+
+	if req.OlderThan <= 0 {
+		req.OlderThan = 30
+	}
+
+	if req.Limit <= 0 || req.Limit > 1000 {
+		req.Limit = 100
+	}
+
+	before := time.Now().Add(-time.Duration(req.OlderThan) * time.Second)
+	entries, err := svc.repo.FindStuck(status, before, req.Limit)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
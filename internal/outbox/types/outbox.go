@@ -0,0 +1,44 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Status string
+
+const (
+	// written, not on kafka yet
+	PENDING Status = "PENDING"
+	// on kafka, waiting for the engine
+	PUBLISHED Status = "PUBLISHED"
+	// answered by the engine
+	ACKNOWLEDGED Status = "ACKNOWLEDGED"
+	// pending for too long, not sent anymore
+	DEAD Status = "DEAD"
+)
+
+type Entry struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id"`
+	Topic string             `json:"topic" bson:"topic"`
+	// the id of the command made by the gateway, the engine echoes it in its answer
+	RequestID      string     `json:"requestId" bson:"requestId"`
+	Payload        string     `json:"payload" bson:"payload"`
+	UserID         string     `json:"userId" bson:"userId"`
+	ClOrdID        string     `json:"clOrdId" bson:"clOrdId"`
+	Status         Status     `json:"status" bson:"status"`
+	Attempts       int        `json:"attempts" bson:"attempts"`
+	LastError      string     `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt" bson:"updatedAt"`
+	PublishedAt    *time.Time `json:"publishedAt,omitempty" bson:"publishedAt,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty" bson:"acknowledgedAt,omitempty"`
+	DeadAt         *time.Time `json:"deadAt,omitempty" bson:"deadAt,omitempty"`
+}
+
+type StuckRequest struct {
+	Status    Status `form:"status"`
+	OlderThan int64  `form:"older_than"`
+	Limit     int64  `form:"limit"`
+}
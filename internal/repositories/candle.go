@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/candle"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// the stores of the candles, a rebuild writes the one not in use and swaps them
+const (
+	candleStore        = "candles"
+	candleRebuildStore = "candles_rebuild"
+)
+
+// CandleStoreRefresh is how long a node keeps writing the store it read last, a rebuild
+// waits that long after the swap before it drops the previous store
+const CandleStoreRefresh = 10 * time.Second
+
+// CandleRepository is the candle store, a time-series collection of the closed candles
+// of the stored resolutions
+type CandleRepository struct {
+	db     *mongo.Database
+	stores *mongo.Collection
+
+	current *currentCandleStore
+}
+
+type currentCandleStore struct {
+	mu     sync.Mutex
+	name   string
+	readAt time.Time
+}
+
+func NewCandleRepository(db Database) *CandleRepository {
+	stores := db.InitCollection("candle_stores")
+	return &CandleRepository{stores.Database(), stores, &currentCandleStore{}}
+}
+
+func (r CandleRepository) readStore() (string, error) {
+	var doc struct {
+		Collection string `bson:"collection"`
+	}
+	err := r.stores.FindOne(context.Background(), bson.M{"_id": candleStore}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return candleStore, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return doc.Collection, nil
+}
+
+// Store returns the store in use, it is read again after CandleStoreRefresh
+func (r CandleRepository) Store() (string, error) {
+	r.current.mu.Lock()
+	defer r.current.mu.Unlock()
+
+	if r.current.name != "" && time.Since(r.current.readAt) < CandleStoreRefresh {
+		return r.current.name, nil
+	}
+
+	name, err := r.readStore()
+	if err != nil {
+		return "", err
+	}
+	r.current.name, r.current.readAt = name, time.Now()
+
+	return name, nil
+}
+
+// RebuildStore returns the store which is not in use
+func (r CandleRepository) RebuildStore() (string, error) {
+	name, err := r.readStore()
+	if err != nil {
+		return "", err
+	}
+
+	if name == candleRebuildStore {
+		return candleStore, nil
+	}
+
+	return candleRebuildStore, nil
+}
+
+// Swap makes the store the one in use
+func (r CandleRepository) Swap(store string) error {
+	_, err := r.stores.UpdateOne(
+		context.Background(),
+		bson.M{"_id": candleStore},
+		bson.M{"$set": bson.M{"collection": store, "updatedAt": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	r.current.mu.Lock()
+	r.current.name, r.current.readAt = store, time.Now()
+	r.current.mu.Unlock()
+
+	return nil
+}
+
+// EnsureCollection creates the time-series collection of the store when it does not exist
+func (r CandleRepository) EnsureCollection(store string) error {
+	names, err := r.db.ListCollectionNames(context.Background(), bson.M{"name": store})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return nil
+	}
+
+	timeSeries := options.TimeSeries().
+		SetTimeField("tick").
+		SetMetaField("meta").
+		SetGranularity("minutes")
+
+	return r.db.CreateCollection(context.Background(), store, options.CreateCollection().SetTimeSeriesOptions(timeSeries))
+}
+
+// Drop removes the store with all its candles
+func (r CandleRepository) Drop(store string) error {
+	return r.db.Collection(store).Drop(context.Background())
+}
+
+func (r CandleRepository) Insert(store string, candles []*_tradeType.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(candles))
+	now := time.Now()
+	for _, c := range candles {
+		c.CreatedAt = now
+		docs = append(docs, c)
+	}
+
+	_, err := r.db.Collection(store).InsertMany(context.Background(), docs)
+	return err
+}
+
+// LastTicks returns the tick of the latest candle of the resolution in the store by
+// instrument
+func (r CandleRepository) LastTicks(store, resolution string) (map[string]time.Time, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", bson.M{"meta.resolution": resolution}}},
+		bson.D{{"$group", bson.M{
+			"_id":  "$meta.instrumentName",
+			"tick": bson.M{"$max": "$tick"},
+		}}},
+	}
+
+	cursor, err := r.db.Collection(store).Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var groups []struct {
+		InstrumentName string    `bson:"_id"`
+		Tick           time.Time `bson:"tick"`
+	}
+	if err := cursor.All(context.Background(), &groups); err != nil {
+		return nil, err
+	}
+
+	ticks := make(map[string]time.Time, len(groups))
+	for _, g := range groups {
+		ticks[g.InstrumentName] = g.Tick
+	}
+
+	return ticks, nil
+}
+
+// Find returns the candles of the instrument in [start, end) sorted by tick, the latest
+// written candle of a tick wins
+func (r CandleRepository) Find(instrument, resolution string, start, end time.Time) ([]candle.Candle, error) {
+	filter := bson.M{
+		"meta.instrumentName": instrument,
+		"meta.resolution":     resolution,
+		"tick":                bson.M{"$gte": start, "$lt": end},
+	}
+	opts := options.Find().SetSort(bson.D{{"tick", 1}, {"createdAt", 1}})
+
+	store, err := r.Store()
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.db.Collection(store).Find(context.Background(), filter, opts)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var stored []*_tradeType.Candle
+	if err = cursor.All(context.Background(), &stored); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	candles := []candle.Candle{}
+	for _, c := range stored {
+		if n := len(candles); n > 0 && candles[n-1].Tick == c.Tick.UnixMilli() {
+			candles[n-1] = c.Candle()
+			continue
+		}
+		candles = append(candles, c.Candle())
+	}
+
+	return candles, nil
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ensureIndexes creates the indexes of the collection, mongo skips the ones which exist
+func ensureIndexes(collection *mongo.Collection, models ...mongo.IndexModel) {
+	if _, err := collection.Indexes().CreateMany(context.Background(), models); err != nil {
+		logs.Log.Error().Err(err).Str("collection", collection.Name()).Msg("failed to create indexes")
+	}
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InstrumentExpiryRepository holds the instruments closed at their expiry, keyed by the
+// instrument name, so a single node closes each of them
+type InstrumentExpiryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInstrumentExpiryRepository(db Database) *InstrumentExpiryRepository {
+	collection := db.InitCollection("instrument_expiries")
+	return &InstrumentExpiryRepository{collection}
+}
+
+// Lock holds the instrument for the node until the time, it returns false when another
+// node holds it
+func (r InstrumentExpiryRepository) Lock(instrumentName string, now, until time.Time) bool {
+	_, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{
+			"_id": instrumentName,
+			"$or": bson.A{
+				bson.M{"lockedUntil": bson.M{"$exists": false}},
+				bson.M{"lockedUntil": bson.M{"$lte": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"lockedUntil": until, "updatedAt": now}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return false
+	}
+
+	return true
+}
+
+// Close marks the instrument closed, it returns true only for the first close
+func (r InstrumentExpiryRepository) Close(instrumentName string, now time.Time) bool {
+	res, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": instrumentName, "closedAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"closedAt": now, "updatedAt": now}},
+	)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return false
+	}
+
+	return res.ModifiedCount > 0
+}
+
+// Closed returns the names of the closed instruments
+func (r InstrumentExpiryRepository) Closed() (map[string]bool, error) {
+	values, err := r.collection.Distinct(context.Background(), "_id", bson.M{"closedAt": bson.M{"$exists": true}})
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	closed := make(map[string]bool, len(values))
+	for _, v := range values {
+		if name, ok := v.(string); ok {
+			closed[name] = true
+		}
+	}
+
+	return closed, nil
+}
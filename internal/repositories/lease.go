@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeaseRepository holds the leases of the jobs a single node runs at a time, keyed by the
+// job name
+type LeaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLeaseRepository(db Database) *LeaseRepository {
+	collection := db.InitCollection("leases")
+	return &LeaseRepository{collection}
+}
+
+// Acquire holds the job for the owner until the time, the owner renews it the same way.
+// It returns false when another owner holds it
+func (r LeaseRepository) Acquire(name, owner string, now, until time.Time) bool {
+	_, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{
+			"_id": name,
+			"$or": bson.A{
+				bson.M{"owner": owner},
+				bson.M{"lockedUntil": bson.M{"$lte": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"owner": owner, "lockedUntil": until, "updatedAt": now}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return false
+	}
+
+	return true
+}
+
+// Release lets another node take the job
+func (r LeaseRepository) Release(name, owner string) {
+	_, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": name, "owner": owner},
+		bson.M{"$set": bson.M{"lockedUntil": time.Now(), "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+	}
+}
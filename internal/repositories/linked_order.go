@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/linked"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LinkedOrderRepository holds the linked order graphs of the gateway, one document per
+// order of a graph
+type LinkedOrderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLinkedOrderRepository(db Database) *LinkedOrderRepository {
+	collection := db.InitCollection("linked_orders")
+	ensureIndexes(collection,
+		mongo.IndexModel{Keys: bson.D{{"orderId", 1}}},
+		mongo.IndexModel{Keys: bson.D{{"requestId", 1}}},
+		mongo.IndexModel{Keys: bson.D{{"primaryId", 1}, {"primary", 1}}},
+		mongo.IndexModel{Keys: bson.D{{"ocoRef", 1}}},
+	)
+
+	return &LinkedOrderRepository{collection}
+}
+
+func (r LinkedOrderRepository) Find(filter interface{}, sort interface{}, offset, limit int64) ([]*_tradeType.LinkedOrder, error) {
+	options := options.FindOptions{
+		MaxTime: &defaultTimeout,
+	}
+
+	if offset >= 0 {
+		options.SetSkip(offset)
+	}
+
+	if limit >= 0 {
+		options.SetLimit(limit)
+	}
+
+	if sort != nil {
+		options.SetSort(sort)
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	cursor, err := r.collection.Find(context.Background(), filter, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	orders := []*_tradeType.LinkedOrder{}
+	if err = cursor.All(context.Background(), &orders); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// DeleteGraph removes the orders of the graph of the primary order
+func (r LinkedOrderRepository) DeleteGraph(primaryId primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"primaryId": primaryId})
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+	}
+
+	return err
+}
+
+func (r LinkedOrderRepository) InsertMany(orders []*_tradeType.LinkedOrder) error {
+	docs := make([]interface{}, 0, len(orders))
+	for _, o := range orders {
+		docs = append(docs, o)
+	}
+
+	_, err := r.collection.InsertMany(context.Background(), docs)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+	}
+
+	return err
+}
+
+// FindOrder returns the order of a graph placed as the order id or sent with the request
+// id, nil when the order is not linked
+func (r LinkedOrderRepository) FindOrder(orderId primitive.ObjectID, requestId string) (*_tradeType.LinkedOrder, error) {
+	or := bson.A{bson.M{"orderId": orderId}}
+	if requestId != "" {
+		or = append(or, bson.M{"requestId": requestId})
+	}
+
+	orders, err := r.Find(bson.M{"$or": or}, nil, 0, 1)
+	if err != nil || len(orders) == 0 {
+		return nil, err
+	}
+
+	return orders[0], nil
+}
+
+// FindByOrderIds returns the orders of the graphs placed as the order ids
+func (r LinkedOrderRepository) FindByOrderIds(orderIds []primitive.ObjectID) ([]*_tradeType.LinkedOrder, error) {
+	if len(orderIds) == 0 {
+		return []*_tradeType.LinkedOrder{}, nil
+	}
+
+	return r.Find(bson.M{"orderId": bson.M{"$in": orderIds}}, nil, 0, -1)
+}
+
+// Legs returns the orders of the otoco config of the primary order
+func (r LinkedOrderRepository) Legs(primaryId primitive.ObjectID) ([]*_tradeType.LinkedOrder, error) {
+	return r.Find(bson.M{"primaryId": primaryId, "primary": false}, bson.M{"_id": 1}, 0, -1)
+}
+
+// OcoPartners returns the orders cancelled once the order is filled
+func (r LinkedOrderRepository) OcoPartners(order *_tradeType.LinkedOrder) ([]*_tradeType.LinkedOrder, error) {
+	if order.OcoRef == "" {
+		return []*_tradeType.LinkedOrder{}, nil
+	}
+
+	return r.Find(bson.M{"ocoRef": order.OcoRef, "_id": bson.M{"$ne": order.ID}}, bson.M{"_id": 1}, 0, -1)
+}
+
+// SetOrderID sets the order id of the placed order, it returns the updated order or nil
+// when the order id was already set
+func (r LinkedOrderRepository) SetOrderID(id, orderId primitive.ObjectID) (*_tradeType.LinkedOrder, error) {
+	var order _tradeType.LinkedOrder
+	err := r.collection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id, "orderId": bson.M{"$ne": orderId}},
+		bson.M{"$set": bson.M{"orderId": orderId, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// UpdateStatus moves the order from one of the statuses to the next one, it returns the
+// order before the update or nil when the order is in none of the statuses
+func (r LinkedOrderRepository) UpdateStatus(id primitive.ObjectID, from []string, to string) (*_tradeType.LinkedOrder, error) {
+	var order _tradeType.LinkedOrder
+	err := r.collection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		bson.M{"$set": bson.M{"status": to, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// Refs returns the links of the orders to the other orders of their graph by order id,
+// the orders which are not linked are left out
+func (r LinkedOrderRepository) Refs(orderIds []primitive.ObjectID) map[primitive.ObjectID]_tradeType.LinkedRef {
+	orders, err := r.FindByOrderIds(orderIds)
+	if err != nil {
+		return map[primitive.ObjectID]_tradeType.LinkedRef{}
+	}
+
+	byId := r.refs(orders)
+	refs := make(map[primitive.ObjectID]_tradeType.LinkedRef)
+	for _, o := range orders {
+		refs[o.OrderID] = byId[o.ID]
+	}
+
+	return refs
+}
+
+// Ref returns the link of the order placed as the order id or sent with the request id
+func (r LinkedOrderRepository) Ref(orderId primitive.ObjectID, requestId string) _tradeType.LinkedRef {
+	order, err := r.FindOrder(orderId, requestId)
+	if err != nil || order == nil {
+		return _tradeType.LinkedRef{}
+	}
+
+	return r.refs([]*_tradeType.LinkedOrder{order})[order.ID]
+}
+
+// refs returns the links of the orders by their id in the graph, the primary order of an
+// order which triggers others gets the ids of the others
+func (r LinkedOrderRepository) refs(orders []*_tradeType.LinkedOrder) map[primitive.ObjectID]_tradeType.LinkedRef {
+	refs := make(map[primitive.ObjectID]_tradeType.LinkedRef)
+
+	primaryIds := []primitive.ObjectID{}
+	for _, o := range orders {
+		refs[o.ID] = _tradeType.LinkedRef{OcoRef: o.OcoRef}
+		if o.Primary && linked.Triggers(o.LinkedOrderType) {
+			primaryIds = append(primaryIds, o.ID)
+		}
+	}
+	if len(primaryIds) == 0 {
+		return refs
+	}
+
+	legs, err := r.Find(bson.M{"primaryId": bson.M{"$in": primaryIds}, "primary": false}, bson.M{"_id": 1}, 0, -1)
+	if err != nil {
+		return refs
+	}
+	for _, leg := range legs {
+		ref := refs[leg.PrimaryID]
+		ref.OtoOrderIds = append(ref.OtoOrderIds, leg.Ref())
+		refs[leg.PrimaryID] = ref
+	}
+
+	return refs
+}
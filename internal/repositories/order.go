@@ -15,6 +15,8 @@ import (
 	_deribitModel "gateway/internal/deribit/model"
 	_orderbookType "gateway/internal/orderbook/types"
 	"gateway/pkg/memdb"
+	"gateway/pkg/pricing"
+	"gateway/pkg/trigger"
 	"gateway/pkg/utils"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -22,12 +24,14 @@ import (
 )
 
 type OrderRepository struct {
-	collection *mongo.Collection
+	collection    *mongo.Collection
+	triggerOrders *TriggerOrderRepository
+	linkedOrders  *LinkedOrderRepository
 }
 
 func NewOrderRepository(db Database) *OrderRepository {
 	collection := db.InitCollection("orders")
-	return &OrderRepository{collection}
+	return &OrderRepository{collection, NewTriggerOrderRepository(db), NewLinkedOrderRepository(db)}
 }
 
 var defaultTimeout = 10 * time.Second
@@ -78,12 +82,6 @@ func (r OrderRepository) GetInstruments(userId, currency string, expired bool) (
 		return []*_deribitModel.DeribitGetInstrumentsResponse{}, errors.New(reason.String())
 	}
 
-	now := time.Now()
-	loc, _ := time.LoadLocation("Singapore")
-	if loc != nil {
-		now = now.In(loc)
-	}
-
 	projectStage := bson.M{
 		"$project": bson.M{
 			"InstrumentName": bson.M{"$concat": bson.A{
@@ -115,10 +113,6 @@ func (r OrderRepository) GetInstruments(userId, currency string, expired bool) (
 					}}},
 				"_USD",
 			}}},
-			"IsActive": bson.M{
-				"$cond": bson.M{"if": bson.M{"$gt": []interface{}{bson.M{"$toDate": "$expiryDate"}, now}},
-					"then": true,
-					"else": false}},
 			"BaseCurrency": bson.M{"$concat": bson.A{
 				bson.D{
 					{"$convert", bson.D{
@@ -131,8 +125,7 @@ func (r OrderRepository) GetInstruments(userId, currency string, expired bool) (
 					{"input", 1},
 					{"to", "int"},
 				}}},
-			"ExpirationTimestamp": bson.M{"$toLong": bson.M{"$toDate": "$expiryDate"}},
-			"CreationTimestamp":   bson.M{"$toLong": "$createdAt"},
+			"CreationTimestamp": bson.M{"$toLong": "$createdAt"},
 			"Kind": bson.M{"$concat": bson.A{
 				bson.D{
 					{"$convert", bson.D{
@@ -151,7 +144,6 @@ func (r OrderRepository) GetInstruments(userId, currency string, expired bool) (
 
 	match := bson.M{
 		"underlying": currency,
-		"IsActive":   !expired,
 	}
 
 	if user.Role == types.CLIENT {
@@ -187,9 +179,6 @@ func (r OrderRepository) GetInstruments(userId, currency string, expired bool) (
 			"PriceIndex": bson.M{
 				"$first": "$PriceIndex",
 			},
-			"IsActive": bson.M{
-				"$first": "$IsActive",
-			},
 			"BaseCurrency": bson.M{
 				"$first": "$BaseCurrency",
 			},
@@ -199,9 +188,6 @@ func (r OrderRepository) GetInstruments(userId, currency string, expired bool) (
 			"CreationTimestamp": bson.M{
 				"$first": "$CreationTimestamp",
 			},
-			"ExpirationTimestamp": bson.M{
-				"$first": "$ExpirationTimestamp",
-			},
 			"Kind": bson.M{
 				"$first": "$Kind",
 			},
@@ -254,7 +240,151 @@ func (r OrderRepository) GetInstruments(userId, currency string, expired bool) (
 		return []*_deribitModel.DeribitGetInstrumentsResponse{}, err
 	}
 
-	return orders, nil
+	return instrumentsByExpiry(orders, expired, time.Now()), nil
+}
+
+// instrumentsByExpiry returns the instruments expired at now or the active ones with their
+// expiry, the instruments expire at the expiry time of their expiry date, not at its start
+func instrumentsByExpiry(orders []*_deribitModel.DeribitGetInstrumentsResponse, expired bool, now time.Time) []*_deribitModel.DeribitGetInstrumentsResponse {
+	instruments := []*_deribitModel.DeribitGetInstrumentsResponse{}
+	for _, o := range orders {
+		instrument, err := utils.ParseInstruments(o.InstrumentName, false)
+		if err != nil {
+			continue
+		}
+		at, err := pricing.Expiry(instrument.ExpDate)
+		if err != nil {
+			continue
+		}
+
+		o.ExpirationTimestamp = at.UnixMilli()
+		o.IsActive = now.Before(at)
+		if o.IsActive != expired {
+			instruments = append(instruments, o)
+		}
+	}
+
+	return instruments
+}
+
+// GetExpiryInstruments returns the instruments of the options of the expiry which
+// were ever ordered, sorted by strike then contracts
+func (r OrderRepository) GetExpiryInstruments(underlying, expiryDate string) ([]*utils.Instruments, error) {
+	return r.orderedInstruments(bson.M{
+		"underlying": underlying,
+		"expiryDate": expiryDate,
+	})
+}
+
+// GetCurrencyInstruments returns the instruments of the options of the underlying which
+// were ever ordered, sorted by expiry date then strike then contracts
+func (r OrderRepository) GetCurrencyInstruments(underlying string) ([]*utils.Instruments, error) {
+	instruments, err := r.orderedInstruments(bson.M{
+		"underlying": underlying,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := func(i int) time.Time {
+		t, _ := time.Parse("02Jan06", instruments[i].ExpDate)
+		return t
+	}
+	sort.SliceStable(instruments, func(i, j int) bool { return expiry(i).Before(expiry(j)) })
+
+	return instruments, nil
+}
+
+// GetUnderlyings returns the underlyings of the options which were ever ordered
+func (r OrderRepository) GetUnderlyings() ([]string, error) {
+	values, err := r.collection.Distinct(context.Background(), "underlying", bson.M{})
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+
+		return nil, err
+	}
+
+	underlyings := make([]string, 0, len(values))
+	for _, v := range values {
+		if underlying, ok := v.(string); ok && underlying != "" {
+			underlyings = append(underlyings, underlying)
+		}
+	}
+
+	return underlyings, nil
+}
+
+// GetRestingInstruments returns the instruments with open orders on the order book
+func (r OrderRepository) GetRestingInstruments() ([]*utils.Instruments, error) {
+	return r.orderedInstruments(bson.M{
+		"status": bson.M{"$in": []types.OrderStatus{types.OPEN, types.PARTIALLY_FILLED}},
+	})
+}
+
+// GetRestingOrders returns the open orders of all the users of the instrument
+func (r OrderRepository) GetRestingOrders(instrument utils.Instruments) ([]*_orderbookType.Order, error) {
+	return r.Find(bson.M{
+		"underlying":  instrument.Underlying,
+		"expiryDate":  instrument.ExpDate,
+		"strikePrice": instrument.Strike,
+		"contracts":   instrument.Contracts,
+		"status":      bson.M{"$in": []types.OrderStatus{types.OPEN, types.PARTIALLY_FILLED}},
+	}, nil, 0, -1)
+}
+
+func (r OrderRepository) orderedInstruments(match bson.M) ([]*utils.Instruments, error) {
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{
+				"underlying":  "$underlying",
+				"expiryDate":  "$expiryDate",
+				"strikePrice": "$strikePrice",
+				"contracts":   "$contracts",
+			},
+		}},
+		bson.M{"$sort": bson.D{
+			{"_id.strikePrice", 1},
+			{"_id.contracts", 1},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var groups []struct {
+		ID struct {
+			Underlying  string          `bson:"underlying"`
+			ExpiryDate  string          `bson:"expiryDate"`
+			StrikePrice float64         `bson:"strikePrice"`
+			Contracts   types.Contracts `bson:"contracts"`
+		} `bson:"_id"`
+	}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+
+		return nil, err
+	}
+
+	instruments := make([]*utils.Instruments, 0, len(groups))
+	for _, group := range groups {
+		if len(group.ID.Contracts) == 0 {
+			continue
+		}
+		instruments = append(instruments, &utils.Instruments{
+			Underlying: group.ID.Underlying,
+			ExpDate:    group.ID.ExpiryDate,
+			Contracts:  group.ID.Contracts,
+			Strike:     group.ID.StrikePrice,
+		})
+	}
+
+	return instruments, nil
 }
 
 func (r OrderRepository) GetOpenOrdersByInstrument(InstrumentName string, OrderType string, userId string) ([]*_deribitModel.DeribitGetOpenOrdersByInstrumentResponse, error) {
@@ -378,6 +508,24 @@ func (r OrderRepository) GetOpenOrdersByInstrument(InstrumentName string, OrderT
 		return []*_deribitModel.DeribitGetOpenOrdersByInstrumentResponse{}, err
 	}
 
+	// the untriggered orders are held by the gateway
+	triggerOrders, err := r.triggerOrders.GetUntriggered(userId, InstrumentName)
+	if err != nil {
+		return []*_deribitModel.DeribitGetOpenOrdersByInstrumentResponse{}, err
+	}
+	for _, o := range triggerOrders {
+		if trigger.MatchesType(OrderType, string(o.Type)) {
+			order := o.Order()
+			orders = append(orders, &order)
+		}
+	}
+	sort.SliceStable(orders, func(i, j int) bool { return orders[i].CreationTimestamp > orders[j].CreationTimestamp })
+
+	r.decorate(len(orders), func(i int) orderFields {
+		o := orders[i]
+		return orderFields{o.OrderId, &o.OtoOrderIds, &o.OcoRef}
+	})
+
 	return orders, nil
 }
 
@@ -550,6 +698,11 @@ func (r OrderRepository) GetOrderHistoryByInstrument(InstrumentName string, Coun
 		return []*_deribitModel.DeribitGetOrderHistoryByInstrumentResponse{}, err
 	}
 
+	r.decorate(len(orders), func(i int) orderFields {
+		o := orders[i]
+		return orderFields{o.OrderId, &o.OtoOrderIds, &o.OcoRef}
+	})
+
 	return orders, nil
 }
 
@@ -660,6 +813,11 @@ func (r OrderRepository) GetChangeOrdersByInstrument(InstrumentName string, user
 
 		return []_deribitModel.DeribitGetOpenOrdersByInstrumentResponse{}, err
 	}
+
+	r.decorate(len(orders), func(i int) orderFields {
+		o := &orders[i]
+		return orderFields{o.OrderId, &o.OtoOrderIds, &o.OcoRef}
+	})
 	return orders, nil
 }
 
@@ -731,6 +889,8 @@ func canceledReasonQuery() bson.D {
 			bson.D{
 				{"branches",
 					bson.A{
+						// the reasons sent by the gateway with its cancels are kept as they are
+						bson.D{{"case", bson.D{{"$eq", bson.A{bson.D{{"$type", "$cancelledReason"}}, "string"}}}}, {"then", "$cancelledReason"}},
 						bson.D{{"case", bson.D{{"$eq", bson.A{"$cancelledReason", 1}}}}, {"then", "user_request"}},
 						bson.D{{"case", bson.D{{"$eq", bson.A{"$cancelledReason", 2}}}}, {"then", "immediate_or_cancel"}},
 						bson.D{{"case", bson.D{{"$eq", bson.A{"$cancelledReason", 3}}}}, {"then", "good_til_day"}},
@@ -745,6 +905,28 @@ func canceledReasonQuery() bson.D {
 	}
 }
 
+// orderFields are the fields of an order response read and set by decorate
+type orderFields struct {
+	orderId     primitive.ObjectID
+	otoOrderIds *[]string
+	ocoRef      *string
+}
+
+// decorate sets the links of the n orders to the other orders of their graphs, the fields
+// of the i-th order are reached through the function
+func (r OrderRepository) decorate(n int, fields func(i int) orderFields) {
+	ids := make([]primitive.ObjectID, 0, n)
+	for i := 0; i < n; i++ {
+		ids = append(ids, fields(i).orderId)
+	}
+
+	refs := r.linkedOrders.Refs(ids)
+	for i := 0; i < n; i++ {
+		f := fields(i)
+		*f.otoOrderIds, *f.ocoRef = refs[f.orderId].OtoOrderIds, refs[f.orderId].OcoRef
+	}
+}
+
 func tradePriceAvgQuery(instrument utils.Instruments) (query bson.A) {
 
 	query = bson.A{
@@ -883,6 +1065,49 @@ func (r OrderRepository) GetOrderBook(o _orderbookType.GetOrderBook) *_orderbook
 	return orderbooks
 }
 
+// GetOpenOrders returns the open amount of the orders resting in the book of the instrument
+func (r OrderRepository) GetOpenOrders(o _orderbookType.GetOrderBook, contract types.Contracts) ([]*_orderbookType.BookOrder, error) {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"status":      bson.M{"$in": []types.OrderStatus{types.OPEN, types.PARTIALLY_FILLED}},
+				"underlying":  o.Underlying,
+				"strikePrice": o.StrikePrice,
+				"expiryDate":  o.ExpiryDate,
+				"contracts":   contract,
+			},
+		},
+		{
+			"$project": bson.M{
+				"userId":    bson.M{"$toString": "$userId"},
+				"userRole":  1,
+				"side":      1,
+				"price":     1,
+				"updatedAt": 1,
+				"amount": bson.M{"$subtract": bson.A{
+					"$amount",
+					bson.M{"$toDouble": "$filledAmount"},
+				}},
+			},
+		},
+	}
+
+	opt := options.AggregateOptions{
+		MaxTime: &defaultTimeout,
+	}
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline, &opt)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []*_orderbookType.BookOrder{}
+	if err := cursor.All(context.Background(), &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
 func (r OrderRepository) GetOrderBookAgg2(o _orderbookType.GetOrderBook) _orderbookType.Orderbook {
 	queryBuilderCount := func(side types.Side) interface{} {
 		return []bson.M{
@@ -1283,6 +1508,11 @@ func (r OrderRepository) GetOrderState(userId string, orderId string) ([]_deribi
 		return []_deribitModel.DeribitGetOrderStateResponse{}, err
 	}
 
+	r.decorate(len(orders), func(i int) orderFields {
+		o := &orders[i]
+		return orderFields{o.OrderId, &o.OtoOrderIds, &o.OcoRef}
+	})
+
 	return orders, nil
 }
 
@@ -1384,5 +1614,10 @@ func (r OrderRepository) GetOrderStateByLabel(ctx context.Context, req _deribitM
 		return
 	}
 
+	r.decorate(len(orders), func(i int) orderFields {
+		o := orders[i]
+		return orderFields{o.OrderId, &o.OtoOrderIds, &o.OcoRef}
+	})
+
 	return
 }
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	_tradeType "gateway/internal/repositories/types"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrderExpirationRepository holds the expiries of the good_til_day and good_til_date
+// orders, they outlive the gateway so the expirations are recovered after a restart
+type OrderExpirationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrderExpirationRepository(db Database) *OrderExpirationRepository {
+	collection := db.InitCollection("order_expirations")
+	ensureIndexes(collection,
+		mongo.IndexModel{Keys: bson.D{{"requestId", 1}}},
+		mongo.IndexModel{Keys: bson.D{{"status", 1}, {"expireAt", 1}}},
+	)
+
+	return &OrderExpirationRepository{collection}
+}
+
+func (r OrderExpirationRepository) Find(filter interface{}, sort interface{}, offset, limit int64) ([]*_tradeType.OrderExpiration, error) {
+	options := options.FindOptions{
+		MaxTime: &defaultTimeout,
+	}
+
+	if offset >= 0 {
+		options.SetSkip(offset)
+	}
+
+	if limit >= 0 {
+		options.SetLimit(limit)
+	}
+
+	if sort != nil {
+		options.SetSort(sort)
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	cursor, err := r.collection.Find(context.Background(), filter, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	expirations := []*_tradeType.OrderExpiration{}
+	if err = cursor.All(context.Background(), &expirations); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return expirations, nil
+}
+
+func (r OrderExpirationRepository) Insert(expiration *_tradeType.OrderExpiration) error {
+	_, err := r.collection.InsertOne(context.Background(), expiration)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+	}
+
+	return err
+}
+
+// dueExpirations is the filter of the pending expirations reached at now which no node
+// holds
+func dueExpirations(now time.Time) bson.M {
+	return bson.M{
+		"status":   _tradeType.EXPIRATION_PENDING,
+		"expireAt": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"lockedUntil": bson.M{"$exists": false}},
+			bson.M{"lockedUntil": bson.M{"$lte": now}},
+		},
+	}
+}
+
+// FindDue returns the pending expirations reached at now, the earliest first
+func (r OrderExpirationRepository) FindDue(now time.Time, limit int64) ([]*_tradeType.OrderExpiration, error) {
+	return r.Find(dueExpirations(now), bson.M{"expireAt": 1}, 0, limit)
+}
+
+// Lock holds the due expiration for the node until the time, it returns nil when
+// another node holds it
+func (r OrderExpirationRepository) Lock(id primitive.ObjectID, now, until time.Time) (*_tradeType.OrderExpiration, error) {
+	filter := dueExpirations(now)
+	filter["_id"] = id
+
+	var expiration _tradeType.OrderExpiration
+	err := r.collection.FindOneAndUpdate(
+		context.Background(),
+		filter,
+		bson.M{"$set": bson.M{"lockedUntil": until, "updatedAt": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&expiration)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return &expiration, nil
+}
+
+// UpdateStatus moves the expiration from the status to the next one
+func (r OrderExpirationRepository) UpdateStatus(id primitive.ObjectID, from, to string) error {
+	_, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": bson.M{"status": to, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+	}
+
+	return err
+}
+
+// SetOrderID sets the order id of the pending expiration of the request, the one of a
+// trigger order is replaced by the order released to the engine with the same request id
+func (r OrderExpirationRepository) SetOrderID(requestId string, orderId primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{
+			"requestId": requestId,
+			"orderId":   bson.M{"$ne": orderId},
+			"status":    _tradeType.EXPIRATION_PENDING,
+		},
+		bson.M{"$set": bson.M{"orderId": orderId, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+	}
+
+	return err
+}
+
+// CloseRequest closes the pending expiration of the request, e.g. an order the engine
+// rejected
+func (r OrderExpirationRepository) CloseRequest(requestId string) error {
+	_, err := r.collection.UpdateOne(
+		context.Background(),
+		bson.M{"requestId": requestId, "status": _tradeType.EXPIRATION_PENDING},
+		bson.M{"$set": bson.M{"status": _tradeType.EXPIRATION_CLOSED, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+	}
+
+	return err
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	_deribitModel "gateway/internal/deribit/model"
+	"gateway/pkg/pricing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentsByExpiry(t *testing.T) {
+	at, err := pricing.Expiry("31JAN23")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	instruments := func() []*_deribitModel.DeribitGetInstrumentsResponse {
+		return []*_deribitModel.DeribitGetInstrumentsResponse{
+			{InstrumentName: "BTC-31JAN23-20000-C"},
+			{InstrumentName: "BTC-29DEC23-20000-P"},
+			{InstrumentName: "invalid"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		expired bool
+		want    []string
+	}{
+		{"active before the expiry", at.Add(-time.Second), false, []string{"BTC-31JAN23-20000-C", "BTC-29DEC23-20000-P"}},
+		{"active at the expiry", at, false, []string{"BTC-29DEC23-20000-P"}},
+		{"expired before the expiry", at.Add(-time.Second), true, []string{}},
+		{"expired at the expiry", at, true, []string{"BTC-31JAN23-20000-C"}},
+	}
+
+	for _, tt := range tests {
+		names := []string{}
+		for _, o := range instrumentsByExpiry(instruments(), tt.expired, tt.now) {
+			names = append(names, o.InstrumentName)
+			assert.NotZero(t, o.ExpirationTimestamp, tt.name)
+			assert.Equal(t, !tt.expired, o.IsActive, tt.name)
+		}
+		assert.Equal(t, tt.want, names, tt.name)
+	}
+
+	// the expiry time of the expiry date, not its start
+	o := instrumentsByExpiry(instruments(), true, at)[0]
+	assert.Equal(t, at.UnixMilli(), o.ExpirationTimestamp)
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gateway/internal/outbox/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxRepository(db Database) *OutboxRepository {
+	collection := db.InitCollection("outbox")
+	// the entries written before the request ids are left out of the unique index
+	ensureIndexes(collection,
+		mongo.IndexModel{
+			Keys:    bson.D{{"requestId", 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"requestId": bson.M{"$type": "string"}}),
+		},
+		mongo.IndexModel{Keys: bson.D{{"status", 1}, {"createdAt", 1}}},
+	)
+
+	return &OutboxRepository{collection}
+}
+
+func (r OutboxRepository) Insert(entry *types.Entry) error {
+	now := time.Now()
+
+	entry.ID = primitive.NewObjectID()
+	entry.Status = types.PENDING
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+
+	_, err := r.collection.InsertOne(context.Background(), entry)
+	return err
+}
+
+func (r OutboxRepository) MarkPublished(id primitive.ObjectID) error {
+	now := time.Now()
+
+	// the engine may answer before the entry is marked as published
+	filter := bson.M{"_id": id, "status": types.PENDING}
+	update := bson.M{"$set": bson.M{
+		"status":      types.PUBLISHED,
+		"publishedAt": now,
+		"updatedAt":   now,
+	}}
+
+	_, err := r.collection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+func (r OutboxRepository) MarkFailed(id primitive.ObjectID, reason error) error {
+	filter := bson.M{"_id": id, "status": types.PENDING}
+	update := bson.M{"$set": bson.M{
+		"lastError": reason.Error(),
+		"updatedAt": time.Now(),
+	}}
+
+	_, err := r.collection.UpdateOne(context.Background(), filter, update)
+	return err
+}
+
+// Acknowledge marks the entry of the request as answered, it returns false when the entry
+// was already answered
+func (r OutboxRepository) Acknowledge(requestId string) (bool, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"requestId": requestId,
+		"status":    bson.M{"$ne": types.ACKNOWLEDGED},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":         types.ACKNOWLEDGED,
+		"acknowledgedAt": now,
+		"updatedAt":      now,
+	}}
+
+	res, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount > 0 {
+		return true, nil
+	}
+
+	// a request unknown to the outbox is answered for the first time
+	answered, err := r.collection.CountDocuments(context.Background(), bson.M{"requestId": requestId, "status": types.ACKNOWLEDGED})
+	if err != nil {
+		return false, err
+	}
+
+	return answered == 0, nil
+}
+
+// MarkDead stops sending the entries pending since before the given time, it returns the
+// number of entries marked
+func (r OutboxRepository) MarkDead(before time.Time) (int64, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"status":    types.PENDING,
+		"createdAt": bson.M{"$lt": before},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":    types.DEAD,
+		"deadAt":    now,
+		"updatedAt": now,
+	}}
+
+	res, err := r.collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// ClaimPending takes the oldest pending entry which was not touched since the given time,
+// the claim bumps its update time so no other relay picks it at the same time
+func (r OutboxRepository) ClaimPending(before time.Time) (*types.Entry, error) {
+	filter := bson.M{
+		"status":    types.PENDING,
+		"updatedAt": bson.M{"$lt": before},
+	}
+	update := bson.M{
+		"$set": bson.M{"updatedAt": time.Now()},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"createdAt": 1}).
+		SetReturnDocument(options.After)
+
+	var entry types.Entry
+	err := r.collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// FindStuck returns the entries which were not acknowledged since the given time
+func (r OutboxRepository) FindStuck(status []types.Status, before time.Time, limit int64) ([]*types.Entry, error) {
+	filter := bson.M{
+		"status":    bson.M{"$in": status},
+		"createdAt": bson.M{"$lt": before},
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"createdAt": 1}).
+		SetLimit(limit).
+		SetMaxTime(defaultTimeout)
+
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	entries := []*types.Entry{}
+	if err := cursor.All(context.Background(), &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -2,26 +2,43 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	_deribitModel "gateway/internal/deribit/model"
 	_engineType "gateway/internal/engine/types"
 	_orderbookType "gateway/internal/orderbook/types"
+	"gateway/pkg/candle"
+	"gateway/pkg/constant"
+	"gateway/pkg/pricing"
 
 	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// the historical volatility is the hourly realised volatility of the index over a
+// rolling day, for the last 15 days
+const (
+	historicalVolatilityWindow = 24
+	historicalVolatilityDays   = 15
+)
+
+// the volatility index data returns at most this many candles per page
+const volatilityIndexPageSize = 1000
+
 type RawPriceRepository struct {
-	collection *mongo.Collection
+	collection      *mongo.Collection
+	volatilityIndex *VolatilityIndexRepository
 }
 
 func NewRawPriceRepository(db Database) *RawPriceRepository {
 	collection := db.InitCollection("raw_prices")
-	return &RawPriceRepository{collection}
+	return &RawPriceRepository{collection, NewVolatilityIndexRepository(db)}
 }
 
 func (r RawPriceRepository) Find(filter interface{}, sort interface{}, offset, limit int64) ([]*_engineType.RawPrice, error) {
@@ -109,3 +126,103 @@ func (r RawPriceRepository) GetIndexPrice(indexName string) []*_engineType.RawPr
 
 	return trades
 }
+
+// GetHourlyIndexPrices returns the last index price of every hour of [start, end) with
+// one, the time of a price is the start of its hour
+func (r RawPriceRepository) GetHourlyIndexPrices(underlying string, start, end time.Time) ([]pricing.Point, error) {
+	options := options.AggregateOptions{
+		MaxTime: &defaultTimeout,
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", bson.M{
+			"metadata.pair": fmt.Sprintf("%s_usd", strings.ToLower(underlying)),
+			"metadata.type": "index",
+			"ts":            bson.M{"$gte": start, "$lt": end},
+		}}},
+		bson.D{{"$sort", bson.D{{"ts", 1}}}},
+		bson.D{{"$group", bson.M{
+			"_id":   bson.M{"$dateTrunc": bson.M{"date": "$ts", "unit": "hour"}},
+			"price": bson.M{"$last": "$price"},
+		}}},
+		bson.D{{"$sort", bson.D{{"_id", 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var groups []struct {
+		Hour  time.Time `bson:"_id"`
+		Price float64   `bson:"price"`
+	}
+	if err = cursor.All(context.Background(), &groups); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	prices := make([]pricing.Point, 0, len(groups))
+	for _, g := range groups {
+		prices = append(prices, pricing.Point{Time: g.Hour, Value: g.Price})
+	}
+
+	return prices, nil
+}
+
+// GetHistoricalVolatility returns the [timestamp, volatility in percent] of every hour
+// of the last days
+func (r RawPriceRepository) GetHistoricalVolatility(currency string) ([][2]float64, error) {
+	end := time.Now()
+	start := end.Truncate(time.Hour).Add(-time.Duration(historicalVolatilityDays*24+historicalVolatilityWindow) * time.Hour)
+
+	prices, err := r.GetHourlyIndexPrices(currency, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := [][2]float64{}
+	for _, v := range pricing.HistoricalVol(prices, time.Hour, historicalVolatilityWindow) {
+		result = append(result, [2]float64{float64(v.Time.UnixMilli()), v.Value * 100})
+	}
+
+	return result, nil
+}
+
+// GetVolatilityIndexData returns the candles of the volatility index of the currency,
+// the most recent page of them when there are more than a page
+func (r RawPriceRepository) GetVolatilityIndexData(req _deribitModel.GetVolatilityIndexDataRequest) (res _deribitModel.GetVolatilityIndexDataResponse, reason *validation_reason.ValidationReason, err error) {
+	resolution, ok := candle.IndexResolutions[req.Resolution]
+	if !ok {
+		vr := validation_reason.INVALID_PARAMS
+		return res, &vr, errors.New(constant.INVALID_RESOLUTION)
+	}
+	if req.StartTimestamp >= req.EndTimestamp {
+		vr := validation_reason.INVALID_PARAMS
+		return res, &vr, errors.New("start_timestamp must be before end_timestamp")
+	}
+
+	start := candle.Start(time.UnixMilli(req.StartTimestamp), resolution)
+	end := time.UnixMilli(req.EndTimestamp)
+
+	// one more candle than the page tells whether there is a next one
+	candles, err := r.volatilityIndex.Candles(req.Currency, req.Resolution, start, end, volatilityIndexPageSize+1)
+	if err != nil {
+		return res, nil, err
+	}
+
+	if len(candles) > volatilityIndexPageSize {
+		candles = candles[len(candles)-volatilityIndexPageSize:]
+		continuation := candles[0].Tick
+		res.Continuation = &continuation
+	}
+
+	res.Data = make([][5]float64, 0, len(candles))
+	for _, c := range candles {
+		res.Data = append(res.Data, [5]float64{float64(c.Tick), c.Open, c.High, c.Low, c.Close})
+	}
+
+	return res, nil, nil
+}
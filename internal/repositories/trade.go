@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,12 +13,12 @@ import (
 	_engineType "gateway/internal/engine/types"
 	_orderbookType "gateway/internal/orderbook/types"
 	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/candle"
 	"gateway/pkg/memdb"
+	"gateway/pkg/pricing"
 	"gateway/pkg/utils"
 
 	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
-	Greeks "github.com/Undercurrent-Technologies/kprime-utilities/helper/greeks"
-	IV "github.com/Undercurrent-Technologies/kprime-utilities/helper/implied_volatility"
 	"github.com/Undercurrent-Technologies/kprime-utilities/models/trade"
 	"github.com/Undercurrent-Technologies/kprime-utilities/types"
 	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
@@ -33,11 +32,12 @@ import (
 
 type TradeRepository struct {
 	collection *mongo.Collection
+	candles    *CandleRepository
 }
 
 func NewTradeRepository(db Database) *TradeRepository {
 	collection := db.InitCollection("trades")
-	return &TradeRepository{collection}
+	return &TradeRepository{collection, NewCandleRepository(db)}
 }
 
 func (r TradeRepository) FilterTradesData(data _deribitModel.DeribitGetLastTradesByInstrumentRequest) []*_engineType.Trade {
@@ -777,15 +777,7 @@ func (r TradeRepository) FindTradesEachUser(
 			trade.UnderlyingIndex = "index_price"
 
 			if trade.MarkPrice != 0 {
-				var contracts string
-				switch trade.Contracts {
-				case "CALL":
-					contracts = "C"
-				case "PUT":
-					contracts = "P"
-				}
-				markIv := r.GetMarkIv(float64(trade.MarkPrice), contracts, trade.ExpirationDate, trade.StrikePrice, trade.IndexPrice)
-				trade.MarkIV = markIv
+				trade.MarkIV = pricing.ImpliedVol(trade.InstrumentName, trade.MarkPrice, trade.IndexPrice, time.UnixMilli(trade.Timestamp))
 			}
 		}
 	}
@@ -966,15 +958,7 @@ func (r TradeRepository) FindTradesByInstrument(
 			trade.UnderlyingIndex = "index_price"
 
 			if trade.MarkPrice != 0 {
-				var contracts string
-				switch trade.Contracts {
-				case "CALL":
-					contracts = "C"
-				case "PUT":
-					contracts = "P"
-				}
-				markIv := r.GetMarkIv(float64(trade.MarkPrice), contracts, trade.ExpirationDate, trade.StrikePrice, trade.IndexPrice)
-				trade.MarkIV = markIv
+				trade.MarkIV = pricing.ImpliedVol(trade.InstrumentName, trade.MarkPrice, trade.IndexPrice, time.UnixMilli(trade.Timestamp))
 			}
 		}
 	}
@@ -1127,91 +1111,155 @@ func (r TradeRepository) Get24HoursTrades(o _orderbookType.GetOrderBook) []*_eng
 	return trades
 }
 
-func (r TradeRepository) GetMarkIv(markPrice float64, contracts string, expiredDate string, strikePrice float64, indexPrice float64) float64 {
-	currentDate := time.Now().Format("2006-01-02 15:04:05")
-	layoutExpired := "02Jan06"
-	layoutCurrent := "2006-01-02 15:04:05"
-	expired, _ := time.Parse(layoutExpired, expiredDate)
-	current, _ := time.Parse(layoutCurrent, currentDate)
-	calculate := float64(expired.Day()) - float64(current.Day())
-	dateValue := float64(calculate / 365)
-
-	optionPrice := ""
-	if string(contracts) == "C" {
-		optionPrice = "call"
-	} else {
-		optionPrice = "put"
+// GetOpenInterest returns the open interest of the options of the expiry by instrument
+// name, the sum of the net long positions of the users
+func (r TradeRepository) GetOpenInterest(underlying, expiryDate string) (map[string]float64, error) {
+	options := options.AggregateOptions{
+		MaxTime: &defaultTimeout,
 	}
 
-	markIv := r.GetImpliedVolatility(float64(markPrice), optionPrice, float64(indexPrice), float64(strikePrice), float64(dateValue))
-	return markIv
-}
+	// the side of a trade is the side of the taker, the maker is on the other side
+	amount := bson.M{"$toDouble": "$amount"}
+	taker := bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$side", types.BUY}}, amount, bson.M{"$multiply": bson.A{amount, -1}}}}
 
-func (r TradeRepository) GetImpliedVolatility(marketPrice float64, optionPrice string, underlying float64, strikePrice float64, timeeXP float64) float64 {
-	expectedCost := marketPrice // The market price of the option
-	s := underlying             // Current price of the underlying
-	k := strikePrice            // Strike price
-	t := timeeXP                // Time to expiration in years
-	ar := 0.05                  // Annual risk-free interest rate as a decimal
-	callPut := optionPrice      // Type of option priced - "call" or "put"
-	estimate := 0.1             // An initial estimate of implied volatility
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", bson.M{
+			"underlying": underlying,
+			"expiryDate": expiryDate,
+		}}},
+		bson.D{{"$project", bson.M{
+			"strikePrice": 1,
+			"contracts":   1,
+			"legs": bson.A{
+				bson.M{"userId": "$taker.userId", "amount": taker},
+				bson.M{"userId": "$maker.userId", "amount": bson.M{"$multiply": bson.A{taker, -1}}},
+			},
+		}}},
+		bson.D{{"$unwind", "$legs"}},
+		bson.D{{"$group", bson.M{
+			"_id": bson.M{
+				"strikePrice": "$strikePrice",
+				"contracts":   "$contracts",
+				"userId":      "$legs.userId",
+			},
+			"position": bson.M{"$sum": "$legs.amount"},
+		}}},
+		bson.D{{"$group", bson.M{
+			"_id": bson.M{
+				"strikePrice": "$_id.strikePrice",
+				"contracts":   "$_id.contracts",
+			},
+			"openInterest": bson.M{"$sum": bson.M{"$max": bson.A{"$position", 0}}},
+		}}},
+	}
 
-	impliedData := IV.ImpliedVolatility(expectedCost, s, k, t, ar, callPut, estimate)
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
 
-	return impliedData
+	var groups []struct {
+		ID struct {
+			StrikePrice float64         `bson:"strikePrice"`
+			Contracts   types.Contracts `bson:"contracts"`
+		} `bson:"_id"`
+		OpenInterest float64 `bson:"openInterest"`
+	}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	result := make(map[string]float64, len(groups))
+	for _, group := range groups {
+		if len(group.ID.Contracts) == 0 {
+			continue
+		}
+		instrument := utils.Instruments{
+			Underlying: underlying,
+			ExpDate:    expiryDate,
+			Contracts:  group.ID.Contracts,
+			Strike:     group.ID.StrikePrice,
+		}
+		result[instrument.Name()] = group.OpenInterest
+	}
+
+	return result, nil
 }
 
-func (r TradeRepository) GetGreeks(types string, impliedVolatily float64, optionPrice string, underlying float64, strikePrice float64, timeeXP float64) float64 {
-	var delta float64
-
-	if types == "delta" {
-		s := underlying        // underlying price
-		k := strikePrice       // strike price
-		t := timeeXP           // time to maturity
-		v := impliedVolatily   // volatility
-		ar := 0.0015           // risk-free interest rate
-		callPut := optionPrice // call or put
-
-		delta = Greeks.GetDelta(s, k, t, v, ar, callPut)
-	} else if types == "vega" {
-		s := underlying      // underlying price
-		k := strikePrice     // strike price
-		t := timeeXP         // time to maturity
-		v := impliedVolatily // volatility
-		r := 0.0015          // risk-free interest rate
-
-		delta = Greeks.GetVega(s, k, t, v, r)
-	} else if types == "gamma" {
-		s := underlying      // underlying price
-		k := strikePrice     // strike price
-		t := timeeXP         // time to maturity
-		v := impliedVolatily // volatility
-		r := 0.0015          // risk-free interest rate
-
-		delta = Greeks.GetGamma(s, k, t, v, r)
-	} else if types == "tetha" {
-		s := underlying        // underlying price
-		k := strikePrice       // strike price
-		t := timeeXP           // time to maturity
-		v := impliedVolatily   // volatility
-		r := 0.0015            // risk-free interest rate
-		callPut := optionPrice // Type of option priced - "call" or "put"
-		scale := 365.0         // You can set the scale to a value like 252 (trading days per year), by default is 365
-
-		delta = Greeks.GetTheta(s, k, t, v, r, callPut, scale)
-	} else {
-		s := underlying        // underlying price
-		k := strikePrice       // strike price
-		t := timeeXP           // time to maturity
-		v := impliedVolatily   // volatility
-		r := 0.0015            // risk-free interest rate
-		callPut := optionPrice // Type of option priced - "call" or "put"
-		scale := 100.0         // You can set the scale to a value like 10000 (rho per 0.01%, or 1BP, change in the risk-free interest rate), by default is 100
+// InstrumentTrades is the last price of an instrument and its volume since a time
+type InstrumentTrades struct {
+	LastPrice float64
+	Volume    float64
+}
 
-		delta = Greeks.GetRho(s, k, t, v, r, callPut, scale)
+// GetExpiryTrades returns the last price and the volume since the time of the options of
+// the expiry by instrument name
+func (r TradeRepository) GetExpiryTrades(underlying, expiryDate string, since time.Time) (map[string]InstrumentTrades, error) {
+	options := options.AggregateOptions{
+		MaxTime: &defaultTimeout,
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", bson.M{
+			"underlying": underlying,
+			"expiryDate": expiryDate,
+		}}},
+		bson.D{{"$sort", bson.M{"createdAt": 1}}},
+		bson.D{{"$group", bson.M{
+			"_id": bson.M{
+				"strikePrice": "$strikePrice",
+				"contracts":   "$contracts",
+			},
+			"lastPrice": bson.M{"$last": "$price"},
+			"volume": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$createdAt", since}},
+				bson.M{"$toDouble": "$amount"},
+				0,
+			}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var groups []struct {
+		ID struct {
+			StrikePrice float64         `bson:"strikePrice"`
+			Contracts   types.Contracts `bson:"contracts"`
+		} `bson:"_id"`
+		LastPrice float64 `bson:"lastPrice"`
+		Volume    float64 `bson:"volume"`
+	}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
 	}
 
-	return delta
+	result := make(map[string]InstrumentTrades, len(groups))
+	for _, group := range groups {
+		if len(group.ID.Contracts) == 0 {
+			continue
+		}
+		instrument := utils.Instruments{
+			Underlying: underlying,
+			ExpDate:    expiryDate,
+			Contracts:  group.ID.Contracts,
+			Strike:     group.ID.StrikePrice,
+		}
+		result[instrument.Name()] = InstrumentTrades{
+			LastPrice: group.LastPrice,
+			Volume:    group.Volume,
+		}
+	}
+
+	return result, nil
 }
 
 func (r TradeRepository) FilterUserTradesByOrder(userId, orderId, sort string) (result _deribitModel.DeribitGetUserTradesByOrderResponse, err error) {
@@ -1448,10 +1496,6 @@ func (r TradeRepository) GetTradingViewChartData(req _deribitModel.GetTradingvie
 		return
 	}
 
-	options := options.AggregateOptions{
-		MaxTime: &defaultTimeout,
-	}
-
 	var instrument *utils.Instruments
 	instrument, err = utils.ParseInstruments(req.InstrumentName, false)
 	if err != nil {
@@ -1469,69 +1513,7 @@ func (r TradeRepository) GetTradingViewChartData(req _deribitModel.GetTradingvie
 		}
 	}
 
-	match := bson.D{
-		{"underlying", instrument.Underlying},
-		{"strikePrice", instrument.Strike},
-		{"expiryDate", instrument.ExpDate},
-		{"contracts", instrument.Contracts},
-		{"createdAt",
-			bson.D{
-				{"$gt", time.UnixMilli(req.StartTimestamp)},
-				{"$lt", time.UnixMilli(req.EndTimestamp)},
-			},
-		},
-		{"maker.userId", bson.D{{"$nin", excludeUserId}}},
-		{"taker.userId", bson.D{{"$nin", excludeUserId}}},
-	}
-
-	matchStage := bson.D{{"$match", match}}
-
-	sortStage := bson.D{
-		{"$sort", bson.D{
-			{"createdAt", -1},
-		}},
-	}
-
-	pipeline := mongo.Pipeline{matchStage, sortStage}
-
-	var cursor *mongo.Cursor
-	cursor, err = r.collection.Aggregate(context.Background(), pipeline, &options)
-	if err != nil {
-		logs.Log.Error().Err(err).Msg("")
-		return
-	}
-	defer cursor.Close(context.Background())
-
-	var trades []*trade.Trade
-	if err = cursor.All(context.TODO(), &trades); err != nil {
-		logs.Log.Error().Err(err).Msg("")
-		return
-	}
-
-	type resolution struct {
-		Start  time.Time
-		End    time.Time
-		Trades []trade.Trade
-	}
-
-	// Resolution
-	// (1, 3, 5, 10, 15, 30, 60, 120, 180, 360, 720, 1D)
-	resolutionMap := map[string]uint64{
-		"1":   1,
-		"3":   3,
-		"5":   5,
-		"10":  10,
-		"15":  15,
-		"30":  30,
-		"60":  60,
-		"120": 120,
-		"180": 180,
-		"360": 360,
-		"720": 720,
-		"1D":  1440,
-	}
-
-	resolutionRange, ok := resolutionMap[req.Resolution]
+	resolution, ok := candle.Resolutions[req.Resolution]
 	if !ok {
 		vr := validation_reason.INVALID_PARAMS
 		reason = &vr
@@ -1549,26 +1531,20 @@ func (r TradeRepository) GetTradingViewChartData(req _deribitModel.GetTradingvie
 		return
 	}
 
-	resolutions := []resolution{}
-	endTs := end
-	for {
-		// If endTs Mapping is after request end timestamp
-		// stop mapped the resolution
-		if endTs.After(end) || (endTs.Equal(end) && len(resolutions) == 1) {
-			break
-		}
-
-		endTs = start.Add(time.Duration(resolutionRange) * time.Minute)
-
-		// Add resolution
-		resolutions = append(resolutions, resolution{
-			start,
-			endTs,
-			[]trade.Trade{},
-		})
+	// the candles are aligned on the resolution, the same ones as chart.trades channel
+	start = candle.Start(start, resolution)
 
-		// Set endTs as start for the next resolution mapping
-		start = endTs
+	var candles []candle.Candle
+	if len(excludeUserId) == 0 {
+		// the users who see every trade read the candle store
+		candles, err = r.storedCandles(instrument, req.Resolution, start, end)
+	} else {
+		var trades []candle.Trade
+		trades, err = r.GetChartTrades(instrument, start, end, excludeUserId)
+		candles = candle.Build(trades, start, end, resolution)
+	}
+	if err != nil {
+		return
 	}
 
 	res = _deribitModel.GetTradingviewChartDataResponse{
@@ -1582,103 +1558,309 @@ func (r TradeRepository) GetTradingViewChartData(req _deribitModel.GetTradingvie
 		Status: "no_data",
 	}
 
-	if len(trades) == 0 {
+	traded := false
+	for _, c := range candles {
+		traded = traded || c.Volume > 0
+	}
+	if !traded {
 		return
 	}
 
 	res.Status = "ok"
 
-	for key, reso := range resolutions {
-		for _, trade := range trades {
-			created := trade.CreatedAt
-			if created.After(reso.Start) && created.Before(reso.End) {
-				t := resolutions[key].Trades
-				t = append(t, *trade)
-
-				sort.Slice(t, func(i, j int) bool {
-					return t[i].CreatedAt.Before(t[j].CreatedAt)
-				})
-
-				resolutions[key] = resolution{
-					Start:  reso.Start,
-					End:    reso.End,
-					Trades: t,
-				}
-			}
+	// the ticks of the endpoint are the end of the candles, the chart.trades channel
+	// sends their start
+	for _, c := range candles {
+		res.Ticks = append(res.Ticks, c.End(resolution).UnixMilli())
+		res.Open = append(res.Open, c.Open)
+		res.High = append(res.High, c.High)
+		res.Low = append(res.Low, c.Low)
+		res.Close = append(res.Close, c.Close)
+		res.Cost = append(res.Cost, c.Cost)
+		res.Volume = append(res.Volume, c.Volume)
+	}
+
+	return
+}
+
+// storedCandles merges the candles of the store, the candles which are not written yet
+// are built from the trades
+func (r TradeRepository) storedCandles(instrument *utils.Instruments, resolution string, start, end time.Time) ([]candle.Candle, error) {
+	name := instrument.Underlying + "-" + instrument.ExpDate + "-" + fmt.Sprintf("%.0f", instrument.Strike) + "-" + string(instrument.Contracts[0])
+
+	source := candle.Source(resolution)
+	sourceRange := candle.Resolutions[source]
+
+	// a candle is written a flush delay after its end, at the next tick of the writer
+	live := candle.Start(time.Now().Add(-2*candle.FlushDelay), sourceRange)
+	if live.Before(start) {
+		live = start
+	}
+
+	candles := []candle.Candle{}
+	if start.Before(live) {
+		stored, err := r.candles.Find(name, source, start, minTime(live, end))
+		if err != nil {
+			return nil, err
 		}
+		candles = append(candles, stored...)
 	}
 
-	for _, reso := range resolutions {
-		res.Ticks = append(res.Ticks, reso.End.UnixMilli())
+	if live.Before(end) {
+		trades, err := r.GetChartTrades(instrument, live, end, nil)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, candle.Build(trades, live, end, sourceRange)...)
+	}
 
-		if len(reso.Trades) == 0 {
-			// Get prev trades open
-			open := 0.0
-			if len(res.Open) > 0 {
-				open = res.Open[len(res.Open)-1]
-			}
-			res.Open = append(res.Open, open)
+	return candle.Merge(candles, start, end, candle.Resolutions[resolution]), nil
+}
 
-			// Get prev trades close
-			close := 0.0
-			if len(res.Close) > 0 {
-				close = res.Close[len(res.Close)-1]
-			}
-			res.Close = append(res.Close, close)
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
 
-			// Get prev trades low
-			low := 0.0
-			if len(res.Low) > 0 {
-				low = res.Low[len(res.Low)-1]
-			}
-			res.Low = append(res.Low, low)
+	return b
+}
 
-			// Get prev trades high
-			high := 0.0
-			if len(res.High) > 0 {
-				high = res.High[len(res.High)-1]
-			}
-			res.High = append(res.High, high)
+// TradedInstrument is an instrument with the time of its first and last trades
+type TradedInstrument struct {
+	Name  string
+	First time.Time
+	Last  time.Time
+}
 
-			res.Cost = append(res.Cost, 0.0)
-			res.Volume = append(res.Volume, 0.0)
+// TradedInstruments returns the instruments with trades
+func (r TradeRepository) TradedInstruments() ([]TradedInstrument, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{"$group", bson.M{
+			"_id": bson.M{
+				"underlying":  "$underlying",
+				"expiryDate":  "$expiryDate",
+				"strikePrice": "$strikePrice",
+				"contracts":   "$contracts",
+			},
+			"first": bson.M{"$min": "$createdAt"},
+			"last":  bson.M{"$max": "$createdAt"},
+		}}},
+	}
 
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var groups []struct {
+		ID struct {
+			Underlying  string          `bson:"underlying"`
+			ExpiryDate  string          `bson:"expiryDate"`
+			StrikePrice float64         `bson:"strikePrice"`
+			Contracts   types.Contracts `bson:"contracts"`
+		} `bson:"_id"`
+		First time.Time `bson:"first"`
+		Last  time.Time `bson:"last"`
+	}
+	if err = cursor.All(context.Background(), &groups); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	instruments := make([]TradedInstrument, 0, len(groups))
+	for _, g := range groups {
+		if g.ID.Contracts == "" {
 			continue
 		}
 
-		open := reso.Trades[0]
-		res.Open = append(res.Open, open.Price)
+		instruments = append(instruments, TradedInstrument{
+			Name:  g.ID.Underlying + "-" + g.ID.ExpiryDate + "-" + fmt.Sprintf("%.0f", g.ID.StrikePrice) + "-" + string(g.ID.Contracts[0]),
+			First: g.First,
+			Last:  g.Last,
+		})
+	}
+
+	return instruments, nil
+}
+
+// GetChartTrades returns the trades of the instrument in [start, end) without the trades
+// of the excluded users
+func (r TradeRepository) GetChartTrades(instrument *utils.Instruments, start, end time.Time, excludeUserId []string) ([]candle.Trade, error) {
+	options := options.AggregateOptions{
+		MaxTime: &defaultTimeout,
+	}
+
+	match := bson.D{
+		{"underlying", instrument.Underlying},
+		{"strikePrice", instrument.Strike},
+		{"expiryDate", instrument.ExpDate},
+		{"contracts", instrument.Contracts},
+		{"createdAt",
+			bson.D{
+				{"$gte", start},
+				{"$lt", end},
+			},
+		},
+	}
+	if len(excludeUserId) > 0 {
+		match = append(match,
+			bson.E{"maker.userId", bson.D{{"$nin", excludeUserId}}},
+			bson.E{"taker.userId", bson.D{{"$nin", excludeUserId}}},
+		)
+	}
+
+	matchStage := bson.D{{"$match", match}}
+
+	sortStage := bson.D{
+		{"$sort", bson.D{
+			{"createdAt", 1},
+		}},
+	}
+
+	pipeline := mongo.Pipeline{matchStage, sortStage}
 
-		close := reso.Trades[len(reso.Trades)-1]
-		res.Close = append(res.Close, close.Price)
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
 
-		sortedByPrices := reso.Trades
+	var trades []*trade.Trade
+	if err = cursor.All(context.TODO(), &trades); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	result := make([]candle.Trade, 0, len(trades))
+	for _, trade := range trades {
+		amount, err := decimal.NewFromString(trade.Amount)
+		if err != nil {
+			logs.Log.Error().Err(err).Msg("cannot parsed amount")
+			continue
+		}
 
-		sort.Slice(sortedByPrices, func(i, j int) bool {
-			return sortedByPrices[i].Price < sortedByPrices[j].Price
+		result = append(result, candle.Trade{
+			Time:   trade.CreatedAt,
+			Price:  trade.Price,
+			Amount: amount.InexactFloat64(),
 		})
+	}
 
-		low := sortedByPrices[0]
-		res.Low = append(res.Low, low.Price)
+	return result, nil
+}
 
-		high := sortedByPrices[len(sortedByPrices)-1]
-		res.High = append(res.High, high.Price)
+// AggregateCandles returns the candles of the trades of [start, end) for every traded
+// instrument, or for the given instruments only
+func (r TradeRepository) AggregateCandles(resolution string, start, end time.Time, instruments []string) ([]*_tradeType.Candle, error) {
+	options := options.AggregateOptions{
+		MaxTime: &defaultTimeout,
+	}
 
-		var cost, volume float64
-		for _, trade := range reso.Trades {
-			amount, err := decimal.NewFromString(trade.Amount)
+	match := bson.D{
+		{"createdAt",
+			bson.D{
+				{"$gte", start},
+				{"$lt", end},
+			},
+		},
+	}
+	if len(instruments) > 0 {
+		or := bson.A{}
+		for _, name := range instruments {
+			instrument, err := utils.ParseInstruments(name, false)
 			if err != nil {
-				logs.Log.Error().Err(err).Msg("cannot parsed amount")
 				continue
 			}
+			or = append(or, bson.D{
+				{"underlying", instrument.Underlying},
+				{"strikePrice", instrument.Strike},
+				{"expiryDate", instrument.ExpDate},
+				{"contracts", instrument.Contracts},
+			})
+		}
+		if len(or) == 0 {
+			return nil, nil
+		}
+		match = append(match, bson.E{"$or", or})
+	}
+
+	// the bins of $dateTrunc are aligned on UTC midnight as the resolutions divide a day
+	trunc := bson.M{"date": "$createdAt", "unit": "minute", "binSize": int64(candle.Resolutions[resolution] / time.Minute)}
+	if resolution == "1D" {
+		trunc = bson.M{"date": "$createdAt", "unit": "day"}
+	}
+
+	amount := bson.M{"$toDouble": "$amount"}
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", match}},
+		bson.D{{"$sort", bson.D{{"createdAt", 1}}}},
+		bson.D{{"$group", bson.M{
+			"_id": bson.M{
+				"underlying":  "$underlying",
+				"expiryDate":  "$expiryDate",
+				"strikePrice": "$strikePrice",
+				"contracts":   "$contracts",
+				"tick":        bson.M{"$dateTrunc": trunc},
+			},
+			"open":   bson.M{"$first": "$price"},
+			"high":   bson.M{"$max": "$price"},
+			"low":    bson.M{"$min": "$price"},
+			"close":  bson.M{"$last": "$price"},
+			"volume": bson.M{"$sum": amount},
+			"cost":   bson.M{"$sum": bson.M{"$multiply": bson.A{"$price", amount}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
 
-			cost += amount.Mul(decimal.NewFromFloat(trade.Price)).InexactFloat64()
-			volume += amount.InexactFloat64()
+	var groups []struct {
+		ID struct {
+			Underlying  string          `bson:"underlying"`
+			ExpiryDate  string          `bson:"expiryDate"`
+			StrikePrice float64         `bson:"strikePrice"`
+			Contracts   types.Contracts `bson:"contracts"`
+			Tick        time.Time       `bson:"tick"`
+		} `bson:"_id"`
+		Open   float64 `bson:"open"`
+		High   float64 `bson:"high"`
+		Low    float64 `bson:"low"`
+		Close  float64 `bson:"close"`
+		Volume float64 `bson:"volume"`
+		Cost   float64 `bson:"cost"`
+	}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	candles := make([]*_tradeType.Candle, 0, len(groups))
+	for _, g := range groups {
+		if g.ID.Contracts == "" {
+			continue
 		}
 
-		res.Cost = append(res.Cost, cost)
-		res.Volume = append(res.Volume, volume)
+		candles = append(candles, &_tradeType.Candle{
+			Tick: g.ID.Tick,
+			Meta: _tradeType.CandleMeta{
+				InstrumentName: g.ID.Underlying + "-" + g.ID.ExpiryDate + "-" + fmt.Sprintf("%.0f", g.ID.StrikePrice) + "-" + string(g.ID.Contracts[0]),
+				Resolution:     resolution,
+			},
+			Open:   g.Open,
+			High:   g.High,
+			Low:    g.Low,
+			Close:  g.Close,
+			Volume: g.Volume,
+			Cost:   g.Cost,
+		})
 	}
 
-	return
+	return candles, nil
 }
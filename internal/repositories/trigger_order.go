@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	_tradeType "gateway/internal/repositories/types"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TriggerOrderRepository holds the trigger orders of the gateway, the engine only sees
+// them once they are triggered
+type TriggerOrderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTriggerOrderRepository(db Database) *TriggerOrderRepository {
+	collection := db.InitCollection("trigger_orders")
+	return &TriggerOrderRepository{collection}
+}
+
+func (r TriggerOrderRepository) Find(filter interface{}, sort interface{}, offset, limit int64) ([]*_tradeType.TriggerOrder, error) {
+	options := options.FindOptions{
+		MaxTime: &defaultTimeout,
+	}
+
+	if offset >= 0 {
+		options.SetSkip(offset)
+	}
+
+	if limit >= 0 {
+		options.SetLimit(limit)
+	}
+
+	if sort != nil {
+		options.SetSort(sort)
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	cursor, err := r.collection.Find(context.Background(), filter, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	orders := []*_tradeType.TriggerOrder{}
+	if err = cursor.All(context.Background(), &orders); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (r TriggerOrderRepository) Insert(order *_tradeType.TriggerOrder) error {
+	res, err := r.collection.InsertOne(context.Background(), order)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return err
+	}
+
+	order.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r TriggerOrderRepository) FindById(id string) (*_tradeType.TriggerOrder, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var order _tradeType.TriggerOrder
+	err = r.collection.FindOne(context.Background(), bson.M{"_id": oid}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// UpdateStatus moves the order from the status to the next one with the fields, it
+// returns the updated order or nil when the order is not in the status anymore
+func (r TriggerOrderRepository) UpdateStatus(id primitive.ObjectID, from, to types.OrderStatus, fields bson.M) (*_tradeType.TriggerOrder, error) {
+	set := bson.M{"status": to, "updatedAt": time.Now()}
+	for k, v := range fields {
+		set[k] = v
+	}
+
+	var order _tradeType.TriggerOrder
+	err := r.collection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// FindReached returns the untriggered orders following the trigger in the scope whose
+// trigger price is reached by the price, the trailing orders are evaluated on their own
+func (r TriggerOrderRepository) FindReached(scope bson.M, trigger string, price float64) ([]*_tradeType.TriggerOrder, error) {
+	filter := bson.M{
+		"status":        _tradeType.UNTRIGGERED,
+		"trigger":       trigger,
+		"triggerOffset": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"above": true, "triggerPrice": bson.M{"$lte": price}},
+			bson.M{"above": false, "triggerPrice": bson.M{"$gte": price}},
+		},
+	}
+	for k, v := range scope {
+		filter[k] = v
+	}
+
+	return r.Find(filter, bson.M{"createdAt": 1}, 0, -1)
+}
+
+// FindTrailing returns the untriggered trailing orders following the trigger in the scope
+func (r TriggerOrderRepository) FindTrailing(scope bson.M, trigger string) ([]*_tradeType.TriggerOrder, error) {
+	filter := bson.M{
+		"status":        _tradeType.UNTRIGGERED,
+		"trigger":       trigger,
+		"triggerOffset": bson.M{"$gt": 0},
+	}
+	for k, v := range scope {
+		filter[k] = v
+	}
+
+	return r.Find(filter, bson.M{"createdAt": 1}, 0, -1)
+}
+
+// UpdateTriggerPrice moves the trigger price of the untriggered order from the price to
+// the next one, it returns the updated order or nil when another node moved it first
+func (r TriggerOrderRepository) UpdateTriggerPrice(id primitive.ObjectID, from, to float64) (*_tradeType.TriggerOrder, error) {
+	var order _tradeType.TriggerOrder
+	err := r.collection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": id, "status": _tradeType.UNTRIGGERED, "triggerPrice": from},
+		bson.M{"$set": bson.M{"triggerPrice": to, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// GetUntriggered returns the untriggered orders of the user, of an instrument when the
+// instrument name is set
+func (r TriggerOrderRepository) GetUntriggered(userId, instrumentName string) ([]*_tradeType.TriggerOrder, error) {
+	uId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"status": _tradeType.UNTRIGGERED,
+		"userId": uId,
+	}
+	if instrumentName != "" {
+		filter["instrumentName"] = instrumentName
+	}
+
+	return r.Find(filter, bson.M{"createdAt": -1}, 0, -1)
+}
+
+// GetUntriggeredByInstrument returns the untriggered orders of all the users of the
+// instrument
+func (r TriggerOrderRepository) GetUntriggeredByInstrument(instrumentName string) ([]*_tradeType.TriggerOrder, error) {
+	return r.Find(bson.M{
+		"status":         _tradeType.UNTRIGGERED,
+		"instrumentName": instrumentName,
+	}, nil, 0, -1)
+}
+
+// GetUntriggeredInstruments returns the names of the instruments with untriggered orders
+func (r TriggerOrderRepository) GetUntriggeredInstruments() ([]string, error) {
+	values, err := r.collection.Distinct(context.Background(), "instrumentName", bson.M{"status": _tradeType.UNTRIGGERED})
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+
+		return nil, err
+	}
+
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		if name, ok := v.(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"time"
+
+	"gateway/pkg/candle"
+)
+
+type CandleMeta struct {
+	InstrumentName string `bson:"instrumentName"`
+	Resolution     string `bson:"resolution"`
+}
+
+// Candle is a closed candle of the candle store, a candle written again replaces the
+// older ones of the same tick
+type Candle struct {
+	Tick      time.Time  `bson:"tick"`
+	Meta      CandleMeta `bson:"meta"`
+	Open      float64    `bson:"open"`
+	High      float64    `bson:"high"`
+	Low       float64    `bson:"low"`
+	Close     float64    `bson:"close"`
+	Volume    float64    `bson:"volume"`
+	Cost      float64    `bson:"cost"`
+	CreatedAt time.Time  `bson:"createdAt"`
+}
+
+func (c Candle) Candle() candle.Candle {
+	return candle.Candle{
+		Tick:   c.Tick.UnixMilli(),
+		Open:   c.Open,
+		High:   c.High,
+		Low:    c.Low,
+		Close:  c.Close,
+		Volume: c.Volume,
+		Cost:   c.Cost,
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"time"
+
+	deribitModel "gateway/internal/deribit/model"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the states of an order of a linked order graph, a pending order waits for its primary
+// order to be filled before it is placed
+const (
+	LINK_PENDING   = "pending"
+	LINK_OPEN      = "open"
+	LINK_FILLED    = "filled"
+	LINK_CANCELLED = "cancelled"
+)
+
+// LinkedOrder is an order of a linked order graph held by the gateway, the primary order
+// is the order of the request and the others are the orders of its otoco config
+type LinkedOrder struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty"`
+	UserID          primitive.ObjectID `bson:"userId"`
+	PrimaryID       primitive.ObjectID `bson:"primaryId"`
+	Primary         bool               `bson:"primary"`
+	LinkedOrderType string             `bson:"linkedOrderType"`
+	// shared by the orders which cancel each other
+	OcoRef  string `bson:"ocoRef,omitempty"`
+	ClOrdID string `bson:"clOrdId"`
+	// made by the gateway and echoed by the engine with the order
+	RequestID string `bson:"requestId"`
+	// the order of the engine or of the trigger orders, set once it is placed
+	OrderID   primitive.ObjectID          `bson:"orderId,omitempty"`
+	Request   deribitModel.DeribitRequest `bson:"request"`
+	Status    string                      `bson:"status"`
+	CreatedAt time.Time                   `bson:"createdAt"`
+	UpdatedAt time.Time                   `bson:"updatedAt"`
+}
+
+// Ref returns the id of the order, the one of the graph until it is placed
+func (o LinkedOrder) Ref() string {
+	if o.OrderID.IsZero() {
+		return o.ID.Hex()
+	}
+
+	return o.OrderID.Hex()
+}
+
+// LinkedRef is the link of an order to the other orders of its graph
+type LinkedRef struct {
+	OtoOrderIds []string
+	OcoRef      string
+}
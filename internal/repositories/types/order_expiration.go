@@ -0,0 +1,34 @@
+package types
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the states of an order expiration, a closed order was filled or cancelled before it
+// expired
+const (
+	EXPIRATION_PENDING = "pending"
+	EXPIRATION_EXPIRED = "expired"
+	EXPIRATION_CLOSED  = "closed"
+)
+
+// OrderExpiration is the expiry of a good_til_day or good_til_date order, the gateway
+// cancels the order once it is reached
+type OrderExpiration struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	UserID  primitive.ObjectID `bson:"userId"`
+	ClOrdID string             `bson:"clOrdId"`
+	// the id of the command made by the gateway, echoed by the engine with the order
+	RequestID string `bson:"requestId"`
+	// the order of the engine, or the trigger order until it is released
+	OrderID     primitive.ObjectID `bson:"orderId,omitempty"`
+	TimeInForce string             `bson:"timeInForce"`
+	ExpireAt    time.Time          `bson:"expireAt"`
+	Status      string             `bson:"status"`
+	// the node cancelling the order holds it until then
+	LockedUntil time.Time `bson:"lockedUntil,omitempty"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	UpdatedAt   time.Time `bson:"updatedAt"`
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"time"
+
+	deribitModel "gateway/internal/deribit/model"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the states of a trigger order before and after its trigger price is reached, it is
+// cancelled with types.CANCELLED
+const (
+	UNTRIGGERED types.OrderStatus = "untriggered"
+	TRIGGERED   types.OrderStatus = "triggered"
+)
+
+// TriggerOrder is a stop or take profit order held by the gateway, it is sent to the
+// engine as a limit or market order once its trigger price is reached
+type TriggerOrder struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	UserID         primitive.ObjectID `bson:"userId"`
+	ClientID       string             `bson:"clientId"`
+	InstrumentName string             `bson:"instrumentName"`
+	Underlying     string             `bson:"underlying"`
+	ExpiryDate     string             `bson:"expiryDate"`
+	StrikePrice    float64            `bson:"strikePrice"`
+	Contracts      types.Contracts    `bson:"contracts"`
+	Type           types.Type         `bson:"type"`
+	Side           types.Side         `bson:"side"`
+	Price          float64            `bson:"price"`
+	Amount         float64            `bson:"amount"`
+	TimeInForce    types.TimeInForce  `bson:"timeInForce"`
+	Label          string             `bson:"label"`
+	MaxShow        float64            `bson:"maxShow"`
+	ReduceOnly     bool               `bson:"reduceOnly"`
+	PostOnly       bool               `bson:"postOnly"`
+	UserRole       types.UserRole     `bson:"userRole"`
+	Trigger        string             `bson:"trigger"`
+	TriggerPrice   float64            `bson:"triggerPrice"`
+	// the distance of the trigger price of a trailing order to the price it follows
+	TriggerOffset float64 `bson:"triggerOffset,omitempty"`
+	// the order fires when the price rises to the trigger price, when it falls otherwise
+	Above        bool              `bson:"above"`
+	Status       types.OrderStatus `bson:"status"`
+	CancelReason string            `bson:"cancelReason"`
+	// the request which placed the order, and the one of the order sent to the engine
+	ClOrdID          string `bson:"clOrdId"`
+	TriggeredClOrdID string `bson:"triggeredClOrdId,omitempty"`
+	// the id of the command made by the gateway, the order is sent to the engine with it
+	RequestID string    `bson:"requestId,omitempty"`
+	CreatedAt time.Time `bson:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// Order returns the trigger order as an open order of the user
+func (o TriggerOrder) Order() deribitModel.DeribitGetOpenOrdersByInstrumentResponse {
+	triggerPrice := o.TriggerPrice
+
+	return deribitModel.DeribitGetOpenOrdersByInstrumentResponse{
+		Amount:              o.Amount,
+		InstrumentName:      o.InstrumentName,
+		Direction:           o.Side,
+		Price:               o.Price,
+		OrderId:             o.ID,
+		TimeInForce:         o.TimeInForce,
+		OrderType:           o.Type,
+		OrderState:          o.Status,
+		MaxShow:             o.MaxShow,
+		PostOnly:            o.PostOnly,
+		ReduceOnly:          o.ReduceOnly,
+		Label:               o.Label,
+		Usd:                 o.Price,
+		CreationTimestamp:   o.CreatedAt.UnixMilli(),
+		LastUpdateTimestamp: o.UpdatedAt.UnixMilli(),
+		Api:                 true,
+		CancelledReason:     o.CancelReason,
+		UserId:              o.UserID,
+		Trigger:             o.Trigger,
+		TriggerPrice:        &triggerPrice,
+		TriggerOffset:       o.Offset(),
+	}
+}
+
+// Offset returns the trigger offset of a trailing order, nil otherwise
+func (o TriggerOrder) Offset() *float64 {
+	if o.TriggerOffset <= 0 {
+		return nil
+	}
+
+	offset := o.TriggerOffset
+	return &offset
+}
@@ -0,0 +1,11 @@
+package types
+
+import "time"
+
+// VolatilityIndex is a sample of the at the money volatility index of a currency in
+// percent
+type VolatilityIndex struct {
+	Ts       time.Time `bson:"ts"`
+	Currency string    `bson:"currency"`
+	Value    float64   `bson:"value"`
+}
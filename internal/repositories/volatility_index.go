@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/candle"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VolatilityIndexRepository is a time-series collection of the samples of the volatility
+// index of every currency
+type VolatilityIndexRepository struct {
+	collection *mongo.Collection
+}
+
+func NewVolatilityIndexRepository(db Database) *VolatilityIndexRepository {
+	collection := db.InitCollection("volatility_index")
+	return &VolatilityIndexRepository{collection}
+}
+
+// EnsureCollection creates the time-series collection when it does not exist, the samples
+// are removed once older than the retention
+func (r VolatilityIndexRepository) EnsureCollection(retention time.Duration) error {
+	db := r.collection.Database()
+	expireAfter := int64(retention / time.Second)
+
+	names, err := db.ListCollectionNames(context.Background(), bson.M{"name": r.collection.Name()})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return db.RunCommand(context.Background(), bson.D{
+			{"collMod", r.collection.Name()},
+			{"expireAfterSeconds", expireAfter},
+		}).Err()
+	}
+
+	timeSeries := options.TimeSeries().
+		SetTimeField("ts").
+		SetMetaField("currency").
+		SetGranularity("seconds")
+
+	return db.CreateCollection(context.Background(), r.collection.Name(), options.CreateCollection().
+		SetTimeSeriesOptions(timeSeries).
+		SetExpireAfterSeconds(expireAfter))
+}
+
+func (r VolatilityIndexRepository) Insert(samples []*_tradeType.VolatilityIndex) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(samples))
+	for _, s := range samples {
+		docs = append(docs, s)
+	}
+
+	_, err := r.collection.InsertMany(context.Background(), docs)
+	return err
+}
+
+// Candles returns the OHLC of the samples of the currency in [start, end) sorted by tick,
+// at most the latest limit ones. The resolution is one of the index resolutions
+func (r VolatilityIndexRepository) Candles(currency, resolution string, start, end time.Time, limit int64) ([]candle.Candle, error) {
+	options := options.AggregateOptions{
+		MaxTime: &defaultTimeout,
+	}
+
+	// the bins of $dateTrunc are aligned on UTC midnight as the resolutions divide a day
+	trunc := bson.M{"date": "$ts", "unit": "second", "binSize": int64(candle.IndexResolutions[resolution] / time.Second)}
+	if resolution == "1D" {
+		trunc = bson.M{"date": "$ts", "unit": "day"}
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", bson.M{
+			"currency": currency,
+			"ts":       bson.M{"$gte": start, "$lt": end},
+		}}},
+		bson.D{{"$sort", bson.D{{"ts", 1}}}},
+		bson.D{{"$group", bson.M{
+			"_id":   bson.M{"$dateTrunc": trunc},
+			"open":  bson.M{"$first": "$value"},
+			"high":  bson.M{"$max": "$value"},
+			"low":   bson.M{"$min": "$value"},
+			"close": bson.M{"$last": "$value"},
+		}}},
+		bson.D{{"$sort", bson.D{{"_id", -1}}}},
+		bson.D{{"$limit", limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline, &options)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var groups []struct {
+		Tick  time.Time `bson:"_id"`
+		Open  float64   `bson:"open"`
+		High  float64   `bson:"high"`
+		Low   float64   `bson:"low"`
+		Close float64   `bson:"close"`
+	}
+	if err = cursor.All(context.Background(), &groups); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return nil, err
+	}
+
+	// the groups are the latest first
+	candles := make([]candle.Candle, 0, len(groups))
+	for i := len(groups) - 1; i >= 0; i-- {
+		g := groups[i]
+		candles = append(candles, candle.Candle{
+			Tick:  g.Tick.UnixMilli(),
+			Open:  g.Open,
+			High:  g.High,
+			Low:   g.Low,
+			Close: g.Close,
+		})
+	}
+
+	return candles, nil
+}
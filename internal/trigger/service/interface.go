@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"gateway/internal/deribit/model"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+)
+
+type ITriggerService interface {
+	// Place sets the id of the trigger order on the payload
+	Place(ctx context.Context, instrumentName string, payload *model.DeribitResponse, trigger string, triggerPrice, triggerOffset float64) (*validation_reason.ValidationReason, error)
+	Cancel(ctx context.Context, userId string, data model.DeribitCancelRequest) (bool, error)
+	CancelAll(ctx context.Context, userId, instrumentName, orderType string)
+	Expire(ctx context.Context, id string) bool
+	CloseInstrument(ctx context.Context, instrumentName string)
+	HandleConsumePrices(msg *sarama.ConsumerMessage)
+	HandleConsumeEngineSaved(msg *sarama.ConsumerMessage)
+}
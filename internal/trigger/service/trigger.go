@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateway/internal/deribit/model"
+	_engineType "gateway/internal/engine/types"
+	"gateway/internal/orderbook/book"
+	_orderbookType "gateway/internal/orderbook/types"
+	_outboxSvc "gateway/internal/outbox/service"
+	"gateway/internal/repositories"
+	_tradeType "gateway/internal/repositories/types"
+	_wsSvc "gateway/internal/ws/service"
+	"gateway/pkg/collector"
+	"gateway/pkg/constant"
+	"gateway/pkg/expiry"
+	"gateway/pkg/protocol"
+	"gateway/pkg/trigger"
+	"gateway/pkg/utils"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type triggerService struct {
+	repo            *repositories.TriggerOrderRepository
+	rawPriceRepo    *repositories.RawPriceRepository
+	linkedOrderRepo *repositories.LinkedOrderRepository
+	outbox          _outboxSvc.IOutboxService
+	wsOrderSvc      _wsSvc.IwsOrderService
+}
+
+func NewTriggerService(
+	repo *repositories.TriggerOrderRepository,
+	rawPriceRepo *repositories.RawPriceRepository,
+	linkedOrderRepo *repositories.LinkedOrderRepository,
+	outbox _outboxSvc.IOutboxService,
+	wsOrderSvc _wsSvc.IwsOrderService,
+) ITriggerService {
+	return &triggerService{repo, rawPriceRepo, linkedOrderRepo, outbox, wsOrderSvc}
+}
+
+// Place holds the trigger order until its trigger price is reached, the caller gets the
+// untriggered order right away
+func (svc triggerService) Place(ctx context.Context, instrumentName string, payload *model.DeribitResponse, source string, triggerPrice, triggerOffset float64) (*validation_reason.ValidationReason, error) {
+	trailing := string(payload.Type) == trigger.TrailingStop
+	if !trigger.IsSource(source) || (trailing && !trigger.IsTrailingSource(source)) {
+		reason := validation_reason.INVALID_PARAMS
+		return &reason, errors.New(constant.INVALID_TRIGGER)
+	}
+
+	above := trigger.Above(string(payload.Type), payload.Side == types.BUY)
+	if trailing {
+		if triggerOffset <= 0 {
+			reason := validation_reason.INVALID_PARAMS
+			return &reason, errors.New(constant.TRIGGER_OFFSET_IS_REQUIRED)
+		}
+
+		// the trigger price starts at the offset of the current price, without one it is
+		// set by the next price
+		price := svc.price(source, instrumentName, *payload)
+		if !trigger.ValidOffset(triggerOffset, price) {
+			reason := validation_reason.INVALID_PARAMS
+			return &reason, errors.New(constant.INVALID_TRIGGER_OFFSET)
+		}
+		triggerPrice = trigger.Trail(above, 0, triggerOffset, price)
+	} else {
+		triggerOffset = 0
+	}
+
+	if !trailing && triggerPrice <= 0 {
+		reason := validation_reason.INVALID_PARAMS
+		return &reason, errors.New(constant.TRIGGER_PRICE_IS_REQUIRED)
+	}
+
+	if trigger.IsLimit(string(payload.Type)) && payload.Price <= 0 {
+		reason := validation_reason.PRICE_IS_REQUIRED
+		return &reason, errors.New(reason.String())
+	}
+
+	userId, err := primitive.ObjectIDFromHex(payload.UserId)
+	if err != nil {
+		reason := validation_reason.UNAUTHORIZED
+		return &reason, errors.New(reason.String())
+	}
+
+	now := time.Now()
+	order := _tradeType.TriggerOrder{
+		UserID:         userId,
+		ClientID:       payload.ClientId,
+		InstrumentName: instrumentName,
+		Underlying:     payload.Underlying,
+		ExpiryDate:     payload.ExpirationDate,
+		StrikePrice:    payload.StrikePrice,
+		Contracts:      payload.Contracts,
+		Type:           payload.Type,
+		Side:           payload.Side,
+		Price:          payload.Price,
+		Amount:         payload.Amount,
+		TimeInForce:    payload.TimeInForce,
+		Label:          payload.Label,
+		MaxShow:        payload.MaxShow,
+		ReduceOnly:     payload.ReduceOnly,
+		PostOnly:       payload.PostOnly,
+		UserRole:       payload.UserRole,
+		Trigger:        source,
+		TriggerPrice:   triggerPrice,
+		TriggerOffset:  triggerOffset,
+		Above:          above,
+		Status:         _tradeType.UNTRIGGERED,
+		CancelReason:   "none",
+		ClOrdID:        payload.ClOrdID,
+		RequestID:      payload.RequestID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := svc.repo.Insert(&order); err != nil {
+		return nil, err
+	}
+
+	protocol.SendSuccessMsg(connKey(&order), _engineType.BuySellEditResponse{
+		Order:  svc.response(&order),
+		Trades: []_engineType.BuySellEditTrade{},
+	})
+	payload.ID = order.ID.Hex()
+	svc.publish(&order)
+
+	return nil, nil
+}
+
+// Cancel cancels the untriggered order of the request, it returns false when the order
+// is not a trigger order
+func (svc triggerService) Cancel(ctx context.Context, userId string, data model.DeribitCancelRequest) (bool, error) {
+	order, err := svc.repo.FindById(data.Id)
+	if err != nil || order == nil {
+		return false, nil
+	}
+
+	if order.UserID.Hex() != userId {
+		return true, errors.New(constant.NOT_OWNER_OF_ORDER)
+	}
+
+	cancelled, err := svc.cancel(order, "user_request")
+	if err != nil {
+		return true, err
+	}
+	if cancelled == nil {
+		return true, errors.New(constant.ORDER_ALREADY_CLOSED)
+	}
+
+	ID, _ := strconv.ParseUint(data.ClOrdID, 0, 64)
+	protocol.SendSuccessMsg(utils.GetKeyFromIdUserID(ID, userId), _engineType.CancelResponse{
+		Order: svc.response(cancelled),
+	})
+
+	return true, nil
+}
+
+// CancelAll cancels the untriggered orders of the user selected by the order type filter,
+// of an instrument when the instrument name is set
+func (svc triggerService) CancelAll(ctx context.Context, userId, instrumentName, orderType string) {
+	orders, err := svc.repo.GetUntriggered(userId, instrumentName)
+	if err != nil {
+		return
+	}
+
+	for _, order := range orders {
+		if trigger.MatchesType(orderType, string(order.Type)) {
+			svc.cancel(order, "user_request")
+		}
+	}
+}
+
+// Expire cancels the untriggered order once its time in force ran out, it returns false
+// when the order is not an untriggered order
+func (svc triggerService) Expire(ctx context.Context, id string) bool {
+	order, err := svc.repo.FindById(id)
+	if err != nil || order == nil {
+		return false
+	}
+
+	cancelled, err := svc.cancel(order, expiry.CancelReason)
+	return err == nil && cancelled != nil
+}
+
+// CloseInstrument cancels the untriggered orders of the instrument once it expired
+func (svc triggerService) CloseInstrument(ctx context.Context, instrumentName string) {
+	orders, err := svc.repo.GetUntriggeredByInstrument(instrumentName)
+	if err != nil {
+		return
+	}
+
+	for _, order := range orders {
+		svc.cancel(order, expiry.InstrumentCancelReason)
+	}
+}
+
+func (svc triggerService) cancel(order *_tradeType.TriggerOrder, reason string) (*_tradeType.TriggerOrder, error) {
+	cancelled, err := svc.repo.UpdateStatus(order.ID, _tradeType.UNTRIGGERED, types.CANCELLED, bson.M{"cancelReason": reason})
+	if err != nil || cancelled == nil {
+		return nil, err
+	}
+
+	svc.publish(cancelled)
+
+	return cancelled, nil
+}
+
+// HandleConsumePrices triggers the orders following the index of the prices
+func (svc triggerService) HandleConsumePrices(msg *sarama.ConsumerMessage) {
+	var data _engineType.MessagePrices
+	if err := json.Unmarshal(msg.Value, &data); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return
+	}
+
+	keys := make(map[string]bool)
+	for _, rawPrice := range data.RawPrice {
+		pair := string(rawPrice.Metadata.Pair)
+		if rawPrice.Metadata.Type != "index" || keys[pair] {
+			continue
+		}
+		keys[pair] = true
+
+		// e.g. btc_usd is the index of BTC
+		underlying := strings.ToUpper(strings.Split(pair, "_")[0])
+		svc.evaluate(bson.M{"underlying": underlying}, trigger.IndexPrice, rawPrice.Price)
+		svc.trail(underlying, rawPrice.Price)
+	}
+}
+
+// trail moves the trigger price of the trailing orders of the underlying with the index
+// or the mark price, and triggers the ones the price reached
+func (svc triggerService) trail(underlying string, indexPrice float64) {
+	orders, err := svc.repo.FindTrailing(bson.M{"underlying": underlying}, trigger.IndexPrice)
+	if err != nil {
+		return
+	}
+	for _, order := range orders {
+		svc.follow(order, indexPrice)
+	}
+
+	svc.trailMark(bson.M{"underlying": underlying})
+}
+
+// trailMark moves the trigger price of the trailing orders following the mark price in
+// the scope, and triggers the ones the price reached
+func (svc triggerService) trailMark(scope bson.M) {
+	orders, err := svc.repo.FindTrailing(scope, trigger.MarkPrice)
+	if err != nil {
+		return
+	}
+	markPrices := make(map[string]float64)
+	for _, order := range orders {
+		markPrice, ok := markPrices[order.InstrumentName]
+		if !ok {
+			markPrice = markPriceOf(order.InstrumentName, order.Underlying, order.ExpiryDate, order.StrikePrice)
+			markPrices[order.InstrumentName] = markPrice
+		}
+		svc.follow(order, markPrice)
+	}
+}
+
+func (svc triggerService) follow(order *_tradeType.TriggerOrder, price float64) {
+	triggerPrice := trigger.Trail(order.Above, order.TriggerPrice, order.TriggerOffset, price)
+	if triggerPrice != order.TriggerPrice {
+		moved, err := svc.repo.UpdateTriggerPrice(order.ID, order.TriggerPrice, triggerPrice)
+		if err != nil || moved == nil {
+			return
+		}
+		order = moved
+
+		svc.publish(order)
+	}
+
+	if order.TriggerPrice > 0 && trigger.Reached(order.Above, order.TriggerPrice, price) {
+		svc.release(order)
+	}
+}
+
+// price returns the current price the trigger follows, 0 without one
+func (svc triggerService) price(source, instrumentName string, payload model.DeribitResponse) float64 {
+	if source == trigger.MarkPrice {
+		return markPriceOf(instrumentName, payload.Underlying, payload.ExpirationDate, payload.StrikePrice)
+	}
+
+	indexPrice := svc.rawPriceRepo.GetLatestIndexPrice(_orderbookType.GetOrderBook{Underlying: payload.Underlying})
+	if len(indexPrice) == 0 {
+		return 0
+	}
+
+	return indexPrice[0].Price
+}
+
+// markPriceOf returns the mid of the book of the instrument, 0 without a bid and an ask
+func markPriceOf(instrumentName, underlying, expiryDate string, strikePrice float64) float64 {
+	bid, ask := book.BestPrices(_orderbookType.GetOrderBook{
+		InstrumentName: instrumentName,
+		Underlying:     underlying,
+		ExpiryDate:     expiryDate,
+		StrikePrice:    strikePrice,
+	})
+	if bid <= 0 || ask <= 0 {
+		return 0
+	}
+
+	return (bid + ask) / 2
+}
+
+// HandleConsumeEngineSaved triggers the orders following the last or the mark price of
+// the instrument of the match
+func (svc triggerService) HandleConsumeEngineSaved(msg *sarama.ConsumerMessage) {
+	var data _engineType.EngineResponse
+	if err := json.Unmarshal(msg.Value, &data); err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return
+	}
+
+	if data.Matches == nil || data.Matches.TakerOrder == nil || len(data.Matches.TakerOrder.Contracts) == 0 {
+		return
+	}
+
+	taker := data.Matches.TakerOrder
+	instrument := utils.Instruments{
+		Underlying: taker.Underlying,
+		ExpDate:    taker.ExpiryDate,
+		Contracts:  taker.Contracts,
+		Strike:     taker.StrikePrice,
+	}
+	scope := bson.M{"instrumentName": instrument.Name()}
+
+	if n := len(data.Matches.Trades); n > 0 {
+		svc.evaluate(scope, trigger.LastPrice, data.Matches.Trades[n-1].Price)
+	}
+
+	// the mark price is the mid of the book, the book in memory is updated first
+	if markPrice := markPriceOf(instrument.Name(), instrument.Underlying, instrument.ExpDate, instrument.Strike); markPrice > 0 {
+		svc.evaluate(scope, trigger.MarkPrice, markPrice)
+	}
+	svc.trailMark(scope)
+}
+
+func (svc triggerService) evaluate(scope bson.M, source string, price float64) {
+	orders, err := svc.repo.FindReached(scope, source, price)
+	if err != nil {
+		return
+	}
+
+	for _, order := range orders {
+		svc.release(order)
+	}
+}
+
+// release sends the order to the engine once, a node which lost the race for the order
+// skips it
+func (svc triggerService) release(order *_tradeType.TriggerOrder) {
+	clOrdID := strconv.FormatInt(time.Now().UnixNano(), 10)
+	triggered, err := svc.repo.UpdateStatus(order.ID, _tradeType.UNTRIGGERED, _tradeType.TRIGGERED, bson.M{"triggeredClOrdId": clOrdID})
+	if err != nil || triggered == nil {
+		return
+	}
+
+	payload := model.DeribitResponse{
+		UserId:         triggered.UserID.Hex(),
+		ClientId:       triggered.ClientID,
+		Underlying:     triggered.Underlying,
+		ExpirationDate: triggered.ExpiryDate,
+		StrikePrice:    triggered.StrikePrice,
+		Type:           types.Type(trigger.Released(string(triggered.Type))),
+		Side:           triggered.Side,
+		ClOrdID:        clOrdID,
+		Price:          triggered.Price,
+		Amount:         triggered.Amount,
+		Contracts:      triggered.Contracts,
+		TimeInForce:    triggered.TimeInForce,
+		Label:          triggered.Label,
+		MaxShow:        triggered.MaxShow,
+		ReduceOnly:     triggered.ReduceOnly,
+		PostOnly:       triggered.PostOnly,
+		UserRole:       triggered.UserRole,
+		RequestID:      triggered.RequestID,
+	}
+	// the orders placed before the request ids
+	if payload.RequestID == "" {
+		payload.RequestID = triggered.ID.Hex()
+	}
+	out, err := json.Marshal(payload)
+	if err == nil {
+		// collector
+		go collector.StartKafkaDuration(payload.UserId, payload.ClOrdID)
+
+		//send to kafka through the outbox
+		err = svc.outbox.Publish(context.Background(), types.NEW_ORDER.String(), payload.RequestID, payload.UserId, payload.ClOrdID, out)
+	}
+	if err != nil {
+		// the order waits for the next price
+		logs.Log.Error().Err(err).Str("order_id", triggered.ID.Hex()).Msg("failed to release trigger order")
+		svc.repo.UpdateStatus(triggered.ID, _tradeType.TRIGGERED, _tradeType.UNTRIGGERED, bson.M{"triggeredClOrdId": ""})
+		return
+	}
+
+	svc.publish(triggered)
+}
+
+// connKey is the key of the request which placed the order
+func connKey(order *_tradeType.TriggerOrder) string {
+	ID, _ := strconv.ParseUint(order.ClOrdID, 0, 64)
+	return utils.GetKeyFromIdUserID(ID, order.UserID.Hex())
+}
+
+// publish sends the change of the order on the user.orders channels
+func (svc triggerService) publish(order *_tradeType.TriggerOrder) {
+	o := order.Order()
+	ref := svc.linkedOrderRepo.Ref(order.ID, order.RequestID)
+	o.OtoOrderIds, o.OcoRef = ref.OtoOrderIds, ref.OcoRef
+
+	svc.wsOrderSvc.PublishUserOrder(order.InstrumentName, order.UserID.Hex(), o)
+}
+
+func (svc triggerService) response(order *_tradeType.TriggerOrder) _engineType.BuySellEditCancelOrder {
+	triggerPrice := order.TriggerPrice
+	ref := svc.linkedOrderRepo.Ref(order.ID, order.RequestID)
+
+	return _engineType.BuySellEditCancelOrder{
+		OrderState:          order.Status,
+		Usd:                 order.Price,
+		InstrumentName:      order.InstrumentName,
+		Direction:           order.Side,
+		LastUpdateTimestamp: utils.MakeTimestamp(order.UpdatedAt),
+		Price:               order.Price,
+		Amount:              order.Amount,
+		OrderId:             order.ID,
+		OrderType:           order.Type,
+		TimeInForce:         order.TimeInForce,
+		CreationTimestamp:   utils.MakeTimestamp(order.CreatedAt),
+		Label:               order.Label,
+		Api:                 true,
+		CancelReason:        order.CancelReason,
+		MaxShow:             order.MaxShow,
+		PostOnly:            order.PostOnly,
+		ReduceOnly:          order.ReduceOnly,
+		Trigger:             order.Trigger,
+		TriggerPrice:        &triggerPrice,
+		TriggerOffset:       order.Offset(),
+		OtoOrderIds:         ref.OtoOrderIds,
+		OcoRef:              ref.OcoRef,
+	}
+}
@@ -129,8 +129,8 @@ func (s AuthService) RefreshToken(ctx context.Context, claim types.JwtClaim) (re
 }
 
 func ClaimJWT(c *ws.Client, jwtToken string) (types.JwtClaim, error) {
-	// If c not nil, check is client is authed connection
-	if c != nil {
+	// If c not nil and no token is given, check is client is authed connection
+	if c != nil && jwtToken == "" {
 		if isAuthed, userId := c.IsAuthed(); isAuthed {
 			return types.JwtClaim{
 				UserID: userId,
@@ -0,0 +1,5 @@
+package service
+
+type IVolatilityService interface {
+	Run()
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"gateway/internal/orderbook/book"
+	_orderbookType "gateway/internal/orderbook/types"
+	"gateway/internal/repositories"
+	_tradeType "gateway/internal/repositories/types"
+	"gateway/pkg/pricing"
+	"gateway/pkg/utils"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the volatility index is the 30 days constant maturity at the money implied volatility,
+// sampled every second
+const (
+	volatilityIndexYears    = 30.0 / 365
+	volatilityIndexInterval = time.Second
+)
+
+// the instruments are read again from the orders once per refresh
+const instrumentsRefresh = time.Minute
+
+// a single node samples the volatility index, it renews its lease on every sample and
+// another node takes over once the lease ends
+const (
+	volatilityIndexLease    = "volatility_index"
+	volatilityIndexLeaseTTL = 10 * volatilityIndexInterval
+)
+
+type volatilityService struct {
+	orderRepo           *repositories.OrderRepository
+	rawPriceRepo        *repositories.RawPriceRepository
+	volatilityIndexRepo *repositories.VolatilityIndexRepository
+	leaseRepo           *repositories.LeaseRepository
+
+	// the node holding the lease
+	owner string
+
+	instruments map[string][]*utils.Instruments
+	refreshed   time.Time
+}
+
+func NewVolatilityService(
+	orderRepo *repositories.OrderRepository,
+	rawPriceRepo *repositories.RawPriceRepository,
+	volatilityIndexRepo *repositories.VolatilityIndexRepository,
+	leaseRepo *repositories.LeaseRepository,
+) IVolatilityService {
+	return &volatilityService{
+		orderRepo:           orderRepo,
+		rawPriceRepo:        rawPriceRepo,
+		volatilityIndexRepo: volatilityIndexRepo,
+		leaseRepo:           leaseRepo,
+		owner:               primitive.NewObjectID().Hex(),
+		instruments:         make(map[string][]*utils.Instruments),
+	}
+}
+
+// retention returns how long the samples are kept, VOLATILITY_INDEX_RETENTION in days
+func retention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("VOLATILITY_INDEX_RETENTION"))
+	if err != nil || days < 1 {
+		days = 365
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Run samples the volatility index of every underlying once per interval from the best
+// bid and ask of its options, on the node holding the lease
+func (svc *volatilityService) Run() {
+	if err := svc.volatilityIndexRepo.EnsureCollection(retention()); err != nil {
+		logs.Log.Error().Err(err).Msg("failed to create the volatility index collection")
+		return
+	}
+
+	ticker := time.NewTicker(volatilityIndexInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if !svc.leaseRepo.Acquire(volatilityIndexLease, svc.owner, now, now.Add(volatilityIndexLeaseTTL)) {
+			continue
+		}
+
+		if now.Sub(svc.refreshed) >= instrumentsRefresh {
+			svc.refresh(now)
+		}
+
+		samples := []*_tradeType.VolatilityIndex{}
+		for underlying, instruments := range svc.instruments {
+			if vol, ok := svc.sample(underlying, instruments, now); ok {
+				samples = append(samples, &_tradeType.VolatilityIndex{
+					Ts:       now,
+					Currency: underlying,
+					Value:    vol * 100,
+				})
+			}
+		}
+
+		if err := svc.volatilityIndexRepo.Insert(samples); err != nil {
+			logs.Log.Error().Err(err).Msg("failed to write the volatility index")
+		}
+	}
+}
+
+func (svc *volatilityService) refresh(now time.Time) {
+	underlyings, err := svc.orderRepo.GetUnderlyings()
+	if err != nil {
+		return
+	}
+
+	instruments := make(map[string][]*utils.Instruments, len(underlyings))
+	for _, underlying := range underlyings {
+		i, err := svc.orderRepo.GetCurrencyInstruments(underlying)
+		if err != nil {
+			return
+		}
+		instruments[underlying] = i
+	}
+
+	svc.instruments = instruments
+	svc.refreshed = now
+}
+
+// sample returns the volatility at the index maturity of the at the money volatilities
+// of the active expiries of the underlying
+func (svc *volatilityService) sample(underlying string, instruments []*utils.Instruments, now time.Time) (float64, bool) {
+	indexPrice := svc.rawPriceRepo.GetLatestIndexPrice(_orderbookType.GetOrderBook{Underlying: underlying})
+	if len(indexPrice) == 0 {
+		return 0, false
+	}
+
+	quotes := make(map[string][]pricing.Quote)
+	for _, instrument := range instruments {
+		if _, _, err := utils.ParseExpiry(underlying, instrument.ExpDate, true); err != nil {
+			continue
+		}
+
+		option, err := pricing.NewOption(underlying, instrument.ExpDate, instrument.Strike, instrument.Contracts == types.CALL)
+		if err != nil {
+			continue
+		}
+
+		bid, ask := book.BestPrices(_orderbookType.GetOrderBook{
+			InstrumentName: instrument.Name(),
+			Underlying:     instrument.Underlying,
+			ExpiryDate:     instrument.ExpDate,
+			StrikePrice:    instrument.Strike,
+		})
+		quotes[instrument.ExpDate] = append(quotes[instrument.ExpDate], pricing.Quote{
+			Option: option,
+			Bid:    bid,
+			Ask:    ask,
+		})
+	}
+
+	terms := []pricing.TermVol{}
+	for _, q := range quotes {
+		smile := pricing.NewSmile(q, indexPrice[0].Price, now)
+		if vol, ok := smile.ATM(); ok {
+			terms = append(terms, pricing.TermVol{
+				Years: smile.Expiry.Sub(now).Hours() / 24 / 365,
+				Vol:   vol,
+			})
+		}
+	}
+
+	return pricing.ConstantMaturityVol(terms, volatilityIndexYears)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	deribitModel "gateway/internal/deribit/model"
+	wsService "gateway/internal/ws/service"
 	"gateway/pkg/constant"
 	"gateway/pkg/middleware"
 	"gateway/pkg/protocol"
@@ -99,8 +100,15 @@ func (svc *wsHandler) buy(input interface{}, c *ws.Client) {
 		MaxShow:        *msg.Params.MaxShow,
 		PostOnly:       msg.Params.PostOnly,
 		ReduceOnly:     msg.Params.ReduceOnly,
+		Trigger:        msg.Params.Trigger,
+		TriggerPrice:   msg.Params.TriggerPrice,
+		TriggerOffset:  msg.Params.TriggerOffset,
+		ExpireAt:       msg.Params.ExpireAt,
 		EnableCancel:   enableCancel,
 		ConnectionId:   connId,
+
+		LinkedOrderType: msg.Params.LinkedOrderType,
+		OtocoConfig:     msg.Params.OtocoConfig,
 	})
 	if err != nil {
 		if validation != nil {
@@ -168,8 +176,15 @@ func (svc *wsHandler) sell(input interface{}, c *ws.Client) {
 		MaxShow:        *msg.Params.MaxShow,
 		PostOnly:       msg.Params.PostOnly,
 		ReduceOnly:     msg.Params.ReduceOnly,
+		Trigger:        msg.Params.Trigger,
+		TriggerPrice:   msg.Params.TriggerPrice,
+		TriggerOffset:  msg.Params.TriggerOffset,
+		ExpireAt:       msg.Params.ExpireAt,
 		EnableCancel:   enableCancel,
 		ConnectionId:   connId,
+
+		LinkedOrderType: msg.Params.LinkedOrderType,
+		OtocoConfig:     msg.Params.OtocoConfig,
 	})
 	if err != nil {
 		if validation != nil {
@@ -696,13 +711,16 @@ func (svc *wsHandler) privateSubscribe(input interface{}, c *ws.Client) {
 			return
 		}
 
-		if len(s) > 2 {
+		// user.{method}.{kind}.{currency}.{interval}
+		if len(s) == 5 {
+			_, err = wsService.ParseScope(s[2], s[3])
+		} else if len(s) > 2 {
 			_, err = utils.ParseInstruments(s[2], true)
-			if err != nil {
-				protocol.SendValidationMsg(connKey,
-					validation_reason.INVALID_PARAMS, err)
-				return
-			}
+		}
+		if err != nil {
+			protocol.SendValidationMsg(connKey,
+				validation_reason.INVALID_PARAMS, err)
+			return
 		}
 
 		val, ok := method[s[1]]
@@ -720,7 +738,7 @@ func (svc *wsHandler) privateSubscribe(input interface{}, c *ws.Client) {
 					validation_reason.INVALID_PARAMS, err)
 				return
 			}
-			if _, ok := interval[s[3]]; !ok {
+			if _, ok := interval[s[len(s)-1]]; !ok {
 				err := errors.New("error invalid interval")
 				protocol.SendValidationMsg(connKey,
 					validation_reason.INVALID_PARAMS, err)
@@ -734,7 +752,7 @@ func (svc *wsHandler) privateSubscribe(input interface{}, c *ws.Client) {
 
 	for _, channel := range validChannels {
 		s := strings.Split(channel, ".")
-		if len(s) != 4 {
+		if len(s) != 4 && len(s) != 5 {
 			reason := validation_reason.INVALID_PARAMS
 			err := fmt.Errorf("unrecognize channel for '%s'", channel)
 			protocol.SendValidationMsg(connKey, reason, err)
@@ -923,6 +941,7 @@ func (svc *wsHandler) getOrderBook(input interface{}, c *ws.Client) {
 	result := svc.wsOBSvc.GetOrderBook(context.TODO(), deribitModel.DeribitGetOrderBookRequest{
 		InstrumentName: msg.Params.InstrumentName,
 		Depth:          msg.Params.Depth,
+		Interval:       msg.Params.Interval,
 		UserId:         claim.UserID,
 	})
 
@@ -7,6 +7,7 @@ import (
 	deribitModel "gateway/internal/deribit/model"
 	authService "gateway/internal/user/service"
 	userType "gateway/internal/user/types"
+	wsService "gateway/internal/ws/service"
 	"gateway/pkg/constant"
 	"gateway/pkg/hmac"
 	"gateway/pkg/middleware"
@@ -33,6 +34,10 @@ func (handler *wsHandler) RegisterPublic() {
 	ws.RegisterChannel("public/get_last_trades_by_instrument", middleware.MiddlewaresWrapper(handler.getLastTradesByInstrument, middleware.RateLimiterWs))
 	ws.RegisterChannel("public/get_index_price", middleware.MiddlewaresWrapper(handler.getIndexPrice, middleware.RateLimiterWs))
 	ws.RegisterChannel("public/get_delivery_prices", middleware.MiddlewaresWrapper(handler.getDeliveryPrices, middleware.RateLimiterWs))
+	ws.RegisterChannel("public/get_option_chain", middleware.MiddlewaresWrapper(handler.getOptionChain, middleware.RateLimiterWs))
+	ws.RegisterChannel("public/get_volatility_surface", middleware.MiddlewaresWrapper(handler.getVolatilitySurface, middleware.RateLimiterWs))
+	ws.RegisterChannel("public/get_historical_volatility", middleware.MiddlewaresWrapper(handler.getHistoricalVolatility, middleware.RateLimiterWs))
+	ws.RegisterChannel("public/get_volatility_index_data", middleware.MiddlewaresWrapper(handler.getVolatilityIndexData, middleware.RateLimiterWs))
 	ws.RegisterChannel("public/set_heartbeat", middleware.MiddlewaresWrapper(handler.setHeartbeat, middleware.RateLimiterWs))
 	ws.RegisterChannel("public/test", middleware.MiddlewaresWrapper(handler.test, middleware.RateLimiterWs))
 	ws.RegisterChannel("public/get_time", middleware.MiddlewaresWrapper(handler.publicGetTime, middleware.RateLimiterWs))
@@ -147,7 +152,7 @@ func (svc *wsHandler) auth(input interface{}, c *ws.Client) {
 			return
 		}
 
-		claim, err := authService.ClaimJWT(c, msg.Params.RefreshToken)
+		claim, err := authService.ClaimJWT(nil, msg.Params.RefreshToken)
 		if err != nil {
 			protocol.SendValidationMsg(connKey, validation_reason.UNAUTHORIZED, err)
 			return
@@ -158,9 +163,18 @@ func (svc *wsHandler) auth(input interface{}, c *ws.Client) {
 			protocol.SendErrMsg(connKey, err)
 			return
 		}
+	default:
+		protocol.SendValidationMsg(connKey,
+			validation_reason.INVALID_PARAMS, errors.New("invalid grant_type"))
+		return
 	}
 
-	c.RegisterAuthedConnection(user.ID.Hex())
+	// bind the connection to the user, private requests may omit the access_token
+	expiresIn := time.Duration(0)
+	if authRes, ok := res.(*userType.AuthResponse); ok {
+		expiresIn = time.Duration(authRes.ExpiresIn) * time.Second
+	}
+	c.RegisterAuthedConnection(user.ID.Hex(), expiresIn)
 
 	protocol.SendSuccessMsg(connKey, res)
 }
@@ -186,7 +200,7 @@ func (svc *wsHandler) publicSubscribe(input interface{}, c *ws.Client) {
 	go protocol.TimeOutProtocol(connKey)
 
 	const t = true
-	method := map[string]bool{"orderbook": t, "order": t, "trade": t, "trades": t, "quote": false, "book": t, "deribit_price_index": false, "ticker": t}
+	method := map[string]bool{"orderbook": t, "order": t, "trade": t, "trades": t, "quote": false, "book": t, "deribit_price_index": false, "ticker": t, "chart": false, "option_chain": false, "volatility_surface": false, "instrument": false}
 	interval := map[string]bool{"raw": t, "100ms": t, "agg2": t}
 	validChannels := []string{}
 	for _, channel := range msg.Params.Channels {
@@ -208,6 +222,70 @@ func (svc *wsHandler) publicSubscribe(input interface{}, c *ws.Client) {
 					validation_reason.INVALID_PARAMS, err)
 				return
 			}
+		} else if s[0] == "chart" {
+			// chart.trades.{instrument}.{resolution}
+			if len(s) != 4 || s[1] != "trades" {
+				err := errors.New(constant.INVALID_CHANNEL)
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+			if _, err := utils.ParseInstruments(s[2], true); err != nil {
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+			if _, err := wsService.ParseChartResolution(s[3]); err != nil {
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+		} else if s[0] == "volatility_surface" {
+			// volatility_surface.{currency}
+			if len(s) != 2 {
+				err := errors.New(constant.INVALID_CHANNEL)
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+			if _, err := utils.ParseCurrency(s[1]); err != nil {
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+		} else if s[0] == "instrument" {
+			// instrument.state.{kind}.{currency}
+			if len(s) != 4 || s[1] != "state" {
+				err := errors.New(constant.INVALID_CHANNEL)
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+			if _, err := wsService.ParseScope(s[2], s[3]); err != nil {
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+		} else if s[0] == "option_chain" {
+			// option_chain.{currency}.{expiry}.{interval}
+			if len(s) != 4 {
+				err := errors.New(constant.INVALID_CHANNEL)
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+			if _, _, _, err := wsService.ParseOptionChain(s[1], s[2], s[3]); err != nil {
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+		} else if s[0] == "trades" && len(s) == 4 {
+			// trades.{kind}.{currency}.{interval}
+			if _, err := wsService.ParseScope(s[1], s[2]); err != nil {
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
 		} else {
 			if len(s) > 1 {
 				_, err = utils.ParseInstruments(s[1], true)
@@ -227,6 +305,17 @@ func (svc *wsHandler) publicSubscribe(input interface{}, c *ws.Client) {
 			return
 		}
 
+		// book.{instrument}.{group}.{depth}.{interval}
+		if s[0] == "book" && len(s) == 5 {
+			if _, _, _, err := wsService.ParseBookGroup(s[2], s[3], s[4]); err != nil {
+				protocol.SendValidationMsg(connKey,
+					validation_reason.INVALID_PARAMS, err)
+				return
+			}
+			validChannels = append(validChannels, channel)
+			continue
+		}
+
 		if val {
 			if len(s) < 3 {
 				err := errors.New(constant.INVALID_INTERVAL)
@@ -234,7 +323,7 @@ func (svc *wsHandler) publicSubscribe(input interface{}, c *ws.Client) {
 					validation_reason.INVALID_PARAMS, err)
 				return
 			}
-			if _, ok := interval[s[2]]; !ok {
+			if _, ok := interval[s[len(s)-1]]; !ok {
 				err := errors.New(constant.INVALID_INTERVAL)
 				protocol.SendValidationMsg(connKey,
 					validation_reason.INVALID_PARAMS, err)
@@ -249,7 +338,7 @@ func (svc *wsHandler) publicSubscribe(input interface{}, c *ws.Client) {
 	for _, channel := range validChannels {
 		s := strings.Split(channel, ".")
 
-		if (s[0] == "trades" || s[0] == "book") && len(s) != 3 {
+		if (s[0] == "trades" && len(s) != 3 && len(s) != 4) || (s[0] == "book" && len(s) != 3 && len(s) != 5) {
 			reason := validation_reason.INVALID_PARAMS
 			err := fmt.Errorf("unrecognize channel for '%s'", channel)
 			protocol.SendValidationMsg(connKey, reason, err)
@@ -259,14 +348,26 @@ func (svc *wsHandler) publicSubscribe(input interface{}, c *ws.Client) {
 		switch s[0] {
 		case "trades":
 			svc.wsTradeSvc.SubscribeTrades(c, channel)
+		case "chart":
+			svc.wsTradeSvc.SubscribeChart(c, channel, s[2], s[3])
 		case "quote":
 			svc.wsOBSvc.SubscribeQuote(c, s[1])
 		case "book":
-			svc.wsOBSvc.SubscribeBook(c, channel, s[1], s[2])
+			if len(s) == 5 {
+				svc.wsOBSvc.SubscribeBookGroup(c, channel, s[1], s[2], s[3], s[4])
+			} else {
+				svc.wsOBSvc.SubscribeBook(c, channel, s[1], s[2])
+			}
 		case "deribit_price_index":
 			svc.wsRawPriceSvc.Subscribe(c, s[1])
 		case "ticker":
 			svc.wsOBSvc.SubscribeTicker(c, channel, s[1], s[2])
+		case "option_chain":
+			svc.wsOBSvc.SubscribeOptionChain(c, channel, s[1], s[2], s[3])
+		case "volatility_surface":
+			svc.wsOBSvc.SubscribeVolatilitySurface(c, channel, s[1])
+		case "instrument":
+			svc.wsOBSvc.SubscribeInstrumentState(c, channel, s[2], s[3])
 		default:
 			reason := validation_reason.INVALID_PARAMS
 			err := fmt.Errorf("unrecognize channel for '%s'", channel)
@@ -305,6 +406,16 @@ func (svc *wsHandler) publicUnsubscribe(input interface{}, c *ws.Client) {
 			svc.wsOBSvc.UnsubscribeQuote(c)
 		case "book":
 			svc.wsOBSvc.UnsubscribeBook(c)
+		case "chart":
+			svc.wsTradeSvc.UnsubscribeChart(c, channel)
+		case "option_chain":
+			svc.wsOBSvc.UnsubscribeOptionChain(c, channel)
+		case "volatility_surface":
+			svc.wsOBSvc.UnsubscribeVolatilitySurface(c, channel)
+		case "instrument":
+			if len(s) == 4 {
+				svc.wsOBSvc.UnsubscribeInstrumentState(c, s[2], s[3])
+			}
 		default:
 			reason := validation_reason.INVALID_PARAMS
 			err := fmt.Errorf("unrecognize channel for '%s'", channel)
@@ -482,6 +593,181 @@ func (svc *wsHandler) getDeliveryPrices(input interface{}, c *ws.Client) {
 	protocol.SendSuccessMsg(connKey, result)
 }
 
+// getOptionChain asyncApi
+// @summary Retrieve option chain
+// @description Retrieves the call and put quotes of every strike of an expiry.
+// @payload model.GetOptionChainParams
+// @x-response model.GetOptionChainResponse
+// @contentType application/json
+// @auth public
+// @queue public.get_option_chain
+// @method get_option_chain
+// @tags public option_chain
+func (svc *wsHandler) getOptionChain(input interface{}, c *ws.Client) {
+	var msg deribitModel.RequestDto[deribitModel.GetOptionChainParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		c.SendInvalidRequestMessage(err)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, nil, c)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+		} else {
+			c.SendInvalidRequestMessage(err)
+		}
+		return
+	}
+	// Add timeout
+	go protocol.TimeOutProtocol(connKey)
+
+	if _, _, err := utils.ParseExpiry(msg.Params.Currency, msg.Params.Expiry, false); err != nil {
+		protocol.SendValidationMsg(connKey,
+			validation_reason.INVALID_PARAMS, err)
+		return
+	}
+
+	result := svc.wsOBSvc.GetOptionChain(context.TODO(), deribitModel.GetOptionChainRequest{
+		Currency: msg.Params.Currency,
+		Expiry:   msg.Params.Expiry,
+	})
+
+	protocol.SendSuccessMsg(connKey, result)
+}
+
+// getVolatilitySurface asyncApi
+// @summary Retrieve volatility surface
+// @description Retrieves the implied volatilities of the active expiries of a currency by strike and by delta bucket.
+// @payload model.GetVolatilitySurfaceParams
+// @x-response model.GetVolatilitySurfaceResponse
+// @contentType application/json
+// @auth public
+// @queue public.get_volatility_surface
+// @method get_volatility_surface
+// @tags public volatility_surface
+func (svc *wsHandler) getVolatilitySurface(input interface{}, c *ws.Client) {
+	var msg deribitModel.RequestDto[deribitModel.GetVolatilitySurfaceParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		c.SendInvalidRequestMessage(err)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, nil, c)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+		} else {
+			c.SendInvalidRequestMessage(err)
+		}
+		return
+	}
+	// Add timeout
+	go protocol.TimeOutProtocol(connKey)
+
+	if _, err := utils.ParseCurrency(msg.Params.Currency); err != nil {
+		protocol.SendValidationMsg(connKey,
+			validation_reason.INVALID_PARAMS, err)
+		return
+	}
+
+	result := svc.wsOBSvc.GetVolatilitySurface(context.TODO(), deribitModel.GetVolatilitySurfaceRequest{
+		Currency: msg.Params.Currency,
+	})
+
+	protocol.SendSuccessMsg(connKey, result)
+}
+
+// getHistoricalVolatility asyncApi
+// @summary Retrieve historical volatility
+// @description Provides information about historical volatility for given cryptocurrency.
+// @payload model.GetHistoricalVolatilityParams
+// @x-response [][2]float64
+// @contentType application/json
+// @auth public
+// @queue public.get_historical_volatility
+// @method get_historical_volatility
+// @tags public volatility
+func (svc *wsHandler) getHistoricalVolatility(input interface{}, c *ws.Client) {
+	var msg deribitModel.RequestDto[deribitModel.GetHistoricalVolatilityParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		c.SendInvalidRequestMessage(err)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, nil, c)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+		} else {
+			c.SendInvalidRequestMessage(err)
+		}
+		return
+	}
+	// Add timeout
+	go protocol.TimeOutProtocol(connKey)
+
+	if _, err := utils.ParseCurrency(msg.Params.Currency); err != nil {
+		protocol.SendValidationMsg(connKey,
+			validation_reason.INVALID_PARAMS, err)
+		return
+	}
+
+	result := svc.deribitSvc.GetHistoricalVolatility(context.TODO(), deribitModel.GetHistoricalVolatilityRequest{
+		Currency: msg.Params.Currency,
+	})
+
+	protocol.SendSuccessMsg(connKey, result)
+}
+
+// getVolatilityIndexData asyncApi
+// @summary Retrieve volatility index data
+// @description Public market data request for volatility index candles.
+// @payload model.GetVolatilityIndexDataParams
+// @x-response model.GetVolatilityIndexDataResponse
+// @contentType application/json
+// @auth public
+// @queue public.get_volatility_index_data
+// @method get_volatility_index_data
+// @tags public volatility
+func (svc *wsHandler) getVolatilityIndexData(input interface{}, c *ws.Client) {
+	var msg deribitModel.RequestDto[deribitModel.GetVolatilityIndexDataParams]
+	if err := utils.UnmarshalAndValidateWS(input, &msg); err != nil {
+		c.SendInvalidRequestMessage(err)
+		return
+	}
+
+	_, connKey, reason, err := requestHelper(msg.Id, msg.Method, nil, c)
+	if err != nil {
+		if connKey != "" {
+			protocol.SendValidationMsg(connKey, *reason, err)
+		} else {
+			c.SendInvalidRequestMessage(err)
+		}
+		return
+	}
+	// Add timeout
+	go protocol.TimeOutProtocol(connKey)
+
+	result, reason, err := svc.deribitSvc.GetVolatilityIndexData(context.TODO(), deribitModel.GetVolatilityIndexDataRequest{
+		Currency:       msg.Params.Currency,
+		StartTimestamp: msg.Params.StartTimestamp,
+		EndTimestamp:   msg.Params.EndTimestamp,
+		Resolution:     msg.Params.Resolution,
+	})
+	if err != nil {
+		if reason != nil {
+			protocol.SendValidationMsg(connKey, *reason, err)
+			return
+		}
+
+		protocol.SendErrMsg(connKey, err)
+		return
+	}
+
+	protocol.SendSuccessMsg(connKey, result)
+}
+
 // setHeartbeat asyncApi
 // @summary Retrieve heartbeats signal
 // @description Signals the Websocket connection to send and request heartbeats.
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gateway/internal/orderbook/book"
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/pkg/constant"
+	"gateway/pkg/utils"
+	"gateway/pkg/ws"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+// the notifications of a grouped channel are snapshots sent once per interval
+var bookGroupIntervals = map[string]time.Duration{
+	"100ms": 100 * time.Millisecond,
+	"agg2":  time.Second,
+}
+
+var bookGroupDepths = map[string]int{"1": 1, "10": 10, "20": 20}
+
+// the grouped channels with a publisher running on this node
+var bookGroups = make(map[string]bool)
+var bookGroupsMutex sync.Mutex
+
+type bookGroup struct {
+	instrument string
+	group      float64
+	depth      int
+	interval   time.Duration
+}
+
+// ParseBookGroup validates the group, depth and interval of a
+// book.{instrument}.{group}.{depth}.{interval} channel, the group is "none" or a price step
+func ParseBookGroup(group, depth, interval string) (float64, int, time.Duration, error) {
+	var step float64
+	if group != "none" {
+		var err error
+		if step, err = strconv.ParseFloat(group, 64); err != nil || step <= 0 {
+			return 0, 0, 0, errors.New(constant.INVALID_GROUP)
+		}
+	}
+
+	d, ok := bookGroupDepths[depth]
+	if !ok {
+		return 0, 0, 0, errors.New(constant.INVALID_DEPTH)
+	}
+
+	i, ok := bookGroupIntervals[interval]
+	if !ok {
+		return 0, 0, 0, errors.New(constant.INVALID_INTERVAL)
+	}
+
+	return step, d, i, nil
+}
+
+func (svc wsOrderbookService) SubscribeBookGroup(c *ws.Client, channel, instrument, group, depth, interval string) {
+	socket := ws.GetBookSocket()
+	if _, err := utils.ParseInstruments(instrument, false); err != nil {
+		msg := map[string]string{"Message": fmt.Sprintf("invalid instrument '%s'", instrument)}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	step, d, i, err := ParseBookGroup(group, depth, interval)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Subscribe
+	id := channel
+	err = socket.Subscribe(id, c)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Prepare when user is doing unsubscribe
+	ws.RegisterConnectionUnsubscribeHandler(c, socket.UnsubscribeHandler(id))
+
+	g := bookGroup{instrument, step, d, i}
+
+	// Send initial data
+	data, err := svc.bookGroupData(g)
+	if err != nil {
+		logs.Log.Error().Err(err).Str("channel", channel).Msg("failed to get grouped book")
+	} else {
+		params := _orderbookTypes.QuoteResponse{
+			Channel: channel,
+			Data:    data,
+		}
+		socket.SendInitMessage(c, "subscription", params)
+	}
+
+	bookGroupsMutex.Lock()
+	defer bookGroupsMutex.Unlock()
+
+	if !bookGroups[id] {
+		bookGroups[id] = true
+		go svc.publishBookGroup(id, g)
+	}
+}
+
+func (svc wsOrderbookService) bookGroupData(g bookGroup) (_orderbookTypes.BookGroupData, error) {
+	snapshot, err := book.GetSnapshot(svc.redis, g.instrument, "raw")
+	if err != nil {
+		return _orderbookTypes.BookGroupData{}, err
+	}
+
+	bids, asks := book.Grouped(snapshot.Book, g.group, g.depth)
+
+	return _orderbookTypes.BookGroupData{
+		Timestamp:      time.Now().UnixNano() / int64(time.Millisecond),
+		InstrumentName: g.instrument,
+		ChangeId:       snapshot.ChangeId,
+		Bids:           bids,
+		Asks:           asks,
+	}, nil
+}
+
+// publishBookGroup sends the snapshot of the channel on every interval, the clients read
+// it as a heartbeat. Every node publishes to its own connections from its book
+func (svc wsOrderbookService) publishBookGroup(id string, g bookGroup) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	socket := ws.GetBookSocket()
+	for range ticker.C {
+		bookGroupsMutex.Lock()
+		if !socket.HasSubscriptions(id) {
+			delete(bookGroups, id)
+			bookGroupsMutex.Unlock()
+			return
+		}
+		bookGroupsMutex.Unlock()
+
+		data, err := svc.bookGroupData(g)
+		if err != nil {
+			logs.Log.Error().Err(err).Str("channel", id).Msg("failed to get grouped book")
+			continue
+		}
+
+		params := _orderbookTypes.QuoteResponse{
+			Channel: id,
+			Data:    data,
+		}
+		socket.BroadcastLocalMessage(id, "subscription", params)
+	}
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	_engineType "gateway/internal/engine/types"
+	_types "gateway/internal/orderbook/types"
+	"gateway/pkg/candle"
+	"gateway/pkg/constant"
+	"gateway/pkg/fanout"
+	"gateway/pkg/utils"
+	"gateway/pkg/ws"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+// the trades of the partitions a node consumes are sent to every node, a node keeps the
+// candles of the channels its connections are subscribed to
+const fanoutChartTrades = "chart.trades"
+
+// the candles in progress of the channels subscribed on this node, by chart channel
+var chartCandles = make(map[string]*chartCandle)
+var chartCandlesMutex sync.Mutex
+var chartCloser sync.Once
+
+type chartCandle struct {
+	instrument string
+	resolution time.Duration
+	candle     candle.Candle
+
+	// closed once loaded, the trades consumed while it loads are kept in pending
+	ready   chan struct{}
+	loaded  bool
+	err     error
+	pending []candle.Trade
+
+	// the trades before are in the candle loaded from mongo
+	since time.Time
+}
+
+type chartTradesMessage struct {
+	Instrument string         `json:"instrument"`
+	Trades     []candle.Trade `json:"trades"`
+}
+
+func init() {
+	fanout.Register(fanoutChartTrades, func(payload json.RawMessage) {
+		var msg chartTradesMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			logs.Log.Error().Err(err).Msg("failed to decode fanout chart trades")
+			return
+		}
+
+		applyChartTrades(msg.Instrument, msg.Trades)
+	})
+}
+
+func chartChannel(instrument, resolution string) string {
+	return fmt.Sprintf("chart.trades.%s.%s", instrument, resolution)
+}
+
+// ParseChartResolution validates the resolution of a chart.trades.{instrument}.{resolution} channel
+func ParseChartResolution(resolution string) (time.Duration, error) {
+	res, ok := candle.Resolutions[resolution]
+	if !ok {
+		return 0, errors.New(constant.INVALID_RESOLUTION)
+	}
+
+	return res, nil
+}
+
+// currentCandle returns the candle in progress from the trades saved before now, an
+// empty candle carries the close of the previous one
+func (svc wsTradeService) currentCandle(instrument string, resolution time.Duration, now time.Time) (candle.Candle, error) {
+	ins, err := utils.ParseInstruments(instrument, false)
+	if err != nil {
+		return candle.Candle{}, err
+	}
+
+	start := candle.Start(now, resolution).Add(-resolution)
+
+	trades, err := svc.repo.GetChartTrades(ins, start, now, nil)
+	if err != nil {
+		return candle.Candle{}, err
+	}
+
+	candles := candle.Build(trades, start, now, resolution)
+
+	return candles[len(candles)-1], nil
+}
+
+// chartCandle returns the candle in progress of the channel, it is loaded from mongo by
+// the first subscription of the node and then kept up to date with the trades
+func (svc wsTradeService) chartCandle(channel, instrument string, resolution time.Duration) (candle.Candle, error) {
+	chartCloser.Do(func() { go closeChartCandles() })
+
+	chartCandlesMutex.Lock()
+	cc, ok := chartCandles[channel]
+	if !ok {
+		cc = &chartCandle{instrument: instrument, resolution: resolution, ready: make(chan struct{})}
+		chartCandles[channel] = cc
+	}
+	chartCandlesMutex.Unlock()
+
+	if ok {
+		<-cc.ready
+
+		chartCandlesMutex.Lock()
+		defer chartCandlesMutex.Unlock()
+
+		return cc.candle, cc.err
+	}
+
+	since := time.Now()
+	current, err := svc.currentCandle(instrument, resolution, since)
+
+	chartCandlesMutex.Lock()
+	defer chartCandlesMutex.Unlock()
+	defer close(cc.ready)
+
+	if err != nil {
+		// the next subscription loads it again
+		delete(chartCandles, channel)
+		cc.err = err
+		return candle.Candle{}, err
+	}
+
+	cc.candle = current
+	cc.since = since
+	cc.loaded = true
+	cc.add(cc.pending)
+	cc.pending = nil
+
+	return cc.candle, nil
+}
+
+// SubscribeChart asyncApi
+// @summary Notification of the candle in progress
+// @description Get the OHLCV candle in progress of the instrument, sent on every trade and when a new candle opens.
+// @payload model.SubscribeChannelParameters
+// @x-response model.SubscribeChannelResponse
+// @contentType application/json
+// @auth public
+// @queue chart.trades.{instrument_name}.{resolution}
+// @method chart.trades.instrument_name.resolution
+// @tags public subscribe chart
+// @operation subscribe
+func (svc wsTradeService) SubscribeChart(c *ws.Client, channel, instrument, resolution string) {
+	socket := ws.GetTradeSocket()
+
+	res, err := ParseChartResolution(resolution)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Subscribe
+	id := channel
+	err = socket.Subscribe(id, c)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Prepare when user is doing unsubscribe
+	ws.RegisterConnectionUnsubscribeHandler(c, socket.UnsubscribeHandler(id))
+
+	// Send the candle in progress
+	data, err := svc.chartCandle(channel, instrument, res)
+	if err != nil {
+		logs.Log.Error().Err(err).Str("channel", channel).Msg("failed to get candle")
+		return
+	}
+
+	params := _types.QuoteResponse{
+		Channel: channel,
+		Data:    data,
+	}
+	socket.SendUpdateMessageTrade(c, "subscription", params)
+}
+
+func (svc wsTradeService) UnsubscribeChart(c *ws.Client, channel string) {
+	socket := ws.GetTradeSocket()
+	socket.UnsubscribeChannel(channel, c)
+}
+
+// HandleConsumeChartTrades sends the saved trades to the candles in progress of every
+// node
+func (svc wsTradeService) HandleConsumeChartTrades(msg *sarama.ConsumerMessage) {
+	var data _engineType.EngineResponse
+	err := json.Unmarshal(msg.Value, &data)
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("")
+		return
+	}
+
+	if data.Matches == nil || len(data.Matches.Trades) == 0 || len(data.Matches.Trades[0].Contracts) == 0 {
+		return
+	}
+
+	first := data.Matches.Trades[0]
+	_instrument := first.Underlying + "-" + first.ExpiryDate + "-" + fmt.Sprintf("%.0f", first.StrikePrice) + "-" + string(first.Contracts[0])
+
+	trades := []candle.Trade{}
+	for _, trade := range data.Matches.Trades {
+		amount, err := strconv.ParseFloat(trade.Amount, 64)
+		if err != nil {
+			logs.Log.Error().Err(err).Msg("cannot parsed amount")
+			continue
+		}
+		trades = append(trades, candle.Trade{Time: trade.CreatedAt, Price: trade.Price, Amount: amount})
+	}
+
+	fanout.Publish(fanoutChartTrades, chartTradesMessage{Instrument: _instrument, Trades: trades})
+	applyChartTrades(_instrument, trades)
+}
+
+// applyChartTrades updates the candles of the instrument subscribed on this node, the
+// candles of the other resolutions are not loaded
+func applyChartTrades(instrument string, trades []candle.Trade) {
+	for resolution := range candle.Resolutions {
+		channel := chartChannel(instrument, resolution)
+
+		chartCandlesMutex.Lock()
+		cc, ok := chartCandles[channel]
+		if ok && !cc.loaded {
+			cc.pending = append(cc.pending, trades...)
+			ok = false
+		} else if ok {
+			cc.add(trades)
+		}
+		var current candle.Candle
+		if ok {
+			current = cc.candle
+		}
+		chartCandlesMutex.Unlock()
+
+		if !ok {
+			continue
+		}
+
+		params := _types.QuoteResponse{
+			Channel: channel,
+			Data:    current,
+		}
+		ws.GetTradeSocket().BroadcastLocalMessageTrade(channel, "subscription", params)
+	}
+}
+
+// add updates the candle with the trades, a trade after the candle opens the next one
+// and a late trade of a closed candle or of the loaded candle is dropped
+func (cc *chartCandle) add(trades []candle.Trade) {
+	for _, trade := range trades {
+		if trade.Time.Before(cc.since) {
+			continue
+		}
+		if !trade.Time.Before(cc.candle.End(cc.resolution)) {
+			cc.candle = candle.New(candle.Start(trade.Time, cc.resolution), cc.candle.Close)
+		}
+		if trade.Time.Before(time.UnixMilli(cc.candle.Tick)) {
+			continue
+		}
+
+		cc.candle.Add(trade.Price, trade.Amount)
+	}
+}
+
+// closeChartCandles opens the next candle on the resolution boundaries, the candles of
+// expired instruments and of the channels without subscription are dropped
+func closeChartCandles() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		opened := make(map[string]candle.Candle)
+
+		chartCandlesMutex.Lock()
+		for channel, cc := range chartCandles {
+			if !cc.loaded {
+				continue
+			}
+
+			if !ws.GetTradeSocket().HasSubscriptions(channel) {
+				delete(chartCandles, channel)
+				continue
+			}
+
+			if now.Before(cc.candle.End(cc.resolution)) {
+				continue
+			}
+
+			if _, err := utils.ParseInstruments(cc.instrument, true); err != nil {
+				delete(chartCandles, channel)
+				continue
+			}
+
+			cc.candle = candle.New(candle.Start(now, cc.resolution), cc.candle.Close)
+			opened[channel] = cc.candle
+		}
+		chartCandlesMutex.Unlock()
+
+		for channel, c := range opened {
+			params := _types.QuoteResponse{
+				Channel: channel,
+				Data:    c,
+			}
+			ws.GetTradeSocket().BroadcastLocalMessageTrade(channel, "subscription", params)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	_deribitModel "gateway/internal/deribit/model"
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/pkg/ws"
+)
+
+// instrumentStateChannel returns the id of the instrument.state.{kind}.{currency} channel
+// of the scope
+func instrumentStateChannel(scope string) string {
+	return "instrument.state." + scope
+}
+
+func (svc wsOrderbookService) SubscribeInstrumentState(c *ws.Client, channel, kind, currency string) {
+	socket := ws.GetBookSocket()
+	scope, err := ParseScope(kind, currency)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Subscribe
+	id := instrumentStateChannel(scope)
+	err = socket.Subscribe(id, c)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Prepare when user is doing unsubscribe
+	ws.RegisterConnectionUnsubscribeHandler(c, socket.UnsubscribeHandler(id))
+}
+
+func (svc wsOrderbookService) UnsubscribeInstrumentState(c *ws.Client, kind, currency string) {
+	scope, err := ParseScope(kind, currency)
+	if err != nil {
+		return
+	}
+
+	socket := ws.GetBookSocket()
+	socket.UnsubscribeChannel(instrumentStateChannel(scope), c)
+}
+
+// PublishInstrumentState sends the state of the instrument on the instrument.state channels
+// of its currency, the notification reaches the connections of every node
+func (svc wsOrderbookService) PublishInstrumentState(instrument, state string, timestamp int64) {
+	data := _deribitModel.InstrumentStateNotification{
+		InstrumentName: instrument,
+		State:          state,
+		Timestamp:      timestamp,
+	}
+
+	// the first scope is the instrument itself
+	for _, scope := range instrumentScopes(instrument)[1:] {
+		id := instrumentStateChannel(scope)
+		params := _orderbookTypes.QuoteResponse{
+			Channel: id,
+			Data:    data,
+		}
+		ws.GetBookSocket().BroadcastMessage(id, "subscription", params)
+	}
+}
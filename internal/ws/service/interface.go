@@ -17,7 +17,11 @@ type IwsOrderbookService interface {
 	Subscribe(c *ws.Client, instrument string)
 	SubscribeQuote(c *ws.Client, instrument string)
 	SubscribeBook(c *ws.Client, channel, instrument, interval string)
+	SubscribeBookGroup(c *ws.Client, channel, instrument, group, depth, interval string)
 	SubscribeTicker(c *ws.Client, channel, instrument, interval string)
+	SubscribeOptionChain(c *ws.Client, channel, currency, expiry, interval string)
+	SubscribeVolatilitySurface(c *ws.Client, channel, currency string)
+	SubscribeInstrumentState(c *ws.Client, channel, kind, currency string)
 	SubscribeUserChange(c *ws.Client, instrument string, userId string)
 	HandleConsumeUserChange(msg *sarama.ConsumerMessage)
 	HandleConsumeUserChangeCancel(order orderType.Order)
@@ -25,13 +29,17 @@ type IwsOrderbookService interface {
 	Unsubscribe(c *ws.Client)
 	UnsubscribeQuote(c *ws.Client)
 	UnsubscribeBook(c *ws.Client)
+	UnsubscribeOptionChain(c *ws.Client, channel string)
+	UnsubscribeVolatilitySurface(c *ws.Client, channel string)
+	UnsubscribeInstrumentState(c *ws.Client, kind, currency string)
+	PublishInstrumentState(instrument, state string, timestamp int64)
 	GetOrderBook(ctx context.Context, request deribitModel.DeribitGetOrderBookRequest) deribitModel.DeribitGetOrderBookResponse
 	GetLastTradesByInstrument(ctx context.Context, request deribitModel.DeribitGetLastTradesByInstrumentRequest) deribitModel.DeribitGetLastTradesByInstrumentResponse
 	GetIndexPrice(ctx context.Context, request deribitModel.DeribitGetIndexPriceRequest) deribitModel.DeribitGetIndexPriceResponse
-	GetOrderLatestTimestamp(o _orderbookTypes.GetOrderBook, after int64, isFilled bool) _orderbookTypes.Orderbook
-	GetOrderLatestTimestampAgg(o _orderbookTypes.GetOrderBook, after int64) _orderbookTypes.Orderbook
 	GetDataQuote(order _orderbookTypes.GetOrderBook) (_orderbookTypes.QuoteMessage, _orderbookTypes.Orderbook)
 	GetDeliveryPrices(ctx context.Context, request deribitModel.DeliveryPricesRequest) deribitModel.DeliveryPricesResponse
+	GetOptionChain(ctx context.Context, request deribitModel.GetOptionChainRequest) deribitModel.GetOptionChainResponse
+	GetVolatilitySurface(ctx context.Context, request deribitModel.GetVolatilitySurfaceRequest) deribitModel.GetVolatilitySurfaceResponse
 }
 
 type IwsOrderService interface {
@@ -41,6 +49,7 @@ type IwsOrderService interface {
 	HandleConsume(msg *sarama.ConsumerMessage, userId string)
 	HandleConsumeUserOrder(msg *sarama.ConsumerMessage)
 	HandleConsumeUserOrderCancel(msg *sarama.ConsumerMessage)
+	PublishUserOrder(instrument, userId string, order deribitModel.DeribitGetOpenOrdersByInstrumentResponse)
 	GetInstruments(ctx context.Context, request deribitModel.DeribitGetInstrumentsRequest) []deribitModel.DeribitGetInstrumentsResponse
 	GetOpenOrdersByInstrument(ctx context.Context, userId string, request deribitModel.DeribitGetOpenOrdersByInstrumentRequest) []deribitModel.DeribitGetOpenOrdersByInstrumentResponse
 	GetGetOrderHistoryByInstrument(ctx context.Context, userId string, request deribitModel.DeribitGetOrderHistoryByInstrumentRequest) []deribitModel.DeribitGetOrderHistoryByInstrumentResponse
@@ -51,10 +60,13 @@ type IwsTradeService interface {
 	Subscribe(c *ws.Client, instrument string)
 	SubscribeUserTrades(c *ws.Client, instrument string, userId string)
 	SubscribeTrades(c *ws.Client, instrument string)
+	SubscribeChart(c *ws.Client, channel, instrument, resolution string)
 	Unsubscribe(c *ws.Client)
+	UnsubscribeChart(c *ws.Client, channel string)
 	HandleConsume(msg *sarama.ConsumerMessage)
 	HandleConsumeUserTrades(msg *sarama.ConsumerMessage)
 	HandleConsumeInstrumentTrades(msg *sarama.ConsumerMessage)
+	HandleConsumeChartTrades(msg *sarama.ConsumerMessage)
 	GetUserTradesByInstrument(
 		ctx context.Context,
 		userId string,
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	_deribitModel "gateway/internal/deribit/model"
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/pkg/constant"
+	"gateway/pkg/utils"
+	"gateway/pkg/ws"
+)
+
+// the option chain channels with a publisher running on this node
+var optionChains = make(map[string]bool)
+var optionChainsMutex sync.Mutex
+
+// ParseOptionChain validates the currency, expiry and interval of an
+// option_chain.{currency}.{expiry}.{interval} channel, the intervals are the ones of the
+// grouped book channels
+func ParseOptionChain(currency, expiry, interval string) (string, string, time.Duration, error) {
+	underlying, expiryDate, err := utils.ParseExpiry(currency, expiry, true)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	i, ok := bookGroupIntervals[interval]
+	if !ok {
+		return "", "", 0, errors.New(constant.INVALID_INTERVAL)
+	}
+
+	return underlying, expiryDate, i, nil
+}
+
+// GetOptionChain returns the call and put quotes of every strike of the expiry
+func (svc wsOrderbookService) GetOptionChain(ctx context.Context, request _deribitModel.GetOptionChainRequest) _deribitModel.GetOptionChainResponse {
+	return svc.optionsService.GetOptionChain(ctx, request)
+}
+
+func (svc wsOrderbookService) SubscribeOptionChain(c *ws.Client, channel, currency, expiry, interval string) {
+	socket := ws.GetBookSocket()
+	_, _, i, err := ParseOptionChain(currency, expiry, interval)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Subscribe
+	id := channel
+	err = socket.Subscribe(id, c)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Prepare when user is doing unsubscribe
+	ws.RegisterConnectionUnsubscribeHandler(c, socket.UnsubscribeHandler(id))
+
+	request := _deribitModel.GetOptionChainRequest{Currency: currency, Expiry: expiry}
+
+	// Send initial data
+	data := svc.GetOptionChain(context.TODO(), request)
+	params := _orderbookTypes.QuoteResponse{
+		Channel: channel,
+		Data:    data,
+	}
+	socket.SendInitMessage(c, "subscription", params)
+
+	optionChainsMutex.Lock()
+	defer optionChainsMutex.Unlock()
+
+	if !optionChains[id] {
+		optionChains[id] = true
+		go svc.publishOptionChain(id, request, i, data)
+	}
+}
+
+func (svc wsOrderbookService) UnsubscribeOptionChain(c *ws.Client, channel string) {
+	socket := ws.GetBookSocket()
+	socket.UnsubscribeChannel(channel, c)
+}
+
+// publishOptionChain sends the chain of the channel once per interval when it changed,
+// every node publishes to its own connections
+func (svc wsOrderbookService) publishOptionChain(id string, request _deribitModel.GetOptionChainRequest, interval time.Duration, last _deribitModel.GetOptionChainResponse) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	socket := ws.GetBookSocket()
+	for range ticker.C {
+		optionChainsMutex.Lock()
+		if !socket.HasSubscriptions(id) {
+			delete(optionChains, id)
+			optionChainsMutex.Unlock()
+			return
+		}
+		optionChainsMutex.Unlock()
+
+		data := svc.GetOptionChain(context.TODO(), request)
+		if reflect.DeepEqual(data.Strikes, last.Strikes) && reflect.DeepEqual(data.IndexPrice, last.IndexPrice) {
+			continue
+		}
+		last = data
+
+		params := _orderbookTypes.QuoteResponse{
+			Channel: id,
+			Data:    data,
+		}
+		socket.BroadcastLocalMessage(id, "subscription", params)
+	}
+}
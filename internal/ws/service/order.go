@@ -129,26 +129,7 @@ func (svc wsOrderService) HandleConsumeUserOrder(msg *sarama.ConsumerMessage) {
 			keys[_id] = true
 			for _, order := range orders {
 				if _id == order.UserId.Hex() {
-					mapIndex := fmt.Sprintf("%s-%s", _instrument, _id)
-					if _, ok := userOrders[mapIndex]; !ok {
-						order100ms := []deribitModel.DeribitGetOpenOrdersByInstrumentResponse{order}
-						userOrdersMutex.Lock()
-						userOrders[mapIndex] = order100ms
-						userOrdersMutex.Unlock()
-						go svc.HandleConsumeUserOrder100ms(_instrument, _id)
-					} else {
-						userOrdersMutex.Lock()
-						userOrders[mapIndex] = append(userOrders[mapIndex], order)
-						userOrdersMutex.Unlock()
-					}
-					// broadcast to user id
-					broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "orders", _instrument, _id)
-					params := _types.QuoteResponse{
-						Channel: fmt.Sprintf("user.orders.%s.raw", _instrument),
-						Data:    order,
-					}
-					method := "subscription"
-					ws.GetOrderSocket().BroadcastMessageOrder(broadcastId, method, params)
+					svc.PublishUserOrder(_instrument, _id, order)
 				}
 			}
 		}
@@ -186,26 +167,7 @@ func (svc wsOrderService) HandleConsumeUserOrderCancel(msg *sarama.ConsumerMessa
 				keys[id] = true
 				for _, order := range orders {
 					if id == order.UserId.Hex() {
-						mapIndex := fmt.Sprintf("%s-%s", _instrument, id)
-						if _, ok := userOrders[mapIndex]; !ok {
-							order100ms := []deribitModel.DeribitGetOpenOrdersByInstrumentResponse{order}
-							userOrdersMutex.Lock()
-							userOrders[mapIndex] = order100ms
-							userOrdersMutex.Unlock()
-							go svc.HandleConsumeUserOrder100ms(_instrument, id.(string))
-						} else {
-							userOrdersMutex.Lock()
-							userOrders[mapIndex] = append(userOrders[mapIndex], order)
-							userOrdersMutex.Unlock()
-						}
-						// broadcast to user id
-						broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "orders", _instrument, id)
-						params := _types.QuoteResponse{
-							Channel: fmt.Sprintf("user.orders.%s.raw", _instrument),
-							Data:    order,
-						}
-						method := "subscription"
-						ws.GetOrderSocket().BroadcastMessageOrder(broadcastId, method, params)
+						svc.PublishUserOrder(_instrument, id.(string), order)
 					}
 				}
 			}
@@ -213,6 +175,33 @@ func (svc wsOrderService) HandleConsumeUserOrderCancel(msg *sarama.ConsumerMessa
 	}
 }
 
+// PublishUserOrder sends the change of the order of the user on the user.orders channels
+// of the instrument
+func (svc wsOrderService) PublishUserOrder(instrument, userId string, order deribitModel.DeribitGetOpenOrdersByInstrumentResponse) {
+	mapIndex := fmt.Sprintf("%s-%s", instrument, userId)
+	if _, ok := userOrders[mapIndex]; !ok {
+		order100ms := []deribitModel.DeribitGetOpenOrdersByInstrumentResponse{order}
+		userOrdersMutex.Lock()
+		userOrders[mapIndex] = order100ms
+		userOrdersMutex.Unlock()
+		go svc.HandleConsumeUserOrder100ms(instrument, userId)
+	} else {
+		userOrdersMutex.Lock()
+		userOrders[mapIndex] = append(userOrders[mapIndex], order)
+		userOrdersMutex.Unlock()
+	}
+	// broadcast to user id
+	for _, scope := range instrumentScopes(instrument) {
+		broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "orders", scope, userId)
+		params := _types.QuoteResponse{
+			Channel: fmt.Sprintf("user.orders.%s.raw", scope),
+			Data:    order,
+		}
+		method := "subscription"
+		ws.GetOrderSocket().BroadcastMessageOrder(broadcastId, method, params)
+	}
+}
+
 func (svc wsOrderService) HandleConsumeUserOrder100ms(instrument string, userId string) {
 	mapIndex := fmt.Sprintf("%s-%s", instrument, userId)
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -230,13 +219,15 @@ func (svc wsOrderService) HandleConsumeUserOrder100ms(instrument string, userId
 				orders := userOrders[mapIndex]
 				userOrdersMutex.RUnlock()
 				if len(orders) > 0 {
-					broadcastId := fmt.Sprintf("%s.%s.%s-%s-100ms", "user", "orders", instrument, userId)
-					params := _types.QuoteResponse{
-						Channel: fmt.Sprintf("user.orders.%s.100ms", instrument),
-						Data:    orders,
+					for _, scope := range instrumentScopes(instrument) {
+						broadcastId := fmt.Sprintf("%s.%s.%s-%s-100ms", "user", "orders", scope, userId)
+						params := _types.QuoteResponse{
+							Channel: fmt.Sprintf("user.orders.%s.100ms", scope),
+							Data:    orders,
+						}
+						method := "subscription"
+						ws.GetOrderSocket().BroadcastMessageOrder(broadcastId, method, params)
 					}
-					method := "subscription"
-					ws.GetOrderSocket().BroadcastMessageOrder(broadcastId, method, params)
 					userOrdersMutex.Lock()
 					userOrders[mapIndex] = []deribitModel.DeribitGetOpenOrdersByInstrumentResponse{}
 					userOrdersMutex.Unlock()
@@ -305,6 +296,7 @@ func (svc wsOrderService) Subscribe(c *ws.Client, key string) {
 // @contentType application/json
 // @auth private
 // @queue user.orders.{instrument_name}.{interval}
+// @queue user.orders.{kind}.{currency}.{interval}
 // @method user.orders.instrument_name.interval
 // @tags private subscribe orders
 // @operation subscribe
@@ -315,10 +307,10 @@ func (svc wsOrderService) SubscribeUserOrder(c *ws.Client, channel string, userI
 	// Subscribe
 
 	var id string
-	if len(key) > 3 && key[3] == "100ms" {
-		id = fmt.Sprintf("%s.%s.%s-%s-100ms", key[0], key[1], key[2], userId)
+	if scope, interval := channelScope(key, 2); interval == "100ms" {
+		id = fmt.Sprintf("%s.%s.%s-%s-100ms", key[0], key[1], scope, userId)
 	} else {
-		id = fmt.Sprintf("%s.%s.%s-%s", key[0], key[1], key[2], userId)
+		id = fmt.Sprintf("%s.%s.%s-%s", key[0], key[1], scope, userId)
 	}
 
 	logs.Log.Info().Str("subscribe", id).Msg("")
@@ -12,13 +12,16 @@ import (
 
 	_deribitModel "gateway/internal/deribit/model"
 	_engineTypes "gateway/internal/engine/types"
+	_optionsSvc "gateway/internal/options/service"
 	_orderbookTypes "gateway/internal/orderbook/types"
 	_tradeType "gateway/internal/repositories/types"
 
 	orderType "github.com/Undercurrent-Technologies/kprime-utilities/models/order"
 
+	"gateway/internal/orderbook/book"
 	"gateway/internal/repositories"
 	"gateway/pkg/memdb"
+	"gateway/pkg/pricing"
 	"gateway/pkg/redis"
 	"gateway/pkg/utils"
 	"gateway/pkg/ws"
@@ -35,6 +38,7 @@ type wsOrderbookService struct {
 	tradeRepository           *repositories.TradeRepository
 	rawPriceRepository        *repositories.RawPriceRepository
 	settlementPriceRepository *repositories.SettlementPriceRepository
+	optionsService            _optionsSvc.IOptionsService
 }
 
 func NewWSOrderbookService(redis *redis.RedisConnectionPool,
@@ -42,8 +46,9 @@ func NewWSOrderbookService(redis *redis.RedisConnectionPool,
 	tradeRepository *repositories.TradeRepository,
 	rawPriceRepository *repositories.RawPriceRepository,
 	settlementPriceRepository *repositories.SettlementPriceRepository,
+	optionsService _optionsSvc.IOptionsService,
 ) IwsOrderbookService {
-	return &wsOrderbookService{redis, orderRepository, tradeRepository, rawPriceRepository, settlementPriceRepository}
+	return &wsOrderbookService{redis, orderRepository, tradeRepository, rawPriceRepository, settlementPriceRepository, optionsService}
 }
 
 var userChangesMutex sync.RWMutex
@@ -189,15 +194,15 @@ func (svc wsOrderbookService) SubscribeBook(c *ws.Client, channel, instrument, i
 		}
 	}
 
-	// Get initial data from db
+	// Get initial data from the book in memory
 	var orderBook _orderbookTypes.Orderbook
 	switch interval {
 	case "raw":
-		orderBook = svc.GetOrderLatestTimestamp(_order, changeId.Timestamp, false)
+		orderBook = book.Orderbook(_order)
 	case "100ms":
-		orderBook = svc.GetOrderLatestTimestamp(_order, changeId.Timestamp, false)
+		orderBook = book.Orderbook(_order)
 	case "agg2":
-		orderBook = svc.orderRepository.GetOrderBookAgg2(_order)
+		orderBook = book.Aggregated(_order)
 	}
 
 	var bidsData [][]interface{}
@@ -333,6 +338,7 @@ func (svc wsOrderbookService) SubscribeBook(c *ws.Client, channel, instrument, i
 		ChangeId:       changeId.Id,
 		Bids:           bidsData,
 		Asks:           asksData,
+		Checksum:       book.Checksum(orderBook),
 	}
 
 	params := _orderbookTypes.QuoteResponse{
@@ -387,15 +393,15 @@ func (svc wsOrderbookService) SubscribeTicker(c *ws.Client, channel, instrument,
 		}
 	}
 
-	// Get initial data from db
+	// Get initial data from the book in memory
 	var orderBook _orderbookTypes.Orderbook
 	switch interval {
 	case "raw":
-		orderBook = svc.GetOrderLatestTimestamp(_order, changeId.Timestamp, false)
+		orderBook = book.Orderbook(_order)
 	case "100ms":
-		orderBook = svc.GetOrderLatestTimestamp(_order, changeId.Timestamp, false)
+		orderBook = book.Orderbook(_order)
 	case "agg2":
-		orderBook = svc.orderRepository.GetOrderBookAgg2(_order)
+		orderBook = book.Aggregated(_order)
 	}
 
 	dataQuote := svc.GetBestPrice(orderBook, instrument)
@@ -474,18 +480,6 @@ func (svc wsOrderbookService) GetDataOrderBook(_order _orderbookTypes.GetOrderBo
 		_state = "open"
 	}
 
-	//TODO query to get expires time
-	expiredDate := dateString
-	currentDate := time.Now().Format("2006-01-02 15:04:05")
-	layoutExpired := "02Jan06"
-	layoutCurrent := "2006-01-02 15:04:05"
-
-	expired, _ := time.Parse(layoutExpired, expiredDate)
-	current, _ := time.Parse(layoutCurrent, currentDate)
-	calculate := float64(expired.Day()) - float64(current.Day())
-
-	dateValue := float64(calculate / 365)
-
 	//TODO query to trades collections
 	_getLastTrades := svc.tradeRepository.GetLastTrades(_order)
 	_lastPrice := 0.0
@@ -530,28 +524,29 @@ func (svc wsOrderbookService) GetDataOrderBook(_order _orderbookTypes.GetOrderBo
 		underlyingPrice = float64(0)
 	}
 
-	//TODO query to get Option Price
-	str := _order.InstrumentName
-	parts := strings.Split(str, "-")
-	lastPart := parts[len(parts)-1]
-	optionPrice := ""
-	if string(lastPart[0]) == "C" {
-		optionPrice = "call"
-	} else {
-		optionPrice = "put"
+	// the options are priced with black-76 on the forward of the index
+	option, err := pricing.NewOption(_order.Underlying, _order.ExpiryDate, _order.StrikePrice, strings.HasSuffix(_order.InstrumentName, "C"))
+	if err != nil {
+		logs.Log.Error().Err(err).Str("instrument", _order.InstrumentName).Msg("failed to price option")
 	}
+	now := time.Now()
+
+	_getImpliedsAsk := option.ImpliedVol(dataQuote.BestAskPrice, underlyingPrice, now)
+	_getImpliedsBid := option.ImpliedVol(dataQuote.BestBidPrice, underlyingPrice, now)
+	_getImpliedsVolatility := option.ImpliedVol(_lastPrice, underlyingPrice, now)
 
-	//TODO query to get ask_iv and bid_iv
-	_getImpliedsAsk := svc.tradeRepository.GetImpliedVolatility(float64(dataQuote.BestAskAmount), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getImpliedsBid := svc.tradeRepository.GetImpliedVolatility(float64(dataQuote.BestBidAmount), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
+	markData := _orderbookTypes.MarkData{}
+	if dataQuote.BestAskPrice != 0 && dataQuote.BestBidPrice != 0 {
+		markData.MarkPrice = (dataQuote.BestAskPrice + dataQuote.BestBidPrice) / 2
+		markData.MarkIv = option.ImpliedVol(markData.MarkPrice, underlyingPrice, now)
+	}
 
-	//TODO query to get all greeks
-	_getImpliedsVolatility := svc.tradeRepository.GetImpliedVolatility(float64(_lastPrice), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksDelta := svc.tradeRepository.GetGreeks("delta", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksVega := svc.tradeRepository.GetGreeks("vega", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksGamma := svc.tradeRepository.GetGreeks("gamma", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksTetha := svc.tradeRepository.GetGreeks("tetha", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
-	_getGreeksRho := svc.tradeRepository.GetGreeks("rho", float64(_getImpliedsVolatility), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
+	// the greeks are at the mark volatility, at the last price one without a mark
+	vol := markData.MarkIv
+	if vol == 0 {
+		vol = _getImpliedsVolatility
+	}
+	greeks := option.Greeks(underlyingPrice, vol, now)
 
 	value := _orderbookTypes.OrderBookData{
 		State:        _state,
@@ -562,17 +557,11 @@ func (svc wsOrderbookService) GetDataOrderBook(_order _orderbookTypes.GetOrderBo
 		ImpliedAsk:   _getImpliedsAsk,
 		ImpliedBid:   _getImpliedsBid,
 		VolumeAmount: _volumeAmount,
-		GreeksDelta:  _getGreeksDelta,
-		GreeksVega:   _getGreeksVega,
-		GreeksGamma:  _getGreeksGamma,
-		GreeksTetha:  _getGreeksTetha,
-		GreeksRho:    _getGreeksRho,
-	}
-
-	markData := _orderbookTypes.MarkData{}
-	if dataQuote.BestAskPrice != 0 && dataQuote.BestBidPrice != 0 {
-		markData.MarkPrice = (dataQuote.BestAskPrice + dataQuote.BestBidPrice) / 2
-		markData.MarkIv = svc.tradeRepository.GetImpliedVolatility(float64(markData.MarkPrice), optionPrice, float64(underlyingPrice), float64(_order.StrikePrice), float64(dateValue))
+		GreeksDelta:  greeks.Delta,
+		GreeksVega:   greeks.Vega,
+		GreeksGamma:  greeks.Gamma,
+		GreeksTetha:  greeks.Theta,
+		GreeksRho:    greeks.Rho,
 	}
 
 	return value, _getIndexPrice, markData
@@ -581,7 +570,7 @@ func (svc wsOrderbookService) GetDataOrderBook(_order _orderbookTypes.GetOrderBo
 func (svc wsOrderbookService) GetDataQuote(order _orderbookTypes.GetOrderBook) (_orderbookTypes.QuoteMessage, _orderbookTypes.Orderbook) {
 
 	// Get initial data
-	_getOrderBook := svc.orderRepository.GetOrderBook(order)
+	_getOrderBook := book.Orderbook(order)
 
 	//count best Ask
 	maxAskPrice := 0.0
@@ -717,10 +706,10 @@ func (svc wsOrderbookService) SubscribeUserChange(c *ws.Client, channel string,
 	// Subscribe
 
 	var id string
-	if len(key) > 3 && key[3] == "100ms" {
-		id = fmt.Sprintf("%s.%s.%s-%s-100ms", key[0], key[1], key[2], userId)
+	if scope, interval := channelScope(key, 2); interval == "100ms" {
+		id = fmt.Sprintf("%s.%s.%s-%s-100ms", key[0], key[1], scope, userId)
 	} else {
-		id = fmt.Sprintf("%s.%s.%s-%s", key[0], key[1], key[2], userId)
+		id = fmt.Sprintf("%s.%s.%s-%s", key[0], key[1], scope, userId)
 	}
 
 	logs.Log.Info().Str("subscribe", id).Msg("")
@@ -856,14 +845,16 @@ func (svc wsOrderbookService) HandleConsumeUserChange(msg *sarama.ConsumerMessag
 				userChangesMutex.Unlock()
 			}
 			// broadcast to user id
-			broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "changes", _instrument, _id)
+			for _, scope := range instrumentScopes(_instrument) {
+				broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "changes", scope, _id)
 
-			params := _orderbookTypes.QuoteResponse{
-				Channel: fmt.Sprintf("user.changes.%s.raw", _instrument),
-				Data:    response,
+				params := _orderbookTypes.QuoteResponse{
+					Channel: fmt.Sprintf("user.changes.%s.raw", scope),
+					Data:    response,
+				}
+				method := "subscription"
+				ws.GetOrderBookSocket().BroadcastMessageSubcription(broadcastId, method, params)
 			}
-			method := "subscription"
-			ws.GetOrderBookSocket().BroadcastMessageSubcription(broadcastId, method, params)
 		}
 	}
 }
@@ -916,14 +907,16 @@ func (svc wsOrderbookService) HandleConsumeUserChangeCancel(order orderType.Orde
 				userChangesMutex.Unlock()
 			}
 			// broadcast to user id
-			broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "changes", _instrument, id)
+			for _, scope := range instrumentScopes(_instrument) {
+				broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "changes", scope, id)
 
-			params := _orderbookTypes.QuoteResponse{
-				Channel: fmt.Sprintf("user.changes.%s.raw", _instrument),
-				Data:    response,
+				params := _orderbookTypes.QuoteResponse{
+					Channel: fmt.Sprintf("user.changes.%s.raw", scope),
+					Data:    response,
+				}
+				method := "subscription"
+				ws.GetOrderBookSocket().BroadcastMessageSubcription(broadcastId, method, params)
 			}
-			method := "subscription"
-			ws.GetOrderBookSocket().BroadcastMessageSubcription(broadcastId, method, params)
 		}
 	}
 }
@@ -951,13 +944,15 @@ func (svc wsOrderbookService) HandleConsumeUserChange100ms(instrument string, us
 						Trades:         trades,
 						Orders:         changes,
 					}
-					broadcastId := fmt.Sprintf("%s.%s.%s-%s-100ms", "user", "changes", instrument, userId)
-					params := _orderbookTypes.QuoteResponse{
-						Channel: fmt.Sprintf("user.changes.%s.100ms", instrument),
-						Data:    response,
+					for _, scope := range instrumentScopes(instrument) {
+						broadcastId := fmt.Sprintf("%s.%s.%s-%s-100ms", "user", "changes", scope, userId)
+						params := _orderbookTypes.QuoteResponse{
+							Channel: fmt.Sprintf("user.changes.%s.100ms", scope),
+							Data:    response,
+						}
+						method := "subscription"
+						ws.GetOrderBookSocket().BroadcastMessageSubcription(broadcastId, method, params)
 					}
-					method := "subscription"
-					ws.GetOrderBookSocket().BroadcastMessageSubcription(broadcastId, method, params)
 					userChangesMutex.Lock()
 					userChanges[mapIndex] = make([]interface{}, 0)
 					userChangesTrades[mapIndex] = make([]interface{}, 0)
@@ -978,13 +973,12 @@ func (svc wsOrderbookService) HandleConsumeTicker(_instrument string, interval s
 		StrikePrice:    instruments.Strike,
 	}
 
-	ts := time.Now().UnixNano() / int64(time.Millisecond)
-	// Get latest data from db
+	// Get latest data from the book in memory
 	var orderBook _orderbookTypes.Orderbook
 	if interval == "raw" {
-		orderBook = svc.GetOrderLatestTimestamp(_order, ts, false)
+		orderBook = book.Orderbook(_order)
 	} else if interval == "agg2" {
-		orderBook = svc.GetOrderLatestTimestampAgg(_order, ts)
+		orderBook = book.Aggregated(_order)
 	}
 
 	dataQuote := svc.GetBestPrice(orderBook, _instrument)
@@ -1093,9 +1087,8 @@ func (svc wsOrderbookService) HandleConsumeUserTicker100ms(instrument string) {
 						StrikePrice:    instruments.Strike,
 					}
 
-					// Get latest data from db
-					ts := time.Now().UnixNano() / int64(time.Millisecond)
-					orderBook := svc.GetOrderLatestTimestamp(_order, ts, false)
+					// Get latest data from the book in memory
+					orderBook := book.Orderbook(_order)
 
 					dataQuote := svc.GetBestPrice(orderBook, instrument)
 
@@ -1225,6 +1218,19 @@ func (svc wsOrderbookService) GetOrderBook(ctx context.Context, data _deribitMod
 		results.MarkIv = nil
 	}
 
+	// the book of the channel, the client checks its own with the change id and the checksum
+	if data.Interval != "" {
+		snapshot, err := book.GetSnapshot(svc.redis, data.InstrumentName, data.Interval)
+		if err != nil {
+			logs.Log.Error().Err(err).Msg("failed to get order book snapshot")
+		} else {
+			results.Bids = snapshot.Book.Bids
+			results.Asks = snapshot.Book.Asks
+			results.ChangeId = &snapshot.ChangeId
+			results.Checksum = &snapshot.Checksum
+		}
+	}
+
 	if len(indexPrice) > 0 {
 		results.IndexPrice = &indexPrice[0].Price
 		results.UnderlyingPrice = &indexPrice[0].Price
@@ -1279,7 +1285,9 @@ func (svc wsOrderbookService) GetLastTradesByInstrument(ctx context.Context, dat
 		var markIv float64
 
 		if markPrice != 0 {
-			markIv = svc.tradeRepository.GetMarkIv(markPrice, contracts, jsonDoc["expiryDate"].(string), jsonDoc["strikePrice"].(float64), jsonDoc["indexPrice"].(float64))
+			if option, err := pricing.NewOption(underlying, expiryDate, strikePrice, contracts == "C"); err == nil {
+				markIv = option.ImpliedVol(markPrice, jsonDoc["indexPrice"].(float64), jsonDoc["createdAt"].(primitive.DateTime).Time())
+			}
 		}
 
 		tradeObjectId := jsonDoc["_id"].(primitive.ObjectID)
@@ -1315,14 +1323,6 @@ func (svc wsOrderbookService) GetLastTradesByInstrument(ctx context.Context, dat
 	return results
 }
 
-func (svc wsOrderbookService) GetOrderLatestTimestamp(o _orderbookTypes.GetOrderBook, after int64, isFilled bool) _orderbookTypes.Orderbook {
-	return svc.orderRepository.GetOrderLatestTimestamp(o, after, isFilled)
-}
-
-func (svc wsOrderbookService) GetOrderLatestTimestampAgg(o _orderbookTypes.GetOrderBook, after int64) _orderbookTypes.Orderbook {
-	return svc.orderRepository.GetOrderLatestTimestampAgg(o, after)
-}
-
 func (svc wsOrderbookService) GetIndexPrice(ctx context.Context, data _deribitModel.DeribitGetIndexPriceRequest) _deribitModel.DeribitGetIndexPriceResponse {
 	var indexPrice float64
 
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"gateway/pkg/constant"
+
+	confType "github.com/Undercurrent-Technologies/kprime-utilities/config/types"
+)
+
+// the scope of a channel is an instrument name or a {kind}.{currency} pair,
+// "any" matches every kind or every currency
+const anyScope = "any"
+
+// instruments are options only
+var scopeKinds = []string{"option", anyScope}
+
+// ParseScope validates the kind and currency of a currency-wide channel
+// and returns its scope, e.g. option.BTC or any.any
+func ParseScope(kind, currency string) (string, error) {
+	kind = strings.ToLower(kind)
+
+	valid := false
+	for _, k := range scopeKinds {
+		valid = valid || k == kind
+	}
+	if !valid {
+		return "", errors.New(constant.INVALID_KIND)
+	}
+
+	if currency != anyScope {
+		c, ok := confType.Pair(currency).CurrencyCheck()
+		if !ok {
+			return "", errors.New(constant.UNSUPPORTED_CURRENCY)
+		}
+		currency = c
+	}
+
+	return kind + "." + currency, nil
+}
+
+// channelScope returns the scope of a {prefix}.{instrument}.{interval} or
+// {prefix}.{kind}.{currency}.{interval} channel split on dots, start is the index of
+// the first part after the prefix
+func channelScope(key []string, start int) (scope string, interval string) {
+	if len(key) > start+2 {
+		scope, err := ParseScope(key[start], key[start+1])
+		if err == nil {
+			return scope, key[start+2]
+		}
+	}
+
+	if len(key) > start+1 {
+		interval = key[start+1]
+	}
+
+	return key[start], interval
+}
+
+// instrumentScopes returns the scopes the notifications of an instrument are sent to,
+// a 100ms notification of a currency-wide channel holds the changes of one instrument
+func instrumentScopes(instrument string) []string {
+	currency := strings.Split(instrument, "-")[0]
+
+	scopes := []string{instrument}
+	for _, kind := range scopeKinds {
+		scopes = append(scopes, kind+"."+currency, kind+"."+anyScope)
+	}
+
+	return scopes
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+
+	"gateway/pkg/constant"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		kind     string
+		currency string
+		want     string
+		err      string
+	}{
+		{kind: "option", currency: "BTC", want: "option.BTC"},
+		{kind: "OPTION", currency: "BTC", want: "option.BTC"},
+		{kind: "any", currency: "BTC", want: "any.BTC"},
+		{kind: "option", currency: "any", want: "option.any"},
+		{kind: "any", currency: "any", want: "any.any"},
+		{kind: "future", currency: "BTC", err: constant.INVALID_KIND},
+		{kind: "", currency: "BTC", err: constant.INVALID_KIND},
+		{kind: "option", currency: "XYZ", err: constant.UNSUPPORTED_CURRENCY},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind+"."+tt.currency, func(t *testing.T) {
+			scope, err := ParseScope(tt.kind, tt.currency)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, scope)
+		})
+	}
+}
+
+func TestChannelScope(t *testing.T) {
+	tests := []struct {
+		channel  []string
+		scope    string
+		interval string
+	}{
+		{[]string{"book", "BTC-28JAN22-50000-C", "100ms"}, "BTC-28JAN22-50000-C", "100ms"},
+		{[]string{"book", "option", "BTC", "100ms"}, "option.BTC", "100ms"},
+		{[]string{"book", "any", "any", "raw"}, "any.any", "raw"},
+		{[]string{"book", "BTC-28JAN22-50000-C"}, "BTC-28JAN22-50000-C", ""},
+	}
+
+	for _, tt := range tests {
+		scope, interval := channelScope(tt.channel, 1)
+		assert.Equal(t, tt.scope, scope)
+		assert.Equal(t, tt.interval, interval)
+	}
+}
+
+func TestInstrumentScopes(t *testing.T) {
+	assert.Equal(t, []string{
+		"BTC-28JAN22-50000-C",
+		"option.BTC",
+		"option.any",
+		"any.BTC",
+		"any.any",
+	}, instrumentScopes("BTC-28JAN22-50000-C"))
+}
@@ -177,14 +177,16 @@ func (svc wsTradeService) HandleConsumeUserTrades(msg *sarama.ConsumerMessage) {
 				userTradesMutex.Unlock()
 			}
 			// broadcast to user id
-			broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "trades", _instrument, id)
+			for _, scope := range instrumentScopes(_instrument) {
+				broadcastId := fmt.Sprintf("%s.%s.%s-%s", "user", "trades", scope, id)
 
-			params := _types.QuoteResponse{
-				Channel: fmt.Sprintf("user.trades.%s.raw", _instrument),
-				Data:    trades.Trades,
+				params := _types.QuoteResponse{
+					Channel: fmt.Sprintf("user.trades.%s.raw", scope),
+					Data:    trades.Trades,
+				}
+				method := "subscription"
+				ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 			}
-			method := "subscription"
-			ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 		}
 		return
 	} else {
@@ -209,13 +211,15 @@ func (svc wsTradeService) HandleConsumeUserTrades100ms(instrument string, userId
 				trades := userTrades[mapIndex]
 				userTradesMutex.RUnlock()
 				if len(trades) > 0 {
-					broadcastId := fmt.Sprintf("%s.%s.%s-%s-100ms", "user", "trades", instrument, userId)
-					params := _types.QuoteResponse{
-						Channel: fmt.Sprintf("user.trades.%s.100ms", instrument),
-						Data:    trades,
+					for _, scope := range instrumentScopes(instrument) {
+						broadcastId := fmt.Sprintf("%s.%s.%s-%s-100ms", "user", "trades", scope, userId)
+						params := _types.QuoteResponse{
+							Channel: fmt.Sprintf("user.trades.%s.100ms", scope),
+							Data:    trades,
+						}
+						method := "subscription"
+						ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 					}
-					method := "subscription"
-					ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 					userTradesMutex.Lock()
 					userTrades[mapIndex] = []*_deribitModel.DeribitGetUserTradesByInstruments{}
 					userTradesMutex.Unlock()
@@ -270,14 +274,16 @@ func (svc wsTradeService) HandleConsumeInstrumentTrades(msg *sarama.ConsumerMess
 				userTradesMutex.Unlock()
 			}
 			// broadcast to user id
-			broadcastId := fmt.Sprintf("%s.%s", "trades", _instrument)
+			for _, scope := range instrumentScopes(_instrument) {
+				broadcastId := fmt.Sprintf("%s.%s", "trades", scope)
 
-			params := _types.QuoteResponse{
-				Channel: fmt.Sprintf("trades.%s.raw", _instrument),
-				Data:    trades.Trades,
+				params := _types.QuoteResponse{
+					Channel: fmt.Sprintf("trades.%s.raw", scope),
+					Data:    trades.Trades,
+				}
+				method := "subscription"
+				ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 			}
-			method := "subscription"
-			ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 		}
 		return
 	} else {
@@ -302,13 +308,15 @@ func (svc wsTradeService) HandleConsumeInstrumentTrades100ms(instrument string)
 				trades := userTrades[mapIndex]
 				userTradesMutex.RUnlock()
 				if len(trades) > 0 {
-					broadcastId := fmt.Sprintf("%s.%s-100ms", "trades", instrument)
-					params := _types.QuoteResponse{
-						Channel: fmt.Sprintf("trades.%s.100ms", instrument),
-						Data:    trades,
+					for _, scope := range instrumentScopes(instrument) {
+						broadcastId := fmt.Sprintf("%s.%s-100ms", "trades", scope)
+						params := _types.QuoteResponse{
+							Channel: fmt.Sprintf("trades.%s.100ms", scope),
+							Data:    trades,
+						}
+						method := "subscription"
+						ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 					}
-					method := "subscription"
-					ws.GetTradeSocket().BroadcastMessageTrade(broadcastId, method, params)
 					userTradesMutex.Lock()
 					userTrades[mapIndex] = []*_deribitModel.DeribitGetUserTradesByInstruments{}
 					userTradesMutex.Unlock()
@@ -376,10 +384,10 @@ func (svc wsTradeService) SubscribeUserTrades(c *ws.Client, channel string, user
 	// Subscribe
 
 	var id string
-	if len(key) > 3 && key[3] == "100ms" {
-		id = fmt.Sprintf("%s.%s.%s-%s-100ms", key[0], key[1], key[2], userId)
+	if scope, interval := channelScope(key, 2); interval == "100ms" {
+		id = fmt.Sprintf("%s.%s.%s-%s-100ms", key[0], key[1], scope, userId)
 	} else {
-		id = fmt.Sprintf("%s.%s.%s-%s", key[0], key[1], key[2], userId)
+		id = fmt.Sprintf("%s.%s.%s-%s", key[0], key[1], scope, userId)
 	}
 
 	logs.Log.Info().Str("subscribe", id).Msg("")
@@ -403,10 +411,10 @@ func (svc wsTradeService) SubscribeTrades(c *ws.Client, channel string) {
 	// Subscribe
 
 	var id string
-	if key[2] == "100ms" {
-		id = fmt.Sprintf("%s.%s-100ms", key[0], key[1])
+	if scope, interval := channelScope(key, 1); interval == "100ms" {
+		id = fmt.Sprintf("%s.%s-100ms", key[0], scope)
 	} else {
-		id = fmt.Sprintf("%s.%s", key[0], key[1])
+		id = fmt.Sprintf("%s.%s", key[0], scope)
 	}
 
 	err := socket.Subscribe(id, c)
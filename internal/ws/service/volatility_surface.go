@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	_deribitModel "gateway/internal/deribit/model"
+	_orderbookTypes "gateway/internal/orderbook/types"
+	"gateway/pkg/utils"
+	"gateway/pkg/ws"
+)
+
+// the volatility surface channels publish a snapshot once per interval
+const volatilitySurfaceInterval = time.Second
+
+// the volatility surface channels with a publisher running on this node
+var volatilitySurfaces = make(map[string]bool)
+var volatilitySurfacesMutex sync.Mutex
+
+// GetVolatilitySurface returns the implied volatilities of the active expiries of the
+// currency by strike and by delta bucket
+func (svc wsOrderbookService) GetVolatilitySurface(ctx context.Context, request _deribitModel.GetVolatilitySurfaceRequest) _deribitModel.GetVolatilitySurfaceResponse {
+	return svc.optionsService.GetVolatilitySurface(ctx, request)
+}
+
+func (svc wsOrderbookService) SubscribeVolatilitySurface(c *ws.Client, channel, currency string) {
+	socket := ws.GetBookSocket()
+	if _, err := utils.ParseCurrency(currency); err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Subscribe
+	id := channel
+	err := socket.Subscribe(id, c)
+	if err != nil {
+		msg := map[string]string{"Message": err.Error()}
+		socket.SendErrorMessage(c, msg)
+		return
+	}
+
+	// Prepare when user is doing unsubscribe
+	ws.RegisterConnectionUnsubscribeHandler(c, socket.UnsubscribeHandler(id))
+
+	request := _deribitModel.GetVolatilitySurfaceRequest{Currency: currency}
+
+	// Send initial data
+	params := _orderbookTypes.QuoteResponse{
+		Channel: channel,
+		Data:    svc.GetVolatilitySurface(context.TODO(), request),
+	}
+	socket.SendInitMessage(c, "subscription", params)
+
+	volatilitySurfacesMutex.Lock()
+	defer volatilitySurfacesMutex.Unlock()
+
+	if !volatilitySurfaces[id] {
+		volatilitySurfaces[id] = true
+		go svc.publishVolatilitySurface(id, request)
+	}
+}
+
+func (svc wsOrderbookService) UnsubscribeVolatilitySurface(c *ws.Client, channel string) {
+	socket := ws.GetBookSocket()
+	socket.UnsubscribeChannel(channel, c)
+}
+
+// publishVolatilitySurface sends the surface of the channel once per interval, every
+// node publishes to its own connections
+func (svc wsOrderbookService) publishVolatilitySurface(id string, request _deribitModel.GetVolatilitySurfaceRequest) {
+	ticker := time.NewTicker(volatilitySurfaceInterval)
+	defer ticker.Stop()
+
+	socket := ws.GetBookSocket()
+	for range ticker.C {
+		volatilitySurfacesMutex.Lock()
+		if !socket.HasSubscriptions(id) {
+			delete(volatilitySurfaces, id)
+			volatilitySurfacesMutex.Unlock()
+			return
+		}
+		volatilitySurfacesMutex.Unlock()
+
+		params := _orderbookTypes.QuoteResponse{
+			Channel: id,
+			Data:    svc.GetVolatilitySurface(context.TODO(), request),
+		}
+		socket.BroadcastLocalMessage(id, "subscription", params)
+	}
+}
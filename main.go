@@ -15,20 +15,35 @@ import (
 
 	"gateway/cmd/server"
 	ordermatch "gateway/internal/fix-acceptor"
+	"gateway/internal/orderbook/book"
 	"gateway/internal/repositories"
 	"gateway/pkg/collector"
+	"gateway/pkg/fanout"
+	"gateway/pkg/grpc"
 	"gateway/pkg/kafka/consumer"
+	"gateway/pkg/kafka/producer"
 	"gateway/pkg/memdb"
 	"gateway/pkg/middleware"
 	"gateway/pkg/mongo"
 	"gateway/pkg/redis"
 	"gateway/pkg/utils"
+	"gateway/pkg/ws"
 
+	_candleSvc "gateway/internal/candle/service"
 	_deribitCtrl "gateway/internal/deribit/controller"
 	_deribitSvc "gateway/internal/deribit/service"
+	_dlqSvc "gateway/internal/dlq/service"
 	_engSvc "gateway/internal/engine/service"
+	_expirySvc "gateway/internal/expiry/service"
+	_grpcCtrl "gateway/internal/grpc/controller"
+	_instrumentSvc "gateway/internal/instrument/service"
+	_linkedSvc "gateway/internal/linked/service"
+	_optionsSvc "gateway/internal/options/service"
 	_obSvc "gateway/internal/orderbook/service"
+	_outboxSvc "gateway/internal/outbox/service"
+	_triggerSvc "gateway/internal/trigger/service"
 	_userSvc "gateway/internal/user/service"
+	_volatilitySvc "gateway/internal/volatility/service"
 	_wsEngineSvc "gateway/internal/ws/engine/service"
 	_wsSvc "gateway/internal/ws/service"
 
@@ -163,6 +178,20 @@ func main() {
 	tradeRepo := repositories.NewTradeRepository(mongoConn)
 	rawPriceRepo := repositories.NewRawPriceRepository(mongoConn)
 	settlementPriceRepo := repositories.NewSettlementPriceRepository(mongoConn)
+	outboxRepo := repositories.NewOutboxRepository(mongoConn)
+	candleRepo := repositories.NewCandleRepository(mongoConn)
+	volatilityIndexRepo := repositories.NewVolatilityIndexRepository(mongoConn)
+	triggerOrderRepo := repositories.NewTriggerOrderRepository(mongoConn)
+	linkedOrderRepo := repositories.NewLinkedOrderRepository(mongoConn)
+	orderExpirationRepo := repositories.NewOrderExpirationRepository(mongoConn)
+	instrumentExpiryRepo := repositories.NewInstrumentExpiryRepository(mongoConn)
+	leaseRepo := repositories.NewLeaseRepository(mongoConn)
+
+	// order books in memory, loaded from the orders on the first use
+	book.Init(orderRepo)
+
+	// option chains and volatility surfaces, quoted from the books in memory
+	_optionsSvc := _optionsSvc.NewOptionsService(orderRepo, tradeRepo, rawPriceRepo)
 
 	_authSvc := _userSvc.NewAuthService(userRepo)
 	_wsOrderbookSvc := _wsSvc.NewWSOrderbookService(
@@ -171,6 +200,7 @@ func main() {
 		tradeRepo,
 		rawPriceRepo,
 		settlementPriceRepo,
+		_optionsSvc,
 	)
 	_wsOrderSvc := _wsSvc.NewWSOrderService(redisConn, orderRepo)
 	_wsTradeSvc := _wsSvc.NewWSTradeService(redisConn, tradeRepo)
@@ -181,14 +211,46 @@ func main() {
 
 	_userSvc.SyncMemDB(context.TODO(), nil)
 
+	_dlqSvc.NewDLQService(engine)
+	_outboxSvc := _outboxSvc.NewOutboxService(engine, outboxRepo)
+	go _outboxSvc.Relay()
+	ws.SetupOutbox(_outboxSvc)
+
+	// candle store of the chart endpoint, backfilled from the trades on start
+	_candleSvc := _candleSvc.NewCandleService(tradeRepo, candleRepo, leaseRepo)
+	go _candleSvc.Run()
+
+	// volatility index of the volatility index data endpoint, sampled from the books
+	_volatilitySvc := _volatilitySvc.NewVolatilityService(orderRepo, rawPriceRepo, volatilityIndexRepo, leaseRepo)
+	go _volatilitySvc.Run()
+
+	// stop and take profit orders, held until their trigger price is reached
+	_triggerSvc := _triggerSvc.NewTriggerService(triggerOrderRepo, rawPriceRepo, linkedOrderRepo, _outboxSvc, _wsOrderSvc)
+
 	_deribitSvc := _deribitSvc.NewDeribitService(
 		redisConn,
 		tradeRepo,
 		orderRepo,
 		rawPriceRepo,
 		settlementPriceRepo,
+		linkedOrderRepo,
+		orderExpirationRepo,
+		_outboxSvc,
+		_triggerSvc,
+		_optionsSvc,
 	)
 
+	// linked orders, their follow-up orders are sent once the orders are filled
+	_linkedSvc := _linkedSvc.NewLinkedOrderService(linkedOrderRepo, _deribitSvc)
+
+	// good til day and good til date orders, cancelled once they expire
+	_expirySvc := _expirySvc.NewExpiryService(orderExpirationRepo, triggerOrderRepo, _deribitSvc, _triggerSvc)
+	go _expirySvc.Run()
+
+	// resting orders of the instruments, cancelled once the instrument expires
+	_instrumentSvc := _instrumentSvc.NewInstrumentService(orderRepo, triggerOrderRepo, instrumentExpiryRepo, _deribitSvc, _triggerSvc, _wsOrderbookSvc)
+	go _instrumentSvc.Run()
+
 	fixApp := ordermatch.InitApp(_deribitSvc, _wsOrderbookSvc)
 	go ordermatch.Execute(fixApp)
 
@@ -207,6 +269,23 @@ func main() {
 		limiter,
 	)
 
+	grpcSrv := grpc.NewServer()
+	_grpcCtrl.NewGrpcHandler(
+		grpcSrv,
+		_deribitSvc,
+		_wsOrderbookSvc,
+		_wsOrderSvc,
+		_wsTradeSvc,
+		userRepo,
+		limiter,
+	)
+
+	grpcPort, ok := os.LookupEnv("GRPC_PORT")
+	if !ok {
+		grpcPort = "9090"
+	}
+	go grpc.Serve(grpcSrv, grpcPort)
+
 	fmt.Printf("Server is running on %s \n", os.Getenv("PORT"))
 	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	srv := &http.Server{
@@ -233,6 +312,8 @@ func main() {
 			collector.IncomingKafkaCounter,
 			collector.RequestDurationHistogram,
 			collector.KafkaDurationHistogram,
+			collector.QueueDepthGauge,
+			collector.KafkaFailureCounter,
 		)
 
 		if err := m.Serve(); err != nil && err != http.ErrServerClosed {
@@ -242,10 +323,15 @@ func main() {
 	}()
 
 	_obSvc := _obSvc.NewOrderbookHandler(engine, redisConn, _wsOrderbookSvc)
-	_engSvc := _engSvc.NewEngineHandler(engine, redisConn, tradeRepo, _wsOrderbookSvc)
+	_engSvc := _engSvc.NewEngineHandler(engine, redisConn, tradeRepo, linkedOrderRepo, _wsOrderbookSvc)
+
+	// cross-node fan-out, required when the replicas do not consume every kafka message
+	if os.Getenv("FANOUT_ENABLED") == "true" {
+		fanout.Init(redisConn)
+	}
 
 	// kafka listener
-	consumer.KafkaConsumer(orderRepo, _engSvc, _obSvc, _wsOrderSvc, _wsTradeSvc, _wsRawPriceSvc, fixApp)
+	consumer.KafkaConsumer(orderRepo, _engSvc, _obSvc, _wsOrderSvc, _wsTradeSvc, _wsRawPriceSvc, _outboxSvc, _candleSvc, _triggerSvc, _linkedSvc, _expirySvc, fixApp)
 
 	// Wait for interrupt signal to gracefully shutdown the server with
 	// a timeout of 5 seconds.
@@ -263,6 +349,8 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server Shutdown:", err)
 	}
+	grpcSrv.GracefulStop()
+	producer.Close()
 	// catching ctx.Done(). timeout of 5 seconds.
 	select {
 	case <-ctx.Done():
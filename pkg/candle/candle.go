@@ -0,0 +1,184 @@
+package candle
+
+import (
+	"sort"
+	"time"
+)
+
+// Resolutions are the supported candle lengths by resolution name, all of them divide
+// a day so the candles are aligned on UTC midnight
+var Resolutions = map[string]time.Duration{
+	"1":   time.Minute,
+	"3":   3 * time.Minute,
+	"5":   5 * time.Minute,
+	"10":  10 * time.Minute,
+	"15":  15 * time.Minute,
+	"30":  30 * time.Minute,
+	"60":  time.Hour,
+	"120": 2 * time.Hour,
+	"180": 3 * time.Hour,
+	"360": 6 * time.Hour,
+	"720": 12 * time.Hour,
+	"1D":  24 * time.Hour,
+}
+
+// IndexResolutions are the candle lengths of the index charts by resolution name in
+// seconds, or 1D
+var IndexResolutions = map[string]time.Duration{
+	"1":     time.Second,
+	"60":    time.Minute,
+	"3600":  time.Hour,
+	"43200": 12 * time.Hour,
+	"1D":    24 * time.Hour,
+}
+
+// Stored are the resolutions kept by the candle store, the others are merged from them
+var Stored = []string{"1", "5", "15", "60", "1D"}
+
+// FlushDelay is how long after its end a candle is written to the store, the trades
+// of a candle are saved a little after they happen
+const FlushDelay = 5 * time.Second
+
+// Candle is the OHLCV of the trades of [Tick, Tick + resolution), Tick is in milliseconds
+type Candle struct {
+	Tick   int64   `json:"tick"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+	Cost   float64 `json:"cost"`
+}
+
+type Trade struct {
+	Time   time.Time
+	Price  float64
+	Amount float64
+}
+
+// Start returns the start of the candle the time falls in
+func Start(t time.Time, resolution time.Duration) time.Time {
+	return t.UTC().Truncate(resolution)
+}
+
+// New opens an empty candle at start, its prices are the close of the previous candle
+func New(start time.Time, close float64) Candle {
+	return Candle{
+		Tick:  start.UnixMilli(),
+		Open:  close,
+		High:  close,
+		Low:   close,
+		Close: close,
+	}
+}
+
+// End returns the end of the candle, the start of the next one
+func (c Candle) End(resolution time.Duration) time.Time {
+	return time.UnixMilli(c.Tick).Add(resolution)
+}
+
+// Add updates the candle with a trade, the first trade opens it
+func (c *Candle) Add(price, amount float64) {
+	if c.Volume == 0 {
+		c.Open, c.High, c.Low = price, price, price
+	}
+
+	if price > c.High {
+		c.High = price
+	}
+	if price < c.Low {
+		c.Low = price
+	}
+	c.Close = price
+	c.Volume += amount
+	c.Cost += price * amount
+}
+
+// Source returns the longest stored resolution the resolution is merged from
+func Source(resolution string) string {
+	source := Stored[0]
+	for _, s := range Stored {
+		if Resolutions[resolution]%Resolutions[s] == 0 {
+			source = s
+		}
+	}
+
+	return source
+}
+
+// merge updates the candle with a shorter candle of its range
+func (c *Candle) merge(o Candle) {
+	if o.Volume == 0 {
+		return
+	}
+
+	if c.Volume == 0 {
+		c.Open, c.High, c.Low = o.Open, o.High, o.Low
+	}
+
+	if o.High > c.High {
+		c.High = o.High
+	}
+	if o.Low < c.Low {
+		c.Low = o.Low
+	}
+	c.Close = o.Close
+	c.Volume += o.Volume
+	c.Cost += o.Cost
+}
+
+// Merge returns the candles of the resolution from shorter candles, the same way Build
+// does from the trades
+func Merge(candles []Candle, start, end time.Time, resolution time.Duration) []Candle {
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].Tick < candles[j].Tick
+	})
+
+	merged := []Candle{}
+	close := 0.0
+	i := 0
+	for tick := Start(start, resolution); tick.Before(end); tick = tick.Add(resolution) {
+		c := New(tick, close)
+		next := tick.Add(resolution).UnixMilli()
+
+		for ; i < len(candles) && candles[i].Tick < next; i++ {
+			if candles[i].Tick < tick.UnixMilli() || candles[i].Tick >= end.UnixMilli() {
+				continue
+			}
+			c.merge(candles[i])
+		}
+
+		close = c.Close
+		merged = append(merged, c)
+	}
+
+	return merged
+}
+
+// Build returns the candles from the start of the candle of start until end, a candle
+// without trades carries the close of the previous one, trades outside are ignored
+func Build(trades []Trade, start, end time.Time, resolution time.Duration) []Candle {
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Time.Before(trades[j].Time)
+	})
+
+	candles := []Candle{}
+	close := 0.0
+	i := 0
+	for tick := Start(start, resolution); tick.Before(end); tick = tick.Add(resolution) {
+		c := New(tick, close)
+		next := tick.Add(resolution)
+
+		for ; i < len(trades) && trades[i].Time.Before(next); i++ {
+			if trades[i].Time.Before(tick) || !trades[i].Time.Before(end) {
+				continue
+			}
+			c.Add(trades[i].Price, trades[i].Amount)
+		}
+
+		close = c.Close
+		candles = append(candles, c)
+	}
+
+	return candles
+}
@@ -0,0 +1,101 @@
+package candle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart(t *testing.T) {
+	ts := time.Date(2023, 5, 17, 13, 47, 12, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2023, 5, 17, 13, 45, 0, 0, time.UTC), Start(ts, Resolutions["15"]))
+	assert.Equal(t, time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC), Start(ts, Resolutions["180"]))
+	assert.Equal(t, time.Date(2023, 5, 17, 0, 0, 0, 0, time.UTC), Start(ts, Resolutions["1D"]))
+}
+
+func TestAdd(t *testing.T) {
+	c := New(time.UnixMilli(0), 10)
+	assert.Equal(t, Candle{Open: 10, High: 10, Low: 10, Close: 10}, c)
+
+	c.Add(12, 1)
+	c.Add(9, 2)
+	c.Add(11, 1)
+
+	assert.Equal(t, Candle{Open: 12, High: 12, Low: 9, Close: 11, Volume: 4, Cost: 41}, c)
+}
+
+func TestBuild(t *testing.T) {
+	start := time.Date(2023, 5, 17, 10, 0, 30, 0, time.UTC)
+	at := func(min, sec int) time.Time {
+		return time.Date(2023, 5, 17, 10, min, sec, 0, time.UTC)
+	}
+
+	trades := []Trade{
+		{Time: at(3, 10), Price: 7, Amount: 1},
+		{Time: at(0, 5), Price: 5, Amount: 2},
+		{Time: at(0, 50), Price: 6, Amount: 1},
+		{Time: at(4, 0), Price: 100, Amount: 1},
+	}
+
+	candles := Build(trades, start, at(4, 0), Resolutions["1"])
+
+	assert.Equal(t, []Candle{
+		{Tick: at(0, 0).UnixMilli(), Open: 5, High: 6, Low: 5, Close: 6, Volume: 3, Cost: 16},
+		{Tick: at(1, 0).UnixMilli(), Open: 6, High: 6, Low: 6, Close: 6},
+		{Tick: at(2, 0).UnixMilli(), Open: 6, High: 6, Low: 6, Close: 6},
+		{Tick: at(3, 0).UnixMilli(), Open: 7, High: 7, Low: 7, Close: 7, Volume: 1, Cost: 7},
+	}, candles)
+}
+
+func TestBuildWithoutTrades(t *testing.T) {
+	start := time.Date(2023, 5, 17, 0, 0, 0, 0, time.UTC)
+
+	candles := Build(nil, start, start.Add(2*time.Hour), Resolutions["60"])
+
+	assert.Equal(t, []Candle{
+		{Tick: start.UnixMilli()},
+		{Tick: start.Add(time.Hour).UnixMilli()},
+	}, candles)
+}
+
+func TestSource(t *testing.T) {
+	assert.Equal(t, "1", Source("1"))
+	assert.Equal(t, "1", Source("3"))
+	assert.Equal(t, "5", Source("10"))
+	assert.Equal(t, "15", Source("30"))
+	assert.Equal(t, "60", Source("720"))
+	assert.Equal(t, "1D", Source("1D"))
+}
+
+func TestMergeMatchesBuild(t *testing.T) {
+	start := time.Date(2023, 5, 17, 10, 0, 0, 0, time.UTC)
+	end := start.Add(40 * time.Minute)
+
+	trades := []Trade{}
+	for i := 0; i < 50; i++ {
+		if i%7 == 3 {
+			continue
+		}
+		trades = append(trades, Trade{
+			Time:   start.Add(time.Duration(i*47) * time.Second),
+			Price:  float64(100 + (i*37)%23),
+			Amount: float64(1 + i%3),
+		})
+	}
+
+	minutes := []Candle{}
+	for _, c := range Build(trades, start, end, Resolutions["1"]) {
+		if c.Volume > 0 {
+			minutes = append(minutes, c)
+		}
+	}
+
+	for _, resolution := range []string{"3", "5", "10", "15", "30"} {
+		assert.Equal(t,
+			Build(trades, start, end, Resolutions[resolution]),
+			Merge(minutes, start, end, Resolutions[resolution]),
+			resolution)
+	}
+}
@@ -0,0 +1,62 @@
+package clock
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Location returns the location named by the env key or by the fallback when the key is
+// not set, UTC when the name is unknown
+func Location(key, fallback string) *time.Location {
+	if loc, err := time.LoadLocation(getenv(key, fallback)); err == nil {
+		return loc
+	}
+
+	return time.UTC
+}
+
+// TimeOfDay returns the time of day of the env key as HH:MM, the fallback when the key is
+// not set or invalid
+func TimeOfDay(key, fallback string) time.Duration {
+	d, err := Parse(getenv(key, fallback))
+	if err != nil {
+		d, _ = Parse(fallback)
+	}
+
+	return d
+}
+
+func getenv(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+// Parse parses HH:MM, 24:00 is the end of the day
+func Parse(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time of day '%s'", s)
+	}
+
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+	if h < 0 || m < 0 || m > 59 || d > 24*time.Hour {
+		return 0, fmt.Errorf("invalid time of day '%s'", s)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,58 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Duration
+		ok   bool
+	}{
+		{"00:00", 0, true},
+		{"08:30", 8*time.Hour + 30*time.Minute, true},
+		{"24:00", 24 * time.Hour, true},
+		{"24:30", 0, false},
+		{"08:60", 0, false},
+		{"8", 0, false},
+		{"ab:00", 0, false},
+	}
+
+	for _, tt := range tests {
+		d, err := Parse(tt.s)
+		if !tt.ok {
+			assert.Error(t, err, tt.s)
+			continue
+		}
+		assert.NoError(t, err, tt.s)
+		assert.Equal(t, tt.want, d, tt.s)
+	}
+}
+
+func TestTimeOfDay(t *testing.T) {
+	t.Setenv("CLOCK_TEST_TIME", "")
+	assert.Equal(t, 24*time.Hour, TimeOfDay("CLOCK_TEST_TIME", "24:00"))
+
+	t.Setenv("CLOCK_TEST_TIME", "08:00")
+	assert.Equal(t, 8*time.Hour, TimeOfDay("CLOCK_TEST_TIME", "24:00"))
+
+	// an invalid time falls back to the default
+	t.Setenv("CLOCK_TEST_TIME", "8")
+	assert.Equal(t, 24*time.Hour, TimeOfDay("CLOCK_TEST_TIME", "24:00"))
+}
+
+func TestLocation(t *testing.T) {
+	t.Setenv("CLOCK_TEST_LOCATION", "")
+	assert.Equal(t, "Asia/Singapore", Location("CLOCK_TEST_LOCATION", "Asia/Singapore").String())
+
+	t.Setenv("CLOCK_TEST_LOCATION", "Europe/London")
+	assert.Equal(t, "Europe/London", Location("CLOCK_TEST_LOCATION", "Asia/Singapore").String())
+
+	// an unknown location is UTC
+	t.Setenv("CLOCK_TEST_LOCATION", "Nowhere")
+	assert.Equal(t, time.UTC, Location("CLOCK_TEST_LOCATION", "Asia/Singapore"))
+}
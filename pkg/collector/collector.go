@@ -16,6 +16,7 @@ const (
 	FIX       Protocol = "fix"
 	HTTP_GET  Protocol = "rest_get"
 	HTTP_POST Protocol = "rest_post"
+	GRPC      Protocol = "grpc"
 )
 
 var (
@@ -62,6 +63,16 @@ var (
 		Help:    "The total number of kafka duration",
 		Buckets: []float64{100, 1000, 10000, 100000, 1000000},
 	})
+
+	KafkaFailureCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_failure_counter",
+		Help: "The total number of kafka messages sent to the dead letter topic",
+	}, []string{"topic", "reason"})
+
+	QueueDepthGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "queue_depth",
+		Help: "The number of tasks waiting in the worker queue",
+	}, []string{"dispatcher", "queue"})
 )
 
 var (
@@ -10,10 +10,22 @@ const (
 	INVALID_INDEX_NAME               = "invalid_index_name"
 	INVALID_CHANNEL                  = "invalid_channel"
 	INVALID_INTERVAL                 = "invalid_interval"
+	INVALID_GROUP                    = "invalid_group"
+	INVALID_DEPTH                    = "invalid_depth"
+	INVALID_KIND                     = "invalid_kind"
+	INVALID_RESOLUTION               = "invalid_resolution"
 	INVALID_ORDER_ID                 = "invalid_order_id"
 	NOT_OWNER_OF_ORDER               = "not_owner_of_order"
 	ORDER_ALREADY_CLOSED             = "order_already_closed"
 	INTERVAL_MUST_BE_GREATER_THAN_10 = "interval_must_be_greater_than_10"
+	INVALID_TRIGGER                  = "invalid_trigger"
+	TRIGGER_PRICE_IS_REQUIRED        = "trigger_price_is_required"
+	TRIGGER_OFFSET_IS_REQUIRED       = "trigger_offset_is_required"
+	INVALID_TRIGGER_OFFSET           = "invalid_trigger_offset"
+	INVALID_LINKED_ORDER_TYPE        = "invalid_linked_order_type"
+	INVALID_OTOCO_CONFIG             = "invalid_otoco_config"
+	EXPIRE_AT_IS_REQUIRED            = "expire_at_is_required"
+	INVALID_EXPIRE_AT                = "invalid_expire_at"
 
 	// For FIX Protocol
 	NO_SESSION_FOUND      = "no_session_found"
@@ -0,0 +1,106 @@
+package dispatcher
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"gateway/pkg/collector"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Dispatcher runs the tasks sharing a key in the order they were dispatched,
+// tasks of other keys run in parallel on the other queues
+type Dispatcher struct {
+	name   string
+	queues []chan func()
+	depths []prometheus.Gauge
+
+	// keeps the parts of multi keys tasks in the same order on every queue
+	mu sync.Mutex
+}
+
+func NewDispatcher(name string, workers, size int) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+
+	d := &Dispatcher{
+		name:   name,
+		queues: make([]chan func(), workers),
+		depths: make([]prometheus.Gauge, workers),
+	}
+
+	for i := range d.queues {
+		d.queues[i] = make(chan func(), size)
+		d.depths[i] = collector.QueueDepthGauge.WithLabelValues(name, strconv.Itoa(i))
+		go d.work(i)
+	}
+
+	return d
+}
+
+// Dispatch queues the task behind the pending tasks of its keys, a task with keys
+// on several queues runs once it reached the head of each of them
+func (d *Dispatcher) Dispatch(task func(), keys ...string) {
+	queues := d.queuesOf(keys)
+	if len(queues) == 1 {
+		d.push(queues[0], task)
+		return
+	}
+
+	pending := int32(len(queues))
+	release := make(chan struct{})
+	barrier := func() {
+		if atomic.AddInt32(&pending, -1) == 0 {
+			task()
+			close(release)
+			return
+		}
+		<-release
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, i := range queues {
+		d.push(i, barrier)
+	}
+}
+
+func (d *Dispatcher) queuesOf(keys []string) []int {
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+
+	seen := make(map[int]bool)
+	queues := []int{}
+	for _, key := range keys {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+
+		i := int(h.Sum32() % uint32(len(d.queues)))
+		if !seen[i] {
+			seen[i] = true
+			queues = append(queues, i)
+		}
+	}
+	sort.Ints(queues)
+
+	return queues
+}
+
+func (d *Dispatcher) push(i int, task func()) {
+	d.depths[i].Inc()
+	d.queues[i] <- task
+}
+
+func (d *Dispatcher) work(i int) {
+	for task := range d.queues[i] {
+		d.depths[i].Dec()
+		task()
+	}
+}
@@ -0,0 +1,71 @@
+package dispatcher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchKeepsKeyOrder(t *testing.T) {
+	d := NewDispatcher("test-order", 4, 16)
+
+	var mu sync.Mutex
+	got := map[string][]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"BTC-1", "ETH-1", "BTC-2"} {
+			wg.Add(1)
+			i, key := i, key
+			d.Dispatch(func() {
+				defer wg.Done()
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			}, key)
+		}
+	}
+	wg.Wait()
+
+	for key, seq := range got {
+		for i, v := range seq {
+			assert.Equal(t, i, v, fmt.Sprintf("%s should be in dispatch order", key))
+		}
+	}
+}
+
+func TestDispatchMultiKeys(t *testing.T) {
+	d := NewDispatcher("test-multi", 8, 16)
+
+	var mu sync.Mutex
+	got := []string{}
+	record := func(s string) func() {
+		return func() {
+			mu.Lock()
+			got = append(got, s)
+			mu.Unlock()
+		}
+	}
+
+	block := make(chan struct{})
+	d.Dispatch(func() { <-block }, "BTC-1")
+	d.Dispatch(record("mass cancel"), "BTC-1", "ETH-1")
+
+	done := make(chan struct{})
+	d.Dispatch(func() {
+		record("after")()
+		close(done)
+	}, "ETH-1")
+
+	// the mass cancel waits behind BTC-1, so does the ETH-1 task behind it
+	mu.Lock()
+	assert.Empty(t, got)
+	mu.Unlock()
+
+	close(block)
+	<-done
+
+	assert.Equal(t, []string{"mass cancel", "after"}, got)
+}
@@ -0,0 +1,60 @@
+package expiry
+
+import (
+	"sync"
+	"time"
+
+	"gateway/pkg/clock"
+)
+
+// the time in force of the orders the gateway cancels once they expire
+const (
+	GoodTilDay  = "good_til_day"
+	GoodTilDate = "good_til_date"
+)
+
+// the cancel reasons of the orders cancelled at their expiry and of the orders cancelled
+// at the expiry of their instrument
+const (
+	CancelReason           = "expired"
+	InstrumentCancelReason = "expired_instrument"
+)
+
+// the trading day ends at the day end, in the venue location
+const (
+	defaultDayLocation = "Singapore"
+	defaultDayEnd      = "24:00"
+)
+
+var (
+	configOnce  sync.Once
+	dayLocation *time.Location
+	dayEnd      time.Duration
+)
+
+// config is read on first use, after the env file is loaded
+func config() {
+	configOnce.Do(func() {
+		dayLocation = clock.Location("TRADING_DAY_LOCATION", defaultDayLocation)
+		dayEnd = clock.TimeOfDay("TRADING_DAY_END", defaultDayEnd)
+	})
+}
+
+// Expires returns whether the orders of the time in force expire
+func Expires(timeInForce string) bool {
+	return timeInForce == GoodTilDay || timeInForce == GoodTilDate
+}
+
+// DayEnd returns the first end of a trading day after t
+func DayEnd(t time.Time) time.Time {
+	config()
+
+	local := t.In(dayLocation)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, dayLocation).Add(dayEnd)
+	if end.After(t) {
+		return end
+	}
+
+	// the day of t already ended
+	return end.AddDate(0, 0, 1)
+}
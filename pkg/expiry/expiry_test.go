@@ -0,0 +1,34 @@
+package expiry
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpires(t *testing.T) {
+	assert.True(t, Expires(GoodTilDay))
+	assert.True(t, Expires(GoodTilDate))
+	assert.False(t, Expires("good_til_cancelled"))
+	assert.False(t, Expires("immediate_or_cancel"))
+}
+
+func TestDayEnd(t *testing.T) {
+	t.Setenv("TRADING_DAY_LOCATION", "UTC")
+	t.Setenv("TRADING_DAY_END", "08:00")
+	configOnce = sync.Once{}
+
+	// before and after the day end
+	assert.Equal(t, time.Date(2023, 1, 31, 8, 0, 0, 0, time.UTC), DayEnd(time.Date(2023, 1, 31, 3, 0, 0, 0, time.UTC)).UTC())
+	assert.Equal(t, time.Date(2023, 2, 1, 8, 0, 0, 0, time.UTC), DayEnd(time.Date(2023, 1, 31, 8, 0, 0, 0, time.UTC)).UTC())
+
+	t.Setenv("TRADING_DAY_LOCATION", "Asia/Singapore")
+	t.Setenv("TRADING_DAY_END", "24:00")
+	configOnce = sync.Once{}
+
+	// the midnight of Singapore is 16:00 UTC
+	assert.Equal(t, time.Date(2023, 1, 31, 16, 0, 0, 0, time.UTC), DayEnd(time.Date(2023, 1, 31, 3, 0, 0, 0, time.UTC)).UTC())
+	assert.Equal(t, time.Date(2023, 2, 1, 16, 0, 0, 0, time.UTC), DayEnd(time.Date(2023, 1, 31, 17, 0, 0, 0, time.UTC)).UTC())
+}
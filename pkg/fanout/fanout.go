@@ -0,0 +1,132 @@
+package fanout
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"gateway/pkg/redis"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/hashicorp/go-uuid"
+)
+
+// Channel is the redis pub/sub channel shared by every gateway node
+const Channel = "GATEWAY-FANOUT"
+
+// Handler is called with the payload published by another node
+type Handler func(payload json.RawMessage)
+
+type message struct {
+	Node    string          `json:"node"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+var (
+	node     string
+	pool     *redis.RedisConnectionPool
+	handlers map[string]Handler
+	mu       sync.RWMutex
+)
+
+// Init joins the fan-out of the gateway nodes, until it is called Publish is a no-op
+// and the node only serves the connections it holds
+func Init(p *redis.RedisConnectionPool) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		logs.Log.Fatal().Err(err).Msg("failed to generate fanout node id")
+	}
+
+	mu.Lock()
+	node = id
+	pool = p
+	mu.Unlock()
+
+	logs.Log.Info().Str("node", id).Msg("fanout enabled")
+
+	go listen(p)
+}
+
+// Enabled reports whether the node publishes to the other nodes
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return pool != nil
+}
+
+// Register sets the handler of the messages published with the kind
+func Register(kind string, handler Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if handlers == nil {
+		handlers = make(map[string]Handler)
+	}
+	handlers[kind] = handler
+}
+
+// Publish sends the payload to the other gateway nodes, the publisher is expected
+// to handle its own connections
+func Publish(kind string, v interface{}) {
+	mu.RLock()
+	p, id := pool, node
+	mu.RUnlock()
+
+	if p == nil {
+		return
+	}
+
+	payload, err := json.Marshal(v)
+	if err != nil {
+		logs.Log.Error().Err(err).Str("kind", kind).Msg("failed to marshal fanout payload")
+		return
+	}
+
+	data, err := json.Marshal(message{Node: id, Kind: kind, Payload: payload})
+	if err != nil {
+		logs.Log.Error().Err(err).Str("kind", kind).Msg("failed to marshal fanout message")
+		return
+	}
+
+	if err := p.Publish(Channel, data); err != nil {
+		logs.Log.Error().Err(err).Str("kind", kind).Msg("failed to publish fanout message")
+	}
+}
+
+func listen(p *redis.RedisConnectionPool) {
+	for {
+		if err := p.Subscribe(Channel, dispatch); err != nil {
+			logs.Log.Error().Err(err).Msg("fanout subscription lost, resubscribing")
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+// dispatch runs the handler in the subscriber goroutine so the messages keep
+// the order they were published in
+func dispatch(data []byte) {
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil {
+		logs.Log.Error().Err(err).Msg("failed to parse fanout message")
+		return
+	}
+
+	mu.RLock()
+	handler, ok := handlers[m.Kind]
+	self := m.Node == node
+	mu.RUnlock()
+
+	if self {
+		return
+	}
+
+	if !ok {
+		logs.Log.Warn().Str("kind", m.Kind).Msg("no fanout handler")
+		return
+	}
+
+	handler(m.Payload)
+}
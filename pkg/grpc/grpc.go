@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// Messages are exchanged as json, the same payloads used by the
+// websocket and http api, so no generated protobuf types are needed
+const CodecName = "json"
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return CodecName
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+func NewServer() *grpc.Server {
+	return grpc.NewServer(grpc.ForceServerCodec(jsonCodec{}))
+}
+
+// Serve blocks until the listener is closed
+func Serve(srv *grpc.Server, port string) {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		logs.Log.Fatal().Err(err).Msg("failed to listen grpc")
+		return
+	}
+
+	logs.Log.Info().Str("port", port).Msg("grpc server is running")
+	if err := srv.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+		logs.Log.Error().Err(err).Msg("")
+	}
+}
@@ -0,0 +1,22 @@
+package kafka
+
+import (
+	"os"
+
+	"github.com/Shopify/sarama"
+)
+
+// Brokers returns the kafka brokers the gateway connects to
+func Brokers() []string {
+	return []string{os.Getenv("KAFKA_BROKER")}
+}
+
+// Version returns the protocol version used with the brokers
+func Version() (sarama.KafkaVersion, error) {
+	version, ok := os.LookupEnv("KAFKA_VERSION")
+	if !ok || version == "" {
+		version = "2.1.0"
+	}
+
+	return sarama.ParseKafkaVersion(version)
+}
@@ -1,22 +1,32 @@
 package consumer
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gateway/pkg/collector"
+	"gateway/pkg/dispatcher"
+	"gateway/pkg/kafka"
 	"gateway/pkg/protocol"
 	"gateway/pkg/utils"
 	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
 	"log"
-	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	candleInt "gateway/internal/candle/service"
 	engInt "gateway/internal/engine/service"
 	_engineType "gateway/internal/engine/types"
+	expiryInt "gateway/internal/expiry/service"
 	ordermatch "gateway/internal/fix-acceptor"
+	linkedInt "gateway/internal/linked/service"
 	obInt "gateway/internal/orderbook/service"
+	outboxInt "gateway/internal/outbox/service"
 	"gateway/internal/repositories"
+	triggerInt "gateway/internal/trigger/service"
 
 	"github.com/Shopify/sarama"
 
@@ -30,6 +40,11 @@ func KafkaConsumer(
 	oSvc oInt.IwsOrderService,
 	tradeSvc oInt.IwsTradeService,
 	rawSvc oInt.IwsRawPriceService,
+	outboxSvc outboxInt.IOutboxService,
+	candleSvc candleInt.ICandleService,
+	triggerSvc triggerInt.ITriggerService,
+	linkedSvc linkedInt.ILinkedOrderService,
+	expirySvc expiryInt.IExpiryService,
 	fixApp *ordermatch.Application,
 ) {
 	// Metrics
@@ -37,57 +52,150 @@ func KafkaConsumer(
 		collector.IncomingKafkaCounter.Inc()
 	}()
 
-	config := sarama.NewConfig()
-	config.Consumer.Return.Errors = true
+	config, err := newConsumerConfig()
+	if err != nil {
+		log.Fatalf("Invalid consumer config: %s", err)
+	}
 
-	brokers := []string{os.Getenv("KAFKA_BROKER")}
-	topics := []string{"ENGINE", "CANCELLED_ORDER", "PRICES", "ENGINE_SAVED", "CANCELLED_ORDER_SAVED"}
+	topics := newTopics()
 
-	consumer, err := sarama.NewConsumer(brokers, config)
+	group, err := sarama.NewConsumerGroup(kafka.Brokers(), consumerGroupID(), config)
 	if err != nil {
-		log.Fatalf("Failed to create consumer: %s", err)
+		log.Fatalf("Failed to create consumer group: %s", err)
 	}
-	defer consumer.Close()
+	defer group.Close()
+
+	go func() {
+		for err := range group.Errors() {
+			logs.Log.Error().Err(err).Msg("kafka consumer group error")
+		}
+	}()
 
-	for _, topic := range topics {
-		partitionConsumer, err := consumer.ConsumePartition(topic, 0, sarama.OffsetNewest)
+	// the handlers of the messages of an instrument run in order
+	d := dispatcher.NewDispatcher("kafka", consumerWorkers(), 1024)
+
+	handle := func(topic string, message *sarama.ConsumerMessage, handlers []string) <-chan struct{} {
+		done := make(chan struct{})
+
+		// the dead letter and the commit keep the original message
+		original := message
+		message, keys, err := decodeMessage(topic, original)
 		if err != nil {
-			log.Fatalf("Failed to create partition consumer for topic '%s': %s", topic, err)
+			// the handlers would drop it, park it for a replay after the fix
+			deadLetter(original, reasonParse, nil, err)
+			close(done)
+			return done
+		}
+
+		var wg sync.WaitGroup
+		var failuresMutex sync.Mutex
+		var failures []failure
+		fail := func(handler, reason string, err error) {
+			failuresMutex.Lock()
+			defer failuresMutex.Unlock()
+
+			failures = append(failures, failure{handler, reason, err})
 		}
 
-		go func(topic string) {
-			for message := range partitionConsumer.Messages() {
-				switch topic {
-				case "ENGINE":
-					go onEngineReceived(oSvc, message, fixApp)
-					go engSvc.HandleConsume(message)
-				case "ENGINE_SAVED":
-					go onEngineSavedReceived(message, fixApp)
-					go engSvc.HandleConsumeQuote(message)
-					go oSvc.HandleConsumeUserOrder(message)
-					go tradeSvc.HandleConsumeUserTrades(message)
-					go tradeSvc.HandleConsumeInstrumentTrades(message)
-					go obSvc.HandleConsumeUserChange(message)
-					go obSvc.HandleConsumeBook(message)
-					go obSvc.HandleConsumeTicker(message)
-				case "CANCELLED_ORDER":
-					handleTopicCancelledOrders(message)
-				case "CANCELLED_ORDER_SAVED":
-					go obSvc.HandleConsumeUserChangeCancel(message)
-					go oSvc.HandleConsumeUserOrderCancel(message)
-					go obSvc.HandleConsumeBookCancel(message)
-					go engSvc.HandleConsumeQuoteCancel(message)
-					go obSvc.HandleConsumeTickerCancel(message)
-				case "PRICES":
-					go rawSvc.HandleConsume(message)
-				default:
-					log.Printf("Unknown topic: %s", topic)
+		run := func(name string, fn func() error) {
+			// a replay only runs the handlers which failed
+			if len(handlers) > 0 && !utils.ArrContains(handlers, name) {
+				return
+			}
+
+			wg.Add(1)
+			d.Dispatch(func() {
+				defer wg.Done()
+				defer func() {
+					if r := recover(); r != nil {
+						fail(name, reasonPanic, fmt.Errorf("%v", r))
+					}
+				}()
+
+				if err := fn(); err != nil {
+					fail(name, reasonHandler, err)
+				}
+			}, keys...)
+		}
+
+		// handlers without error
+		do := func(name string, fn func()) {
+			run(name, func() error {
+				fn()
+				return nil
+			})
+		}
+
+		switch topic {
+		case topicEngine:
+			// the answers of a command sent twice are only handled once
+			do("engine", func() {
+				if onEngineReceived(oSvc, outboxSvc, message, fixApp) {
+					engSvc.HandleConsume(message)
 				}
+			})
+		case topicEngineSaved:
+			// the book in memory is updated first, the handlers of the instrument run in order
+			run("orderbook.orders", func() error { return obSvc.HandleConsumeOrders(message) })
+			do("fix", func() { onEngineSavedReceived(message, fixApp) })
+			run("engine.quote", func() error { return engSvc.HandleConsumeQuote(message) })
+			do("ws.user_order", func() { oSvc.HandleConsumeUserOrder(message) })
+			do("ws.user_trades", func() { tradeSvc.HandleConsumeUserTrades(message) })
+			do("ws.instrument_trades", func() { tradeSvc.HandleConsumeInstrumentTrades(message) })
+			do("trigger", func() { triggerSvc.HandleConsumeEngineSaved(message) })
+			do("linked", func() { linkedSvc.HandleConsumeEngineSaved(message) })
+			do("expiry", func() { expirySvc.HandleConsumeEngineSaved(message) })
+			do("ws.chart_trades", func() { tradeSvc.HandleConsumeChartTrades(message) })
+			do("candle", func() { candleSvc.HandleConsume(message) })
+			do("orderbook.user_change", func() { obSvc.HandleConsumeUserChange(message) })
+			run("orderbook.book", func() error { return obSvc.HandleConsumeBook(message) })
+			run("orderbook.ticker", func() error { return obSvc.HandleConsumeTicker(message) })
+		case topicCancelledOrder:
+			run("cancelled_orders", func() error { return handleTopicCancelledOrders(outboxSvc, message) })
+		case topicCancelledOrderSaved:
+			run("orderbook.orders", func() error { return obSvc.HandleConsumeOrdersCancel(message) })
+			run("orderbook.user_change", func() error { return obSvc.HandleConsumeUserChangeCancel(message) })
+			do("ws.user_order", func() { oSvc.HandleConsumeUserOrderCancel(message) })
+			do("linked", func() { linkedSvc.HandleConsumeCancelledOrderSaved(message) })
+			run("orderbook.book", func() error { return obSvc.HandleConsumeBookCancel(message) })
+			run("engine.quote", func() error { return engSvc.HandleConsumeQuoteCancel(message) })
+			run("orderbook.ticker", func() error { return obSvc.HandleConsumeTickerCancel(message) })
+		case topicPrices:
+			do("ws.raw_price", func() { rawSvc.HandleConsume(message) })
+			do("trigger", func() { triggerSvc.HandleConsumePrices(message) })
+		default:
+			log.Printf("Unknown topic: %s", topic)
+		}
+
+		// the offset is committed once every handler is done with the message, a message
+		// is dead lettered once whatever the number of handlers which failed
+		go func() {
+			wg.Wait()
+			if len(failures) > 0 {
+				deadLetterFailures(original, failures)
 			}
-		}(topic)
+			close(done)
+		}()
+
+		return done
+	}
+	setPipeline(topics, handle)
+
+	handler := &groupHandler{
+		topics: topics,
+		handle: handle,
 	}
 
-	select {}
+	// Consume returns on every rebalance, join the group again
+	for {
+		if err := group.Consume(context.Background(), topics.names(), handler); err != nil {
+			if err == sarama.ErrClosedConsumerGroup {
+				return
+			}
+			logs.Log.Error().Err(err).Msg("kafka consumer group stopped")
+			time.Sleep(time.Second)
+		}
+	}
 }
 
 // Hook when ENGINE_SAVED topic received
@@ -103,8 +211,9 @@ func onEngineSavedReceived(message *sarama.ConsumerMessage, fixApp *ordermatch.A
 	fixApp.OnEngineSavedReceived(data)
 }
 
-// Hook when ENGINE topic received
-func onEngineReceived(oSvc oInt.IwsOrderService, message *sarama.ConsumerMessage, fixApp *ordermatch.Application) {
+// Hook when ENGINE topic received, it returns false for another answer to a command
+// which was already answered
+func onEngineReceived(oSvc oInt.IwsOrderService, outboxSvc outboxInt.IOutboxService, message *sarama.ConsumerMessage, fixApp *ordermatch.Application) bool {
 	logs.Log.Info().Msg(fmt.Sprintf("Received message from ORDER: %s\n", string(message.Value)))
 
 	str := string(message.Value)
@@ -112,25 +221,26 @@ func onEngineReceived(oSvc oInt.IwsOrderService, message *sarama.ConsumerMessage
 	err := json.Unmarshal([]byte(str), &data)
 	if err != nil {
 		logs.Log.Error().Err(err).Msg("Error parsing JSON")
-		return
+		return true
 	}
 
-	if data.Matches.TakerOrder == nil {
-		return
+	if data.Matches == nil || data.Matches.TakerOrder == nil {
+		return true
 	}
+
+	// the engine answered the command
+	if !outboxSvc.Acknowledge(data.Matches.TakerOrder.RequestID) {
+		logs.Log.Info().Str("request_id", data.Matches.TakerOrder.RequestID).Msg("duplicate engine answer dropped")
+		return false
+	}
+
 	// Send message to websocket
 
 	// convert mongodb object id to string
 	userIDStr := data.Matches.TakerOrder.UserID.Hex()
 
-	// Handle taker order (if FIX user is subscribing)
-	go fixApp.OrderConfirmation(data)
-
-	// Handle maker order (if FIX user is subscribing)
-	go fixApp.MakerConfirmation(data)
-
-	// FIX Subscription
-	go fixApp.OnTradeHappens(data)
+	// Handle taker, maker order and subscriptions (if FIX user is subscribing)
+	fixApp.OnEngineReceived(data)
 
 	// no need to use HandleConsume
 	// userId := data.Matches.TakerOrder.UserID
@@ -139,6 +249,8 @@ func onEngineReceived(oSvc oInt.IwsOrderService, message *sarama.ConsumerMessage
 	// Metrics
 	clOrdID := fmt.Sprintf("%v", data.Matches.TakerOrder.ClOrdID)
 	collector.EndKafkaDuration(userIDStr, clOrdID)
+
+	return true
 }
 
 func handleTopicTrade(tradeSvc oInt.IwsTradeService, message *sarama.ConsumerMessage) {
@@ -192,36 +304,49 @@ func handleTopicOrderbook(message *sarama.ConsumerMessage) {
 // 	// protocol.SendSuccessMsg(connectionKey, _payload)
 // }
 
-func handleTopicCancelledOrders(message *sarama.ConsumerMessage) {
+func handleTopicCancelledOrders(outboxSvc outboxInt.IOutboxService, message *sarama.ConsumerMessage) error {
 	fmt.Printf("Received message from CANCELLED_ORDERS: %s\n", string(message.Value))
 
-	str := string(message.Value)
-	var data map[string]interface{}
-	err := json.Unmarshal([]byte(str), &data)
-	if err != nil {
-		fmt.Println("Error parsing JSON:", err)
-		return
+	var data cancelResponse
+	if err := json.Unmarshal(message.Value, &data); err != nil {
+		return err
 	}
-	dataArr := data["data"].([]interface{})
 
 	var userIDStr string
 	var ClOrdID string
+	var requestID string
 
-	if data["query"].(map[string]interface{})["userId"] != nil || data["query"].(map[string]interface{})["clOrdId"] != nil {
-		userIDStr = data["query"].(map[string]interface{})["userId"].(string)
-		ClOrdID = data["query"].(map[string]interface{})["clOrdId"].(string)
-	} else if len(dataArr) > 0 {
-		userIDStr = dataArr[0].(map[string]interface{})["userId"].(string)
-		ClOrdID = dataArr[0].(map[string]interface{})["clOrdId"].(string)
+	if data.Query.UserID != nil || data.Query.ClOrdID != nil {
+		if data.Query.UserID == nil || data.Query.ClOrdID == nil {
+			return errors.New("incomplete cancel query")
+		}
+		userIDStr = *data.Query.UserID
+		ClOrdID = *data.Query.ClOrdID
+		if data.Query.RequestID != nil {
+			requestID = *data.Query.RequestID
+		}
+	} else if len(data.Data) > 0 {
+		userIDStr = data.Data[0].UserID
+		ClOrdID = data.Data[0].ClOrdID
+		requestID = data.Data[0].RequestID
+	}
+
+	// the engine answered the command, the answers of a command sent twice are only
+	// handled once
+	if !outboxSvc.Acknowledge(requestID) {
+		logs.Log.Info().Str("request_id", requestID).Msg("duplicate engine answer dropped")
+		return nil
 	}
 
 	ID, _ := strconv.ParseUint(ClOrdID, 0, 64)
 
 	connectionKey := utils.GetKeyFromIdUserID(ID, userIDStr)
-	_payload := len(dataArr)
+	_payload := len(data.Data)
 
 	// Metrics
 	collector.EndKafkaDuration(userIDStr, ClOrdID)
 
 	protocol.SendSuccessMsg(connectionKey, _payload)
+
+	return nil
 }
@@ -0,0 +1,280 @@
+package consumer
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gateway/pkg/collector"
+	"gateway/pkg/kafka"
+	"gateway/pkg/kafka/producer"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+const (
+	reasonParse   = "parse"
+	reasonPanic   = "panic"
+	reasonHandler = "handler"
+)
+
+// headers of a dead letter, the value and the key are the original ones
+const (
+	headerTopic     = "x-original-topic"
+	headerPartition = "x-original-partition"
+	headerOffset    = "x-original-offset"
+	headerReason    = "x-reason"
+	headerHandler   = "x-handler"
+	headerError     = "x-error"
+	headerFailedAt  = "x-failed-at"
+)
+
+type pipeline struct {
+	topics topics
+	handle handleFunc
+}
+
+var (
+	currentPipeline *pipeline
+	pipelineMutex   sync.RWMutex
+
+	// a single replay at a time, they share the committed offsets
+	replayMutex sync.Mutex
+)
+
+func setPipeline(t topics, handle handleFunc) {
+	pipelineMutex.Lock()
+	defer pipelineMutex.Unlock()
+
+	currentPipeline = &pipeline{t, handle}
+}
+
+func deadLetterTopic() string {
+	topic, ok := os.LookupEnv("KAFKA_DLQ_TOPIC")
+	if !ok || topic == "" {
+		topic = "GATEWAY_DLQ"
+	}
+
+	return topic
+}
+
+// failure of one of the handlers of a message
+type failure struct {
+	handler string
+	reason  string
+	err     error
+}
+
+// deadLetterFailures parks the message once with every handler which failed, a replay
+// runs only those
+func deadLetterFailures(message *sarama.ConsumerMessage, failures []failure) {
+	reason := reasonHandler
+	handlers := make([]string, 0, len(failures))
+	errs := make([]string, 0, len(failures))
+	for _, f := range failures {
+		if f.reason == reasonPanic {
+			reason = reasonPanic
+		}
+		handlers = append(handlers, f.handler)
+		errs = append(errs, f.handler+": "+f.err.Error())
+	}
+
+	deadLetter(message, reason, handlers, errors.New(strings.Join(errs, "; ")))
+}
+
+// deadLetter parks the message on the dead letter topic with the failure, handlers are
+// the ones to run again, all of them when empty
+func deadLetter(message *sarama.ConsumerMessage, reason string, handlers []string, err error) {
+	logs.Log.Error().
+		Err(err).
+		Str("topic", message.Topic).
+		Int32("partition", message.Partition).
+		Int64("offset", message.Offset).
+		Str("reason", reason).
+		Strs("handlers", handlers).
+		Msg("kafka message dead lettered")
+
+	go func() {
+		collector.KafkaFailureCounter.WithLabelValues(message.Topic, reason).Inc()
+	}()
+
+	dlq := &sarama.ProducerMessage{
+		Topic: deadLetterTopic(),
+		Key:   sarama.ByteEncoder(message.Key),
+		Value: sarama.ByteEncoder(message.Value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(headerTopic), Value: []byte(message.Topic)},
+			{Key: []byte(headerPartition), Value: []byte(strconv.Itoa(int(message.Partition)))},
+			{Key: []byte(headerOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+			{Key: []byte(headerReason), Value: []byte(reason)},
+			{Key: []byte(headerError), Value: []byte(err.Error())},
+			{Key: []byte(headerFailedAt), Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+		},
+	}
+	if len(handlers) > 0 {
+		dlq.Headers = append(dlq.Headers, sarama.RecordHeader{
+			Key:   []byte(headerHandler),
+			Value: []byte(strings.Join(handlers, ",")),
+		})
+	}
+
+	if err := producer.Send(dlq); err != nil {
+		logs.Log.Error().Err(err).Str("value", string(message.Value)).Msg("failed to send dead letter")
+	}
+}
+
+func header(message *sarama.ConsumerMessage, key string) string {
+	for _, h := range message.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+// ReplayDeadLetters runs the dead letters through the handlers which failed again, from
+// the last replayed one up to the latest one or the limit. A message failing again goes
+// back to the dead letter topic
+func ReplayDeadLetters(limit int) (int, error) {
+	pipelineMutex.RLock()
+	p := currentPipeline
+	pipelineMutex.RUnlock()
+
+	if p == nil {
+		return 0, errors.New("kafka consumer is not running")
+	}
+
+	replayMutex.Lock()
+	defer replayMutex.Unlock()
+
+	config, err := newConsumerConfig()
+	if err != nil {
+		return 0, err
+	}
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	client, err := sarama.NewClient(kafka.Brokers(), config)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	offsets, err := sarama.NewOffsetManagerFromClient(consumerGroupID()+"-dlq-replay", client)
+	if err != nil {
+		return 0, err
+	}
+	defer offsets.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		return 0, err
+	}
+	defer consumer.Close()
+
+	topic := deadLetterTopic()
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, partition := range partitions {
+		if limit > 0 && replayed >= limit {
+			break
+		}
+
+		n, err := replayPartition(p, client, offsets, consumer, topic, partition, limit-replayed)
+		replayed += n
+		if err != nil {
+			return replayed, err
+		}
+	}
+
+	return replayed, nil
+}
+
+func replayPartition(
+	p *pipeline,
+	client sarama.Client,
+	offsets sarama.OffsetManager,
+	consumer sarama.Consumer,
+	topic string,
+	partition int32,
+	limit int,
+) (int, error) {
+	newest, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, err
+	}
+
+	pom, err := offsets.ManagePartition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+	// committed when the offset manager is closed
+	defer pom.AsyncClose()
+
+	next, _ := pom.NextOffset()
+	if next < 0 {
+		if next, err = client.GetOffset(topic, partition, sarama.OffsetOldest); err != nil {
+			return 0, err
+		}
+	}
+
+	if next >= newest {
+		return 0, nil
+	}
+
+	pc, err := consumer.ConsumePartition(topic, partition, next)
+	if err != nil {
+		return 0, err
+	}
+	defer pc.Close()
+
+	replayed := 0
+	for message := range pc.Messages() {
+		original := &sarama.ConsumerMessage{
+			Topic:     header(message, headerTopic),
+			Key:       message.Key,
+			Value:     message.Value,
+			Timestamp: message.Timestamp,
+		}
+		original.Partition = int32(parseInt(header(message, headerPartition)))
+		original.Offset = parseInt(header(message, headerOffset))
+
+		if logical, ok := p.topics[original.Topic]; ok {
+			<-p.handle(logical, original, handlers(message))
+			replayed++
+		} else {
+			logs.Log.Warn().Str("topic", original.Topic).Msg("dead letter of an unknown topic skipped")
+		}
+
+		pom.MarkOffset(message.Offset+1, "")
+
+		if message.Offset+1 >= newest || (limit > 0 && replayed >= limit) {
+			break
+		}
+	}
+
+	return replayed, nil
+}
+
+// handlers of the dead letter to run again, all of them when it could not be decoded
+func handlers(message *sarama.ConsumerMessage) []string {
+	value := header(message, headerHandler)
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+func parseInt(s string) int64 {
+	v, _ := strconv.ParseInt(s, 10, 64)
+	return v
+}
@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"encoding/json"
+
+	_engineType "gateway/internal/engine/types"
+	"gateway/pkg/kafka/event"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/models/kafka"
+)
+
+// cancelResponse is the answer of the engine to a cancel request
+type cancelResponse struct {
+	Data []struct {
+		UserID    string `json:"userId"`
+		ClOrdID   string `json:"clOrdId"`
+		RequestID string `json:"requestId"`
+	} `json:"data"`
+	Query struct {
+		UserID    *string `json:"userId"`
+		ClOrdID   *string `json:"clOrdId"`
+		RequestID *string `json:"requestId"`
+	} `json:"query"`
+}
+
+// the formats sent before the envelope, a new version of the engine registers its
+// decoder here and converts to the same types
+func init() {
+	event.Register(topicEngine, event.Legacy, decodeEngineResponse)
+	event.Register(topicEngineSaved, event.Legacy, decodeEngineResponse)
+	event.Register(topicCancelledOrder, event.Legacy, func(payload []byte) (interface{}, error) {
+		var data cancelResponse
+		err := json.Unmarshal(payload, &data)
+		return data, err
+	})
+	event.Register(topicCancelledOrderSaved, event.Legacy, func(payload []byte) (interface{}, error) {
+		var data kafka.CancelledOrder
+		err := json.Unmarshal(payload, &data)
+		return data, err
+	})
+	event.Register(topicPrices, event.Legacy, func(payload []byte) (interface{}, error) {
+		var data _engineType.MessagePrices
+		err := json.Unmarshal(payload, &data)
+		return data, err
+	})
+}
+
+func decodeEngineResponse(payload []byte) (interface{}, error) {
+	var data _engineType.EngineResponse
+	err := json.Unmarshal(payload, &data)
+	return data, err
+}
@@ -0,0 +1,155 @@
+package consumer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gateway/pkg/kafka"
+
+	"github.com/Shopify/sarama"
+)
+
+// logical topics, the actual topic names can be overridden with the environment
+const (
+	topicEngine              = "ENGINE"
+	topicEngineSaved         = "ENGINE_SAVED"
+	topicCancelledOrder      = "CANCELLED_ORDER"
+	topicCancelledOrderSaved = "CANCELLED_ORDER_SAVED"
+	topicPrices              = "PRICES"
+)
+
+// topics maps the configured topic name to the logical topic
+type topics map[string]string
+
+func newTopics() topics {
+	t := topics{}
+	for _, topic := range []string{
+		topicEngine,
+		topicEngineSaved,
+		topicCancelledOrder,
+		topicCancelledOrderSaved,
+		topicPrices,
+	} {
+		// e.g. KAFKA_TOPIC_ENGINE_SAVED
+		name, ok := os.LookupEnv("KAFKA_TOPIC_" + topic)
+		if !ok || name == "" {
+			name = topic
+		}
+		t[name] = topic
+	}
+
+	return t
+}
+
+func (t topics) names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func consumerGroupID() string {
+	id, ok := os.LookupEnv("KAFKA_CONSUMER_GROUP")
+	if !ok || id == "" {
+		id = "gateway"
+	}
+
+	return id
+}
+
+func newConsumerConfig() (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+
+	v, err := kafka.Version()
+	if err != nil {
+		return nil, err
+	}
+	config.Version = v
+
+	// only used when the group has no committed offset for the partition
+	switch strings.ToLower(os.Getenv("KAFKA_CONSUMER_OFFSET")) {
+	case "", "newest":
+		config.Consumer.Offsets.Initial = sarama.OffsetNewest
+	case "oldest":
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	default:
+		return nil, fmt.Errorf("unknown KAFKA_CONSUMER_OFFSET '%s'", os.Getenv("KAFKA_CONSUMER_OFFSET"))
+	}
+
+	return config, nil
+}
+
+func consumerWorkers() int {
+	workers, err := strconv.Atoi(os.Getenv("KAFKA_CONSUMER_WORKERS"))
+	if err != nil || workers < 1 {
+		workers = 16
+	}
+
+	return workers
+}
+
+// groupHandler consumes every claimed partition, the messages are handed to the
+// dispatcher and marked in the partition order once handled so they are committed
+type groupHandler struct {
+	topics topics
+	handle handleFunc
+}
+
+// handleFunc runs the handlers of the message, only the named ones when handlers is not
+// empty. The channel is closed once they are done
+type handleFunc func(topic string, message *sarama.ConsumerMessage, handlers []string) <-chan struct{}
+
+type pendingMessage struct {
+	message *sarama.ConsumerMessage
+	done    <-chan struct{}
+}
+
+func (h *groupHandler) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *groupHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	topic := h.topics[claim.Topic()]
+
+	// bounds the messages in flight of the partition
+	pending := make(chan pendingMessage, 256)
+	marked := make(chan struct{})
+	go func() {
+		defer close(marked)
+		for p := range pending {
+			select {
+			case <-p.done:
+				session.MarkMessage(p.message, "")
+			case <-session.Context().Done():
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		close(pending)
+		<-marked
+	}()
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+
+			select {
+			case pending <- pendingMessage{message, h.handle(topic, message, nil)}:
+			case <-session.Context().Done():
+				return nil
+			}
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
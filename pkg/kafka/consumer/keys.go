@@ -0,0 +1,80 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	_engineType "gateway/internal/engine/types"
+	"gateway/pkg/kafka/event"
+
+	"github.com/Shopify/sarama"
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+	"github.com/Undercurrent-Technologies/kprime-utilities/models/kafka"
+)
+
+// decodeMessage decodes the event of the message and returns the message in the format
+// the handlers read with the dispatcher keys of the message, the instruments for the
+// engine events and the user for the responses of a cancel request
+func decodeMessage(topic string, message *sarama.ConsumerMessage) (*sarama.ConsumerMessage, []string, error) {
+	e, err := event.Decode(topic, message.Value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e.Type != topic {
+		return nil, nil, fmt.Errorf("unexpected event %s on %s", e.Type, topic)
+	}
+
+	if e.Enveloped {
+		logs.Log.Debug().
+			Str("type", e.Type).
+			Str("version", e.Version).
+			Str("producer", e.Producer).
+			Str("correlationId", e.CorrelationID).
+			Msg("kafka event received")
+
+		value, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		copied := *message
+		copied.Value = value
+		message = &copied
+	}
+
+	return message, keys(e.Data), nil
+}
+
+func keys(data interface{}) []string {
+	switch data := data.(type) {
+	case _engineType.EngineResponse:
+		if data.Matches == nil || data.Matches.TakerOrder == nil || len(data.Matches.TakerOrder.Contracts) == 0 {
+			return nil
+		}
+
+		order := data.Matches.TakerOrder
+		return []string{order.Underlying + "-" + order.ExpiryDate + "-" + fmt.Sprintf("%.0f", order.StrikePrice) + "-" + string(order.Contracts[0])}
+	case kafka.CancelledOrder:
+		// a mass cancel runs once it is the next message of every instrument
+		keys := []string{}
+		for _, order := range data.Data {
+			if len(order.Contracts) == 0 {
+				continue
+			}
+			keys = append(keys, order.Underlying+"-"+order.ExpiryDate+"-"+fmt.Sprintf("%.0f", order.StrikePrice)+"-"+string(order.Contracts[0]))
+		}
+
+		return keys
+	case cancelResponse:
+		if data.Query.UserID == nil {
+			return nil
+		}
+
+		return []string{*data.Query.UserID}
+	case _engineType.MessagePrices:
+		return []string{topicPrices}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Legacy is the version of the payloads sent without an envelope
+const Legacy = "v0"
+
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Envelope wraps a payload with the metadata needed to decode it
+type Envelope struct {
+	Type          string          `json:"type"`
+	Version       string          `json:"version"`
+	Producer      string          `json:"producer,omitempty"`
+	Timestamp     time.Time       `json:"timestamp"`
+	CorrelationID string          `json:"correlationId,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Event is a decoded payload, Data has the type the decoder of the version returns
+type Event struct {
+	Envelope
+	Data interface{}
+
+	// false for a legacy value, the payload is the whole value
+	Enveloped bool
+}
+
+// Decoder decodes the payload of a version into the type the handlers use, a new
+// version converts its payload so the handlers are unchanged
+type Decoder func(payload []byte) (interface{}, error)
+
+var (
+	decoders = map[string]Decoder{}
+	mu       sync.RWMutex
+)
+
+func key(eventType, version string) string {
+	return eventType + "/" + version
+}
+
+// Register adds the decoder of a version of an event type
+func Register(eventType, version string, decoder Decoder) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	decoders[key(eventType, version)] = decoder
+}
+
+func decoder(eventType, version string) (Decoder, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	d, ok := decoders[key(eventType, version)]
+	return d, ok
+}
+
+// Decode decodes an enveloped value, a value without an envelope is the legacy
+// version of the fallback type
+func Decode(fallback string, value []byte) (*Event, error) {
+	var e Envelope
+	if err := json.Unmarshal(value, &e); err != nil {
+		return nil, err
+	}
+
+	enveloped := e.Type != "" && e.Version != "" && len(e.Payload) > 0
+	if !enveloped {
+		e = Envelope{
+			Type:    fallback,
+			Version: Legacy,
+			Payload: value,
+		}
+	}
+
+	d, ok := decoder(e.Type, e.Version)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrUnknownEvent, e.Type, e.Version)
+	}
+
+	data, err := d(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s %s: %w", e.Type, e.Version, err)
+	}
+
+	return &Event{e, data, enveloped}, nil
+}
@@ -0,0 +1,58 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+func init() {
+	Register("TEST", Legacy, func(payload []byte) (interface{}, error) {
+		var o order
+		err := json.Unmarshal(payload, &o)
+		return o, err
+	})
+
+	// v1 sends the price as a string
+	Register("TEST", "v1", func(payload []byte) (interface{}, error) {
+		var o struct {
+			ID    string      `json:"id"`
+			Price json.Number `json:"price"`
+		}
+		if err := json.Unmarshal(payload, &o); err != nil {
+			return nil, err
+		}
+
+		price, err := o.Price.Float64()
+		return order{o.ID, price}, err
+	})
+}
+
+func TestDecodeLegacy(t *testing.T) {
+	e, err := Decode("TEST", []byte(`{"id":"a","price":1.5}`))
+	assert.NoError(t, err)
+	assert.False(t, e.Enveloped)
+	assert.Equal(t, Legacy, e.Version)
+	assert.Equal(t, order{"a", 1.5}, e.Data)
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	e, err := Decode("OTHER", []byte(`{"type":"TEST","version":"v1","producer":"engine","correlationId":"c","payload":{"id":"a","price":"1.5"}}`))
+	assert.NoError(t, err)
+	assert.True(t, e.Enveloped)
+	assert.Equal(t, "engine", e.Producer)
+	assert.Equal(t, "c", e.CorrelationID)
+	assert.Equal(t, order{"a", 1.5}, e.Data)
+}
+
+func TestDecodeUnknownVersion(t *testing.T) {
+	_, err := Decode("TEST", []byte(`{"type":"TEST","version":"v9","payload":{}}`))
+	assert.True(t, errors.Is(err, ErrUnknownEvent))
+}
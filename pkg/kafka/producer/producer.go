@@ -1,37 +1,145 @@
 package producer
 
 import (
+	"errors"
 	"fmt"
 	"gateway/pkg/collector"
+	"gateway/pkg/kafka"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
 )
 
-func KafkaProducer(obj string, topic string) {
+// ErrSendTimeout is returned when the brokers did not acknowledge the message in time
+var ErrSendTimeout = errors.New("kafka send timeout")
+
+var (
+	producer sarama.AsyncProducer
+	mu       sync.Mutex
+)
+
+// result is passed through the message metadata to answer the sender
+type result chan error
+
+func newConfig() (*sarama.Config, error) {
+	version, err := kafka.Version()
+	if err != nil {
+		return nil, err
+	}
+
 	config := sarama.NewConfig()
+	config.Version = version
 	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
 	config.Producer.Compression = sarama.CompressionLZ4
+
+	// idempotent delivery, a retried message is written once
+	config.Producer.Idempotent = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
-	config.Producer.Flush.Frequency = 1000
+	config.Net.MaxOpenRequests = 1
+
+	// batching
+	config.Producer.Flush.Frequency = 5 * time.Millisecond
+	config.Producer.Flush.Messages = 100
+
+	// retries with exponential backoff
+	config.Producer.Retry.Max = 5
+	config.Producer.Retry.BackoffFunc = func(retries, maxRetries int) time.Duration {
+		return time.Duration(100*(1<<retries)) * time.Millisecond
+	}
+
+	return config, nil
+}
+
+// getProducer returns the shared producer, it is created on the first send and again
+// after a failed creation
+func getProducer() (sarama.AsyncProducer, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if producer != nil {
+		return producer, nil
+	}
 
-	producer, err := sarama.NewSyncProducer([]string{os.Getenv("KAFKA_BROKER")}, config)
+	config, err := newConfig()
 	if err != nil {
-		logs.Log.Error().Err(err).Msg("failed to create producer")
+		return nil, err
+	}
+
+	p, err := sarama.NewAsyncProducer(kafka.Brokers(), config)
+	if err != nil {
+		return nil, err
 	}
-	defer producer.Close()
 
-	_topic := topic
+	go func() {
+		for msg := range p.Successes() {
+			logs.Log.Debug().Str("topic", msg.Topic).Int32("partition", msg.Partition).Int64("offset", msg.Offset).Msg("kafka message sent")
+			msg.Metadata.(result) <- nil
+		}
+	}()
 
-	message := &sarama.ProducerMessage{
-		Topic: _topic,
+	go func() {
+		for err := range p.Errors() {
+			logs.Log.Error().Err(err.Err).Str("topic", err.Msg.Topic).Msg("failed to send message")
+			err.Msg.Metadata.(result) <- err.Err
+		}
+	}()
+
+	producer = p
+	return producer, nil
+}
+
+func sendTimeout() time.Duration {
+	timeout, err := strconv.Atoi(os.Getenv("KAFKA_PRODUCER_TIMEOUT"))
+	if err != nil || timeout < 1 {
+		timeout = 10
+	}
+
+	return time.Duration(timeout) * time.Second
+}
+
+// KafkaProducer sends the message through the shared producer and waits until the
+// brokers acknowledged it, the error is returned once the retries are exhausted
+func KafkaProducer(obj string, topic string) error {
+	return Send(&sarama.ProducerMessage{
+		Topic: topic,
 		Value: sarama.StringEncoder(obj),
+	})
+}
+
+// Send is KafkaProducer for a message with a key or headers
+func Send(message *sarama.ProducerMessage) error {
+	p, err := getProducer()
+	if err != nil {
+		logs.Log.Error().Err(err).Msg("failed to create producer")
+		return err
+	}
+
+	// buffered, the answer is never blocked by a sender which gave up
+	res := make(result, 1)
+	message.Metadata = res
+
+	timer := time.NewTimer(sendTimeout())
+	defer timer.Stop()
+
+	select {
+	case p.Input() <- message:
+	case <-timer.C:
+		return ErrSendTimeout
+	}
+
+	select {
+	case err = <-res:
+	case <-timer.C:
+		return ErrSendTimeout
 	}
 
-	partition, offset, err := producer.SendMessage(message)
 	if err != nil {
-		logs.Log.Error().Err(err).Msg("failed to send message")
+		return fmt.Errorf("failed to send message: %w", err)
 	}
 
 	// Metrics
@@ -39,5 +147,20 @@ func KafkaProducer(obj string, topic string) {
 		collector.OutgoingKafkaCounter.Inc()
 	}()
 
-	fmt.Println("Kafka message sent to topic", topic, "partition", partition, "offset", offset)
+	return nil
+}
+
+// Close flushes the buffered messages and closes the shared producer
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if producer == nil {
+		return
+	}
+
+	if err := producer.Close(); err != nil {
+		logs.Log.Error().Err(err).Msg("failed to close producer")
+	}
+	producer = nil
 }
@@ -0,0 +1,35 @@
+package linked
+
+// the linked order types of an order and the orders of its otoco config
+const (
+	OneCancelsOther            = "one_cancels_other"
+	OneTriggersOther           = "one_triggers_other"
+	OneTriggersOneCancelsOther = "one_triggers_one_cancels_other"
+)
+
+// IsType returns whether the type is a linked order type
+func IsType(linkedType string) bool {
+	switch linkedType {
+	case OneCancelsOther, OneTriggersOther, OneTriggersOneCancelsOther:
+		return true
+	}
+
+	return false
+}
+
+// Triggers returns whether the orders of the otoco config are placed once the primary
+// order is filled, they are placed with the primary order otherwise
+func Triggers(linkedType string) bool {
+	return linkedType == OneTriggersOther || linkedType == OneTriggersOneCancelsOther
+}
+
+// PrimaryInOco returns whether the primary order cancels the orders of the otoco config
+// once filled, and the other way around
+func PrimaryInOco(linkedType string) bool {
+	return linkedType == OneCancelsOther
+}
+
+// LegsInOco returns whether the orders of the otoco config cancel each other once filled
+func LegsInOco(linkedType string) bool {
+	return linkedType == OneCancelsOther || linkedType == OneTriggersOneCancelsOther
+}
@@ -0,0 +1,32 @@
+package linked
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsType(t *testing.T) {
+	assert.True(t, IsType(OneCancelsOther))
+	assert.True(t, IsType(OneTriggersOther))
+	assert.True(t, IsType(OneTriggersOneCancelsOther))
+	assert.False(t, IsType(""))
+	assert.False(t, IsType("one_cancels_all"))
+}
+
+func TestGraph(t *testing.T) {
+	// oco: every order is placed at once and cancels the others
+	assert.False(t, Triggers(OneCancelsOther))
+	assert.True(t, PrimaryInOco(OneCancelsOther))
+	assert.True(t, LegsInOco(OneCancelsOther))
+
+	// oto: the legs are placed once the primary is filled
+	assert.True(t, Triggers(OneTriggersOther))
+	assert.False(t, PrimaryInOco(OneTriggersOther))
+	assert.False(t, LegsInOco(OneTriggersOther))
+
+	// otoco: the legs are placed once the primary is filled and cancel each other
+	assert.True(t, Triggers(OneTriggersOneCancelsOther))
+	assert.False(t, PrimaryInOco(OneTriggersOneCancelsOther))
+	assert.True(t, LegsInOco(OneTriggersOneCancelsOther))
+}
@@ -8,6 +8,7 @@ import (
 	"gateway/pkg/hmac"
 	"gateway/pkg/memdb"
 	"gateway/pkg/protocol"
+	"gateway/pkg/ws"
 	"io"
 	"net/http"
 	"strings"
@@ -32,6 +33,25 @@ func Authenticate() gin.HandlerFunc {
 				c.AbortWithStatus(http.StatusBadRequest)
 			}
 
+			// JSON-RPC batch, each request is validated by the handler
+			if ws.IsBatchPayload(body) {
+				var requests []gin.H
+				if err := json.Unmarshal(body, &requests); err != nil {
+					logs.Log.Err(err).Msg("")
+					c.AbortWithStatus(http.StatusBadRequest)
+					return
+				}
+
+				for _, data := range requests {
+					if method, ok := data["method"].(string); ok && strings.Contains(method, "private") {
+						isPrivateMethod = true
+					}
+				}
+
+				c.Set("body", body)
+				break
+			}
+
 			var data gin.H
 			if err := json.Unmarshal(body, &data); err != nil {
 				logs.Log.Err(err).Msg("")
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"gateway/pkg/protocol"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+	"github.com/ulule/limiter/v3"
+	"google.golang.org/grpc/peer"
+)
+
+// MiddlewareGrpc is the rate limiter of the grpc requests.
+type MiddlewareGrpc struct {
+	Limiter *limiter.Limiter
+}
+
+// RateLimiterGrpc returns the rate limiter of the grpc requests, they are charged to the
+// connection of the rpc as the websocket requests are
+func RateLimiterGrpc(limiter *limiter.Limiter) *MiddlewareGrpc {
+	return &MiddlewareGrpc{
+		Limiter: limiter,
+	}
+}
+
+// Handle returns the answer of the request once the limit of its connection is reached,
+// nil otherwise
+func (middleware *MiddlewareGrpc) Handle(ctx context.Context, id uint64) *protocol.RPCResponseMessage {
+	key := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		key = p.Addr.String()
+	}
+
+	limit, err := middleware.Limiter.Get(ctx, key)
+	if err != nil {
+		return &protocol.RPCResponseMessage{
+			Error: &protocol.ErrorMessage{
+				Message: err.Error(),
+			},
+		}
+	}
+
+	if limit.Reached {
+		return &protocol.RPCResponseMessage{
+			JSONRPC: "2.0",
+			ID:      id,
+			Result:  nil,
+			Error: &protocol.ErrorMessage{
+				Message: validation_reason.TOO_MANY_REQUESTS.String(),
+				Data: protocol.ReasonMessage{
+					Reason: validation_reason.TOO_MANY_REQUESTS.String(),
+				},
+				Code: 10028,
+			},
+			Testnet: true,
+		}
+	}
+
+	return nil
+}
@@ -27,6 +27,19 @@ func RateLimiterWs(input interface{}, c *ws.Client) *protocol.RPCResponseMessage
 		Limiter: WSLimiter,
 	}
 
+	// a batch is charged as a single request
+	if msg, ok := input.(ws.WebsocketMessage); ok && msg.Batch != nil {
+		allowed := msg.Batch.Allow(func() bool {
+			limit, err := middleware.Limiter.Get(context.TODO(), c.RemoteAddr().String())
+			return err == nil && !limit.Reached
+		})
+		if allowed {
+			return nil
+		}
+
+		return middleware.HandleLimitReachedWs(c, msg.ID)
+	}
+
 	return middleware.Handle(c)
 }
 
@@ -43,13 +56,13 @@ func (middleware *MiddlewareWs) Handle(c *ws.Client) *protocol.RPCResponseMessag
 	}
 
 	if context.Reached {
-		return middleware.HandleLimitReachedWs(c)
+		return middleware.HandleLimitReachedWs(c, nil)
 	}
 
 	return nil
 }
 
-func (middleware *MiddlewareWs) HandleLimitReachedWs(c *ws.Client) *protocol.RPCResponseMessage {
+func (middleware *MiddlewareWs) HandleLimitReachedWs(c *ws.Client, id *uint64) *protocol.RPCResponseMessage {
 	m := protocol.RPCResponseMessage{
 		JSONRPC: "2.0",
 		Result:  nil,
@@ -62,6 +75,10 @@ func (middleware *MiddlewareWs) HandleLimitReachedWs(c *ws.Client) *protocol.RPC
 		},
 		Testnet: true,
 	}
+	if id != nil {
+		m.ID = *id
+	}
+
 	msg := ws.WebsocketResponseMessage{
 		JSONRPC: m.JSONRPC,
 		ID:      m.ID,
@@ -0,0 +1,120 @@
+package pricing
+
+import "math"
+
+func normCdf(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normPdf(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+func d1d2(f, k, t, vol float64) (float64, float64) {
+	sd := vol * math.Sqrt(t)
+	d1 := (math.Log(f/k) + sd*sd/2) / sd
+	return d1, d1 - sd
+}
+
+// black76 returns the discounted price of an option on the forward f
+func black76(call bool, f, k, t, r, vol float64) float64 {
+	df := math.Exp(-r * t)
+	if t <= 0 || vol <= 0 {
+		if call {
+			return df * math.Max(f-k, 0)
+		}
+		return df * math.Max(k-f, 0)
+	}
+
+	d1, d2 := d1d2(f, k, t, vol)
+	if call {
+		return df * (f*normCdf(d1) - k*normCdf(d2))
+	}
+	return df * (k*normCdf(-d2) - f*normCdf(-d1))
+}
+
+// Greeks are the sensitivities to the index, the forward is the index grown at the rate.
+// Vega is per vol point, theta per calendar day and rho per rate point
+type Greeks struct {
+	Delta float64 `json:"delta"`
+	Gamma float64 `json:"gamma"`
+	Vega  float64 `json:"vega"`
+	Theta float64 `json:"theta"`
+	Rho   float64 `json:"rho"`
+}
+
+// greeks are the Black-Scholes greeks on the index s, the price is the Black-76 one on
+// the forward s·e^(rt)
+func greeks(call bool, s, k, t, r, vol float64) Greeks {
+	if t <= 0 || vol <= 0 || s <= 0 || k <= 0 {
+		return Greeks{}
+	}
+
+	df := math.Exp(-r * t)
+	d1, d2 := d1d2(s/df, k, t, vol)
+	decay := -s * normPdf(d1) * vol / (2 * math.Sqrt(t))
+
+	g := Greeks{
+		Gamma: normPdf(d1) / (s * vol * math.Sqrt(t)),
+		Vega:  s * normPdf(d1) * math.Sqrt(t) / 100,
+	}
+
+	if call {
+		g.Delta = normCdf(d1)
+		g.Theta = (decay - r*k*df*normCdf(d2)) / 365
+		g.Rho = k * t * df * normCdf(d2) / 100
+	} else {
+		g.Delta = normCdf(d1) - 1
+		g.Theta = (decay + r*k*df*normCdf(-d2)) / 365
+		g.Rho = -k * t * df * normCdf(-d2) / 100
+	}
+
+	return g
+}
+
+// impliedVol solves the Black-76 volatility of the price, it is 0 when the price is out of
+// the no-arbitrage bounds
+func impliedVol(call bool, price, f, k, t, r float64) float64 {
+	if t <= 0 || price <= 0 || f <= 0 || k <= 0 {
+		return 0
+	}
+
+	const (
+		low       = 1e-4
+		high      = 10.0
+		tolerance = 1e-10
+	)
+
+	if price <= black76(call, f, k, t, r, low) || price >= black76(call, f, k, t, r, high) {
+		return 0
+	}
+
+	// newton steps, kept inside the bracket by bisection
+	lo, hi := low, high
+	vol := math.Sqrt(2 * math.Abs(math.Log(f/k)) / t)
+	if vol < low || vol > high {
+		vol = 0.5
+	}
+	for i := 0; i < 100; i++ {
+		diff := black76(call, f, k, t, r, vol) - price
+		if math.Abs(diff) < tolerance*price || hi-lo < tolerance {
+			return vol
+		}
+
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+
+		d1, _ := d1d2(f, k, t, vol)
+		vega := f * math.Exp(-r*t) * normPdf(d1) * math.Sqrt(t)
+		next := vol - diff/vega
+		if vega <= 0 || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		vol = next
+	}
+
+	return vol
+}
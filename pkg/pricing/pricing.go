@@ -0,0 +1,135 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gateway/pkg/clock"
+)
+
+// instruments expire at the expiry time of their expiry date, in the venue location
+const (
+	defaultExpiryLocation = "Singapore"
+	defaultExpiryTime     = "24:00"
+	defaultRate           = 0.05
+)
+
+var (
+	configOnce     sync.Once
+	expiryLocation *time.Location
+	expiryOffset   time.Duration
+)
+
+// config is read on first use, after the env file is loaded
+func config() {
+	configOnce.Do(func() {
+		expiryLocation = clock.Location("INSTRUMENT_EXPIRY_LOCATION", defaultExpiryLocation)
+		expiryOffset = clock.TimeOfDay("INSTRUMENT_EXPIRY_TIME", defaultExpiryTime)
+	})
+}
+
+// Expiry returns the exact expiry of an expiry date such as 28JAN22
+func Expiry(expiryDate string) (time.Time, error) {
+	config()
+
+	date, err := time.ParseInLocation("02Jan06", expiryDate, expiryLocation)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return date.Add(expiryOffset), nil
+}
+
+// Rate returns the risk-free rate of the underlying, RISK_FREE_RATE_BTC overrides
+// RISK_FREE_RATE for BTC
+func Rate(underlying string) float64 {
+	for _, key := range []string{"RISK_FREE_RATE_" + strings.ToUpper(underlying), "RISK_FREE_RATE"} {
+		if v, ok := os.LookupEnv(key); ok {
+			if r, err := strconv.ParseFloat(v, 64); err == nil {
+				return r
+			}
+		}
+	}
+
+	return defaultRate
+}
+
+// Option is an european option of an instrument, priced with Black-76 on the forward of
+// the index of its underlying
+type Option struct {
+	Underlying string
+	Call       bool
+	Strike     float64
+	Expiry     time.Time
+	Rate       float64
+}
+
+func NewOption(underlying, expiryDate string, strike float64, call bool) (Option, error) {
+	expiry, err := Expiry(expiryDate)
+	if err != nil {
+		return Option{}, err
+	}
+
+	return Option{
+		Underlying: underlying,
+		Call:       call,
+		Strike:     strike,
+		Expiry:     expiry,
+		Rate:       Rate(underlying),
+	}, nil
+}
+
+// ParseOption returns the option of an instrument name, e.g. BTC-28JAN22-50000-C
+func ParseOption(instrument string) (Option, error) {
+	parts := strings.Split(instrument, "-")
+	if len(parts) != 4 || parts[3] == "" {
+		return Option{}, errors.New("invalid instrument")
+	}
+
+	strike, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return Option{}, err
+	}
+
+	return NewOption(strings.ToUpper(parts[0]), strings.ToUpper(parts[1]), strike, strings.ToUpper(parts[3])[0] == 'C')
+}
+
+// Years returns the time to expiry in years of 365 days
+func (o Option) Years(now time.Time) float64 {
+	return math.Max(o.Expiry.Sub(now).Hours()/24/365, 0)
+}
+
+// Forward returns the forward of the index at expiry
+func (o Option) Forward(index float64, now time.Time) float64 {
+	return index * math.Exp(o.Rate*o.Years(now))
+}
+
+func (o Option) Price(index, vol float64, now time.Time) float64 {
+	return black76(o.Call, o.Forward(index, now), o.Strike, o.Years(now), o.Rate, vol)
+}
+
+// ImpliedVol returns the volatility of the price as a fraction, 0 when there is none
+func (o Option) ImpliedVol(price, index float64, now time.Time) float64 {
+	return impliedVol(o.Call, price, o.Forward(index, now), o.Strike, o.Years(now), o.Rate)
+}
+
+// Greeks returns the greeks of the option with respect to the index
+func (o Option) Greeks(index, vol float64, now time.Time) Greeks {
+	return greeks(o.Call, index, o.Strike, o.Years(now), o.Rate, vol)
+}
+
+// ImpliedVol returns the volatility of a price of the instrument at the time, 0 when
+// the instrument name is invalid
+func ImpliedVol(instrument string, price, index float64, at time.Time) float64 {
+	o, err := ParseOption(instrument)
+	if err != nil {
+		return 0
+	}
+
+	return o.ImpliedVol(price, index, at)
+}
@@ -0,0 +1,100 @@
+package pricing
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiry(t *testing.T) {
+	t.Setenv("INSTRUMENT_EXPIRY_LOCATION", "UTC")
+	t.Setenv("INSTRUMENT_EXPIRY_TIME", "08:00")
+	configOnce = sync.Once{}
+
+	expiry, err := Expiry("31JAN23")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 1, 31, 8, 0, 0, 0, time.UTC), expiry.UTC())
+
+	o, err := ParseOption("BTC-31JAN23-20000-C")
+	assert.NoError(t, err)
+
+	// across the month boundary
+	now := time.Date(2023, 1, 29, 20, 0, 0, 0, time.UTC)
+	assert.InDelta(t, 1.5/365, o.Years(now), 1e-12)
+	assert.Equal(t, 0.0, o.Years(expiry.Add(time.Hour)))
+}
+
+func TestRate(t *testing.T) {
+	t.Setenv("RISK_FREE_RATE", "0.03")
+	t.Setenv("RISK_FREE_RATE_ETH", "0.01")
+
+	assert.Equal(t, 0.03, Rate("BTC"))
+	assert.Equal(t, 0.01, Rate("eth"))
+}
+
+func TestBlack76(t *testing.T) {
+	// Hull, Options Futures and Other Derivatives, example 18.8
+	price := black76(false, 20, 20, 4.0/12, 0.09, 0.25)
+	assert.InDelta(t, 1.12, price, 0.005)
+
+	// put-call parity on the forward
+	f, k, ty, r, vol := 30000.0, 32000.0, 0.25, 0.05, 0.7
+	call := black76(true, f, k, ty, r, vol)
+	put := black76(false, f, k, ty, r, vol)
+	assert.InDelta(t, math.Exp(-r*ty)*(f-k), call-put, 1e-8)
+}
+
+func TestImpliedVolRoundTrip(t *testing.T) {
+	for _, call := range []bool{true, false} {
+		for _, k := range []float64{20000, 28000, 30000, 33000, 40000} {
+			for _, vol := range []float64{0.45, 0.8, 1.5} {
+				price := black76(call, 30000, k, 0.1, 0.05, vol)
+				assert.InDelta(t, vol, impliedVol(call, price, 30000, k, 0.1, 0.05), 1e-6)
+			}
+		}
+	}
+
+	// below the intrinsic value
+	assert.Equal(t, 0.0, impliedVol(true, 100, 30000, 20000, 0.1, 0.05))
+	assert.Equal(t, 0.0, impliedVol(true, 100, 30000, 20000, 0, 0.05))
+}
+
+func TestGreeks(t *testing.T) {
+	// Hull, Options Futures and Other Derivatives, examples 19.1 to 19.7
+	s, k, ty, r, vol := 49.0, 50.0, 20.0/52, 0.05, 0.2
+	g := greeks(true, s, k, ty, r, vol)
+	assert.InDelta(t, 0.522, g.Delta, 0.0005)
+	assert.InDelta(t, 0.066, g.Gamma, 0.0005)
+	assert.InDelta(t, 12.1/100, g.Vega, 0.0005)
+	assert.InDelta(t, -4.31/365, g.Theta, 0.0005)
+	assert.InDelta(t, 8.91/100, g.Rho, 0.0005)
+
+	put := greeks(false, s, k, ty, r, vol)
+	assert.InDelta(t, 1, g.Delta-put.Delta, 1e-9)
+	assert.InDelta(t, g.Gamma, put.Gamma, 1e-12)
+	assert.InDelta(t, g.Vega, put.Vega, 1e-12)
+	assert.Less(t, put.Rho, 0.0)
+}
+
+func TestGreeksOnIndex(t *testing.T) {
+	s, k, ty, r, vol := 30000.0, 31000.0, 0.2, 0.05, 0.6
+
+	for _, call := range []bool{true, false} {
+		g := greeks(call, s, k, ty, r, vol)
+
+		// the price of the option on the forward of the index
+		price := func(s, ty, r, vol float64) float64 {
+			return black76(call, s*math.Exp(r*ty), k, ty, r, vol)
+		}
+
+		const h = 1e-3
+		assert.InDelta(t, (price(s+h, ty, r, vol)-price(s-h, ty, r, vol))/(2*h), g.Delta, 1e-6)
+		assert.InDelta(t, (price(s+1, ty, r, vol) - 2*price(s, ty, r, vol) + price(s-1, ty, r, vol)), g.Gamma, 1e-7)
+		assert.InDelta(t, (price(s, ty, r, vol+h)-price(s, ty, r, vol-h))/(2*h)/100, g.Vega, 1e-4)
+		assert.InDelta(t, -(price(s, ty+h, r, vol)-price(s, ty-h, r, vol))/(2*h)/365, g.Theta, 1e-4)
+		assert.InDelta(t, (price(s, ty, r+h, vol)-price(s, ty, r-h, vol))/(2*h)/100, g.Rho, 1e-4)
+	}
+}
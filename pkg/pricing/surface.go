@@ -0,0 +1,170 @@
+package pricing
+
+import (
+	"sort"
+	"time"
+)
+
+// DeltaBucket is a point of a smile quoted by the forward delta of the call, the put
+// buckets are at the complement of the call delta, e.g. the 25 delta put is at 0.75
+type DeltaBucket struct {
+	Name  string
+	Delta float64
+}
+
+var DeltaBuckets = []DeltaBucket{
+	{"10P", 0.9},
+	{"25P", 0.75},
+	{"ATM", 0.5},
+	{"25C", 0.25},
+	{"10C", 0.1},
+}
+
+// Quote is the best bid and ask of an option, 0 without one
+type Quote struct {
+	Option Option
+	Bid    float64
+	Ask    float64
+}
+
+// SmileStrike is the volatility of a strike of a smile, from its out of the money option
+type SmileStrike struct {
+	Strike float64
+	Call   bool
+	Vol    float64
+	BidVol float64
+	AskVol float64
+	Delta  float64
+}
+
+type SmileDelta struct {
+	Bucket string
+	Delta  float64
+	Vol    float64
+}
+
+// Smile is the volatility of an expiry by strike and by delta bucket
+type Smile struct {
+	Expiry  time.Time
+	Forward float64
+	Strikes []SmileStrike
+	Deltas  []SmileDelta
+}
+
+// ForwardDelta returns the undiscounted delta of the call of the strike
+func ForwardDelta(forward, strike, years, vol float64) float64 {
+	if forward <= 0 || strike <= 0 || years <= 0 || vol <= 0 {
+		return 0
+	}
+
+	d1, _ := d1d2(forward, strike, years, vol)
+	return normCdf(d1)
+}
+
+// NewSmile returns the smile of the quotes of the options of an expiry, the volatility of
+// a strike is the one of the mid of its out of the money option, or of the other option
+// when the out of the money one has no bid or no ask
+func NewSmile(quotes []Quote, index float64, now time.Time) Smile {
+	if len(quotes) == 0 {
+		return Smile{}
+	}
+
+	o := quotes[0].Option
+	smile := Smile{
+		Expiry:  o.Expiry,
+		Forward: o.Forward(index, now),
+	}
+	years := o.Years(now)
+
+	byStrike := make(map[float64][]SmileStrike)
+	for _, q := range quotes {
+		s := SmileStrike{Strike: q.Option.Strike, Call: q.Option.Call}
+		if q.Bid > 0 {
+			s.BidVol = q.Option.ImpliedVol(q.Bid, index, now)
+		}
+		if q.Ask > 0 {
+			s.AskVol = q.Option.ImpliedVol(q.Ask, index, now)
+		}
+		if q.Bid > 0 && q.Ask > 0 {
+			s.Vol = q.Option.ImpliedVol((q.Bid+q.Ask)/2, index, now)
+		}
+		s.Delta = ForwardDelta(smile.Forward, s.Strike, years, s.Vol)
+		byStrike[s.Strike] = append(byStrike[s.Strike], s)
+	}
+
+	for strike, options := range byStrike {
+		otm := strike >= smile.Forward
+		sort.Slice(options, func(i, j int) bool {
+			// the out of the money option first, then the one with a volatility
+			if (options[i].Call == otm) != (options[j].Call == otm) {
+				return options[i].Call == otm
+			}
+			return options[i].Vol > options[j].Vol
+		})
+
+		best := options[0]
+		if best.Vol == 0 {
+			for _, s := range options[1:] {
+				if s.Vol > 0 {
+					best = s
+					break
+				}
+			}
+		}
+		if best.Vol == 0 && best.BidVol == 0 && best.AskVol == 0 {
+			continue
+		}
+		smile.Strikes = append(smile.Strikes, best)
+	}
+	sort.Slice(smile.Strikes, func(i, j int) bool { return smile.Strikes[i].Strike < smile.Strikes[j].Strike })
+
+	for _, b := range DeltaBuckets {
+		if vol, ok := smileAtDelta(smile.Strikes, b.Delta); ok {
+			smile.Deltas = append(smile.Deltas, SmileDelta{b.Name, b.Delta, vol})
+		}
+	}
+
+	return smile
+}
+
+// ATM returns the volatility of the at the money bucket of the smile
+func (s Smile) ATM() (float64, bool) {
+	for _, d := range s.Deltas {
+		if d.Bucket == "ATM" {
+			return d.Vol, true
+		}
+	}
+
+	return 0, false
+}
+
+// smileAtDelta interpolates linearly the volatility of the strikes at the call forward
+// delta, false outside of the deltas of the strikes
+func smileAtDelta(strikes []SmileStrike, delta float64) (float64, bool) {
+	points := make([]SmileStrike, 0, len(strikes))
+	for _, s := range strikes {
+		if s.Vol > 0 {
+			points = append(points, s)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Delta < points[j].Delta })
+
+	if len(points) == 0 || delta < points[0].Delta || delta > points[len(points)-1].Delta {
+		return 0, false
+	}
+
+	for i := range points {
+		if points[i].Delta < delta {
+			continue
+		}
+		if i == 0 || points[i].Delta == delta {
+			return points[i].Vol, true
+		}
+
+		lo, hi := points[i-1], points[i]
+		w := (delta - lo.Delta) / (hi.Delta - lo.Delta)
+		return lo.Vol + w*(hi.Vol-lo.Vol), true
+	}
+
+	return 0, false
+}
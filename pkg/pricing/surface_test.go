@@ -0,0 +1,81 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func smileQuotes(now time.Time, index float64, vol func(strike float64) float64, strikes ...float64) []Quote {
+	quotes := []Quote{}
+	for _, strike := range strikes {
+		for _, call := range []bool{true, false} {
+			o := Option{Underlying: "BTC", Call: call, Strike: strike, Expiry: now.AddDate(0, 0, 90), Rate: 0.05}
+			price := o.Price(index, vol(strike), now)
+			quotes = append(quotes, Quote{Option: o, Bid: price, Ask: price})
+		}
+	}
+	return quotes
+}
+
+func TestForwardDelta(t *testing.T) {
+	assert.InDelta(t, normCdf(0.5*math.Sqrt(0.25)/2), ForwardDelta(100, 100, 0.25, 0.5), 1e-12)
+	assert.Greater(t, ForwardDelta(100, 80, 0.25, 0.5), ForwardDelta(100, 120, 0.25, 0.5))
+	assert.Equal(t, 0.0, ForwardDelta(100, 100, 0, 0.5))
+}
+
+func TestNewSmileFlat(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	flat := func(float64) float64 { return 0.6 }
+	quotes := smileQuotes(now, 20000, flat, 10000, 15000, 20000, 25000, 30000, 40000)
+
+	smile := NewSmile(quotes, 20000, now)
+	assert.Equal(t, now.AddDate(0, 0, 90), smile.Expiry)
+	assert.Len(t, smile.Strikes, 6)
+	for _, s := range smile.Strikes {
+		assert.InDelta(t, 0.6, s.Vol, 1e-6)
+		assert.Equal(t, s.Strike >= smile.Forward, s.Call, "strike %v", s.Strike)
+	}
+
+	assert.Len(t, smile.Deltas, len(DeltaBuckets))
+	for _, d := range smile.Deltas {
+		assert.InDelta(t, 0.6, d.Vol, 1e-6)
+	}
+}
+
+func TestNewSmileFallsBackToQuotedOption(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	flat := func(float64) float64 { return 0.6 }
+	quotes := smileQuotes(now, 20000, flat, 30000)
+
+	// the out of the money call has no ask
+	quotes[0].Ask = 0
+
+	smile := NewSmile(quotes, 20000, now)
+	assert.Len(t, smile.Strikes, 1)
+	assert.False(t, smile.Strikes[0].Call)
+	assert.InDelta(t, 0.6, smile.Strikes[0].Vol, 1e-6)
+}
+
+func TestSmileAtDelta(t *testing.T) {
+	strikes := []SmileStrike{
+		{Strike: 30000, Vol: 0.7, Delta: 0.2},
+		{Strike: 20000, Vol: 0.5, Delta: 0.6},
+		{Strike: 25000, Vol: 0, Delta: 0.4},
+	}
+
+	vol, ok := smileAtDelta(strikes, 0.3)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.65, vol, 1e-12)
+
+	vol, ok = smileAtDelta(strikes, 0.6)
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, vol)
+
+	_, ok = smileAtDelta(strikes, 0.1)
+	assert.False(t, ok)
+	_, ok = smileAtDelta(strikes, 0.9)
+	assert.False(t, ok)
+}
@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Point is a value at a time
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// RealizedVol returns the annualized standard deviation of the log returns of the
+// prices sampled periodsPerYear times a year, 0 without two returns
+func RealizedVol(prices []float64, periodsPerYear float64) float64 {
+	returns := make([]float64, 0, len(prices))
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] <= 0 || prices[i] <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(prices[i]/prices[i-1]))
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	return math.Sqrt(variance * periodsPerYear)
+}
+
+// HistoricalVol returns the realised volatility of the window of returns ending on every
+// step from the first full window, the prices are sorted by time and sampled on the
+// steps carrying the last price over the steps without one
+func HistoricalVol(prices []Point, step time.Duration, window int) []Point {
+	result := []Point{}
+	if len(prices) == 0 || window < 2 {
+		return result
+	}
+
+	// one price per step, the last one of the step
+	var times []time.Time
+	var sampled []float64
+	for _, p := range prices {
+		t := p.Time.Truncate(step)
+		if n := len(times); n > 0 {
+			if times[n-1].Equal(t) {
+				sampled[n-1] = p.Value
+				continue
+			}
+			for gap := times[n-1].Add(step); gap.Before(t); gap = gap.Add(step) {
+				times = append(times, gap)
+				sampled = append(sampled, sampled[len(sampled)-1])
+			}
+		}
+		times = append(times, t)
+		sampled = append(sampled, p.Value)
+	}
+
+	periodsPerYear := float64(365*24*time.Hour) / float64(step)
+	for i := window; i < len(sampled); i++ {
+		result = append(result, Point{
+			Time:  times[i],
+			Value: RealizedVol(sampled[i-window:i+1], periodsPerYear),
+		})
+	}
+
+	return result
+}
+
+// TermVol is the volatility of an expiry in years
+type TermVol struct {
+	Years float64
+	Vol   float64
+}
+
+// ConstantMaturityVol interpolates linearly the total variance of the terms at the
+// years, it is the volatility of the nearest term outside of them
+func ConstantMaturityVol(terms []TermVol, years float64) (float64, bool) {
+	points := make([]TermVol, 0, len(terms))
+	for _, t := range terms {
+		if t.Years > 0 && t.Vol > 0 {
+			points = append(points, t)
+		}
+	}
+	if len(points) == 0 || years <= 0 {
+		return 0, false
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Years < points[j].Years })
+
+	if years <= points[0].Years {
+		return points[0].Vol, true
+	}
+	last := points[len(points)-1]
+	if years >= last.Years {
+		return last.Vol, true
+	}
+
+	for i := 1; i < len(points); i++ {
+		if points[i].Years < years {
+			continue
+		}
+
+		lo, hi := points[i-1], points[i]
+		w := (years - lo.Years) / (hi.Years - lo.Years)
+		variance := lo.Vol*lo.Vol*lo.Years + w*(hi.Vol*hi.Vol*hi.Years-lo.Vol*lo.Vol*lo.Years)
+		if variance <= 0 {
+			return 0, false
+		}
+		return math.Sqrt(variance / years), true
+	}
+
+	return last.Vol, true
+}
@@ -0,0 +1,58 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealizedVol(t *testing.T) {
+	// alternating returns of +-1% have a sample standard deviation of 1% * sqrt(n/(n-1))
+	prices := []float64{100}
+	for i := 0; i < 100; i++ {
+		prices = append(prices, prices[len(prices)-1]*math.Exp(0.01*math.Pow(-1, float64(i))))
+	}
+
+	assert.InDelta(t, 0.01*math.Sqrt(100.0/99*365*24), RealizedVol(prices, 365*24), 1e-9)
+	assert.Equal(t, 0.0, RealizedVol(prices[:2], 365*24))
+	assert.Equal(t, 0.0, RealizedVol([]float64{100, 100, 100}, 365*24))
+}
+
+func TestHistoricalVol(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []Point{
+		{start, 100},
+		{start.Add(30 * time.Minute), 101},
+		{start.Add(time.Hour), 102},
+		// no price in the third hour
+		{start.Add(3 * time.Hour), 100},
+		{start.Add(4 * time.Hour), 103},
+	}
+
+	vols := HistoricalVol(prices, time.Hour, 2)
+	assert.Len(t, vols, 3)
+	assert.Equal(t, start.Add(2*time.Hour), vols[0].Time)
+	assert.InDelta(t, RealizedVol([]float64{101, 102, 102}, 365*24), vols[0].Value, 1e-12)
+	assert.InDelta(t, RealizedVol([]float64{102, 100, 103}, 365*24), vols[2].Value, 1e-12)
+
+	assert.Empty(t, HistoricalVol(prices[:2], time.Hour, 2))
+}
+
+func TestConstantMaturityVol(t *testing.T) {
+	terms := []TermVol{{Years: 0.5, Vol: 0.5}, {Years: 0.1, Vol: 0.8}, {Years: 0.2, Vol: 0}}
+
+	vol, ok := ConstantMaturityVol(terms, 0.3)
+	assert.True(t, ok)
+	w := 0.8*0.8*0.1 + (0.3-0.1)/(0.5-0.1)*(0.5*0.5*0.5-0.8*0.8*0.1)
+	assert.InDelta(t, math.Sqrt(w/0.3), vol, 1e-12)
+
+	vol, _ = ConstantMaturityVol(terms, 0.05)
+	assert.Equal(t, 0.8, vol)
+	vol, _ = ConstantMaturityVol(terms, 1)
+	assert.Equal(t, 0.5, vol)
+
+	_, ok = ConstantMaturityVol(nil, 0.3)
+	assert.False(t, ok)
+}
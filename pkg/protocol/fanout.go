@@ -0,0 +1,69 @@
+package protocol
+
+import (
+	"encoding/json"
+
+	"gateway/pkg/fanout"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+// FanoutResponse is the fan-out kind of the responses whose connection is not held by
+// the node which received the engine event
+const FanoutResponse = "protocol.response"
+
+type remoteResponse struct {
+	Key    string          `json:"key"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ErrorMessage   `json:"error,omitempty"`
+
+	// ErrorMessage does not serialize the http status code
+	HttpStatusCode int `json:"http_status_code,omitempty"`
+}
+
+func init() {
+	fanout.Register(FanoutResponse, onResponse)
+}
+
+func publishResponse(key string, result any, err *ErrorMessage) {
+	m := remoteResponse{Key: key, Error: err}
+
+	if result != nil {
+		data, e := json.Marshal(result)
+		if e != nil {
+			logs.Log.Error().Err(e).Str("connection_key", key).Msg("failed to marshal response")
+			return
+		}
+		m.Result = data
+	}
+
+	if err != nil {
+		m.HttpStatusCode = err.HttpStatusCode
+	}
+
+	fanout.Publish(FanoutResponse, m)
+}
+
+// onResponse answers the connection when this node holds it, the other nodes ignore the response
+func onResponse(payload json.RawMessage) {
+	var m remoteResponse
+	if err := json.Unmarshal(payload, &m); err != nil {
+		logs.Log.Error().Err(err).Msg("failed to parse response")
+		return
+	}
+
+	if !isConnExist(m.Key) {
+		return
+	}
+
+	var result any
+	if len(m.Result) > 0 {
+		result = m.Result
+	}
+
+	if m.Error != nil {
+		m.Error.HttpStatusCode = m.HttpStatusCode
+	}
+
+	send(m.Key, result, m.Error)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"gateway/pkg/collector"
 	"gateway/pkg/constant"
+	"gateway/pkg/fanout"
 	"gateway/pkg/utils"
 	"gateway/pkg/ws"
 	"net/http"
@@ -60,6 +61,7 @@ type ProtocolRequest struct {
 	RequestedTime uint64
 	WS            *ws.Client
 	Http          *gin.Context
+	Grpc          chan RPCResponseMessage
 	Method        string
 }
 
@@ -80,7 +82,9 @@ var timeoutMutex sync.RWMutex
 
 func (p *ProtocolRequest) getcollectorProtocol() collector.Protocol {
 	var protocol collector.Protocol
-	if p.WS != nil {
+	if p.Protocol == GRPC {
+		protocol = collector.GRPC
+	} else if p.WS != nil {
 		protocol = collector.WS
 	} else if p.Http != nil {
 		switch p.Http.Request.Method {
@@ -242,6 +246,17 @@ func SendErrMsg(key string, err error) bool {
 
 // Responsible for handling to send for different protocol
 func doSend(key string, result any, err *ErrorMessage) bool {
+	if !isConnExist(key) && fanout.Enabled() {
+		// the connection may be held by another gateway node
+		publishResponse(key, result, err)
+		return false
+	}
+
+	return send(key, result, err)
+}
+
+// send delivers the message to the connection held by this node
+func send(key string, result any, err *ErrorMessage) bool {
 	ok, conn := GetProtocol(key)
 	if !ok {
 		logs.Log.Error().Str("connection_key", key).Msg("no connection found")
@@ -300,7 +315,12 @@ func doSend(key string, result any, err *ErrorMessage) bool {
 
 		break
 	case GRPC:
-		// TODO: add grpc response
+		// the caller is waiting on the channel, never block the sender
+		select {
+		case conn.Grpc <- m:
+		default:
+			logs.Log.Warn().Str("connection_key", key).Msg("grpc response dropped")
+		}
 		break
 	case Channel:
 		resultMutex.Lock()
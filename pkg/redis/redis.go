@@ -87,3 +87,34 @@ func (p *RedisConnectionPool) GetValue(key string) (string, error) {
 
 	return value, nil
 }
+
+// Publish posts the message on the given pub/sub channel
+func (p *RedisConnectionPool) Publish(channel string, message []byte) error {
+	conn := p.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PUBLISH", channel, message)
+	return err
+}
+
+// Subscribe listens the given pub/sub channel and passes every message to the handler,
+// it blocks until the connection fails
+func (p *RedisConnectionPool) Subscribe(channel string, handler func(message []byte)) error {
+	conn := p.Get()
+	defer conn.Close()
+
+	psc := redis.PubSubConn{Conn: conn}
+	if err := psc.Subscribe(channel); err != nil {
+		return err
+	}
+	defer psc.Unsubscribe(channel)
+
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			handler(v.Data)
+		case error:
+			return v
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package trigger
+
+import "strings"
+
+// the prices a trigger order can follow
+const (
+	IndexPrice = "index_price"
+	MarkPrice  = "mark_price"
+	LastPrice  = "last_price"
+)
+
+// the order types held by the gateway until their trigger price is reached
+const (
+	StopLimit  = "stop_limit"
+	StopMarket = "stop_market"
+	TakeLimit  = "take_limit"
+	TakeMarket = "take_market"
+	// a stop market order whose trigger price follows the price at the trigger offset
+	TrailingStop = "trailing_stop"
+)
+
+// IsTriggerType returns whether the order type is a trigger order type
+func IsTriggerType(orderType string) bool {
+	switch orderType {
+	case StopLimit, StopMarket, TakeLimit, TakeMarket, TrailingStop:
+		return true
+	}
+
+	return false
+}
+
+// IsSource returns whether the trigger is a price a trigger order can follow
+func IsSource(trigger string) bool {
+	switch trigger {
+	case IndexPrice, MarkPrice, LastPrice:
+		return true
+	}
+
+	return false
+}
+
+// Released returns the type of the order sent to the engine once the trigger order of
+// the type is triggered, limit or market
+func Released(orderType string) string {
+	if strings.HasSuffix(orderType, "_market") || orderType == TrailingStop {
+		return "market"
+	}
+
+	return "limit"
+}
+
+// IsLimit returns whether the trigger order of the type is released as a limit order
+func IsLimit(orderType string) bool {
+	return Released(orderType) == "limit"
+}
+
+// Above returns whether the trigger order fires when the price rises to its trigger
+// price, a stop buy and a take profit sell, or when it falls to it otherwise
+func Above(orderType string, buy bool) bool {
+	stop := !strings.HasPrefix(orderType, "take_")
+	return stop == buy
+}
+
+// IsTrailingSource returns whether the trigger is a price a trailing order can follow
+func IsTrailingSource(trigger string) bool {
+	return trigger == IndexPrice || trigger == MarkPrice
+}
+
+// Trail returns the trigger price of the trailing order at the offset of the price, it
+// only moves toward the price: the one of a sell rises with the price and the one of a
+// buy falls with it. A trigger price of 0 is not set yet
+func Trail(above bool, triggerPrice, offset, price float64) float64 {
+	if price <= 0 {
+		return triggerPrice
+	}
+
+	if above {
+		level := price + offset
+		if triggerPrice <= 0 || level < triggerPrice {
+			return level
+		}
+		return triggerPrice
+	}
+
+	level := price - offset
+	if level > triggerPrice {
+		return level
+	}
+
+	return triggerPrice
+}
+
+// ValidOffset returns whether the trailing order at the offset gets a trigger price above
+// 0 at the price, the offset must be below the price. Any offset is valid without a price
+func ValidOffset(offset, price float64) bool {
+	return offset > 0 && (price <= 0 || offset < price)
+}
+
+// Reached returns whether the price reached the trigger price in the direction
+func Reached(above bool, triggerPrice, price float64) bool {
+	if price <= 0 {
+		return false
+	}
+	if above {
+		return price >= triggerPrice
+	}
+
+	return price <= triggerPrice
+}
+
+// MatchesType returns whether the trigger order type is selected by the order type
+// filter of the open orders, all, trigger_all, stop_all, take_all, trailing_all or a type
+func MatchesType(filter, orderType string) bool {
+	switch filter {
+	case "", "all", "trigger_all":
+		return true
+	case "stop_all":
+		return strings.HasPrefix(orderType, "stop_")
+	case "take_all":
+		return strings.HasPrefix(orderType, "take_")
+	case "trailing_all":
+		return orderType == TrailingStop
+	}
+
+	return filter == orderType
+}
@@ -0,0 +1,75 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbove(t *testing.T) {
+	assert.True(t, Above(StopLimit, true))
+	assert.False(t, Above(StopMarket, false))
+	assert.False(t, Above(TakeLimit, true))
+	assert.True(t, Above(TakeMarket, false))
+	assert.True(t, Above(TrailingStop, true))
+	assert.False(t, Above(TrailingStop, false))
+}
+
+func TestTrail(t *testing.T) {
+	// a sell stop is set below the price and only rises
+	assert.Equal(t, 90.0, Trail(false, 0, 10, 100))
+	assert.Equal(t, 100.0, Trail(false, 90, 10, 110))
+	assert.Equal(t, 100.0, Trail(false, 100, 10, 105))
+
+	// a buy stop is set above the price and only falls
+	assert.Equal(t, 110.0, Trail(true, 0, 10, 100))
+	assert.Equal(t, 100.0, Trail(true, 110, 10, 90))
+	assert.Equal(t, 100.0, Trail(true, 100, 10, 95))
+
+	// without a price the trigger price stays
+	assert.Equal(t, 90.0, Trail(false, 90, 10, 0))
+}
+
+func TestReached(t *testing.T) {
+	assert.True(t, Reached(true, 100, 100))
+	assert.True(t, Reached(true, 100, 101))
+	assert.False(t, Reached(true, 100, 99))
+	assert.True(t, Reached(false, 100, 99))
+	assert.False(t, Reached(false, 100, 101))
+	assert.False(t, Reached(false, 100, 0))
+}
+
+func TestReleased(t *testing.T) {
+	assert.Equal(t, "limit", Released(StopLimit))
+	assert.Equal(t, "market", Released(StopMarket))
+	assert.Equal(t, "limit", Released(TakeLimit))
+	assert.Equal(t, "market", Released(TakeMarket))
+	assert.Equal(t, "market", Released(TrailingStop))
+	assert.False(t, IsTriggerType("limit"))
+	assert.True(t, IsSource(MarkPrice))
+	assert.False(t, IsSource("bid_price"))
+	assert.True(t, IsTrailingSource(IndexPrice))
+	assert.False(t, IsTrailingSource(LastPrice))
+}
+
+func TestMatchesType(t *testing.T) {
+	assert.True(t, MatchesType("all", StopLimit))
+	assert.True(t, MatchesType("trigger_all", TakeMarket))
+	assert.True(t, MatchesType("stop_all", StopMarket))
+	assert.False(t, MatchesType("stop_all", TakeLimit))
+	assert.False(t, MatchesType("stop_all", TrailingStop))
+	assert.True(t, MatchesType("trailing_all", TrailingStop))
+	assert.True(t, MatchesType("take_all", TakeLimit))
+	assert.True(t, MatchesType(StopLimit, StopLimit))
+	assert.False(t, MatchesType("limit", StopLimit))
+}
+
+func TestValidOffset(t *testing.T) {
+	assert.True(t, ValidOffset(10, 100))
+	assert.False(t, ValidOffset(100, 100))
+	assert.False(t, ValidOffset(150, 100))
+	assert.False(t, ValidOffset(0, 100))
+
+	// without a price the offset is checked against the next one
+	assert.True(t, ValidOffset(150, 0))
+}
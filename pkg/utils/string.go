@@ -4,11 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"gateway/pkg/constant"
+	"gateway/pkg/pricing"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/Undercurrent-Technologies/kprime-utilities/commons/date"
 	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
 	_instrumentTypes "github.com/Undercurrent-Technologies/kprime-utilities/config/types"
 	"github.com/Undercurrent-Technologies/kprime-utilities/types"
@@ -48,32 +48,11 @@ type Instruments struct {
 	Strike              float64
 }
 
+// isExpired returns whether the expiry time of the expiry date is reached, an invalid
+// date is expired
 func isExpired(expDate string) bool {
-	now := time.Now()
-	loc, _ := time.LoadLocation("Singapore")
-	if loc != nil {
-		now = now.In(loc)
-	}
-
-	cy, cm, cd := now.Date()
-	ey, em, ed := date.ParseDate(expDate)
-	if ey < cy {
-		return true
-	}
-
-	if ey == cy {
-		if em < cm {
-			return true
-		}
-
-		if em == cm {
-			if ed < cd {
-				return true
-			}
-		}
-	}
-
-	return false
+	at, err := pricing.Expiry(expDate)
+	return err != nil || !time.Now().Before(at)
 }
 
 func ParseInstruments(str string, checkExpired bool) (*Instruments, error) {
@@ -121,6 +100,41 @@ func ParseInstruments(str string, checkExpired bool) (*Instruments, error) {
 	return &Instruments{_underlying, _expDate, _contracts, strike}, nil
 }
 
+// Name returns the canonical name of the instrument, e.g. BTC-28JAN22-50000-C
+func (i Instruments) Name() string {
+	return i.Underlying + "-" + i.ExpDate + "-" + fmt.Sprintf("%.0f", i.Strike) + "-" + string(i.Contracts[0])
+}
+
+// ParseCurrency validates the currency of the options of an underlying, e.g. BTC
+func ParseCurrency(currency string) (string, error) {
+	underlying := strings.ToUpper(currency)
+	if _instrumentTypes.Underlying(underlying).IsValidUnderlying() == false {
+		return "", errors.New(constant.UNSUPPORTED_CURRENCY)
+	}
+
+	return underlying, nil
+}
+
+// ParseExpiry validates the currency and the expiry date of the options of an expiry,
+// e.g. BTC and 28JAN22
+func ParseExpiry(currency, expiry string, checkExpired bool) (underlying, expDate string, err error) {
+	underlying, err = ParseCurrency(currency)
+	if err != nil {
+		return "", "", err
+	}
+
+	expDate = strings.ToUpper(expiry)
+	if _, err := time.Parse("02Jan06", expDate); err != nil {
+		return "", "", errors.New(constant.INVALID_EXPIRY_DATE)
+	}
+
+	if checkExpired && isExpired(expDate) {
+		return "", "", errors.New(constant.EXPIRED_INSTRUMENT)
+	}
+
+	return underlying, expDate, nil
+}
+
 func ConvertToFloat(str string) (number float64, isSuccess bool) {
 	conversion, err := strconv.ParseFloat(str, 32)
 	if err != nil {
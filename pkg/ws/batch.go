@@ -0,0 +1,215 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"gateway/pkg/constant"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/types/validation_reason"
+)
+
+// Batch holds the responses of a JSON-RPC batch request until every request is answered
+type Batch struct {
+	mu        sync.Mutex
+	ids       []uint64
+	responses map[uint64]WebsocketResponseMessage
+	rejected  []WebsocketResponseMessage
+	pending   int
+	done      chan struct{}
+
+	limitOnce sync.Once
+	allowed   bool
+}
+
+func newBatch() *Batch {
+	return &Batch{
+		responses: make(map[uint64]WebsocketResponseMessage),
+		done:      make(chan struct{}),
+	}
+}
+
+// Allow runs the rate limiter check once, the whole batch is charged as a single request
+func (b *Batch) Allow(check func() bool) bool {
+	b.limitOnce.Do(func() {
+		b.allowed = check()
+	})
+
+	return b.allowed
+}
+
+func (b *Batch) has(id uint64) bool {
+	for _, v := range b.ids {
+		if v == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *Batch) add(id uint64) {
+	b.ids = append(b.ids, id)
+	b.pending++
+}
+
+func (b *Batch) reject(m WebsocketResponseMessage) {
+	b.rejected = append(b.rejected, m)
+}
+
+func (b *Batch) set(m WebsocketResponseMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.responses[m.ID]; ok {
+		return
+	}
+
+	b.responses[m.ID] = m
+	b.pending--
+	if b.pending == 0 {
+		close(b.done)
+	}
+}
+
+// Responses returns the responses in the order of the requests
+func (b *Batch) Responses() []WebsocketResponseMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := make([]WebsocketResponseMessage, 0, len(b.ids)+len(b.rejected))
+	for _, id := range b.ids {
+		res = append(res, b.responses[id])
+	}
+
+	return append(res, b.rejected...)
+}
+
+// IsBatchPayload reports whether the payload is a JSON-RPC batch (array) request
+func IsBatchPayload(payload []byte) bool {
+	trimmed := bytes.TrimSpace(payload)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+func dispatchBatch(c *Client, msgs []WebsocketMessage) {
+	b := newBatch()
+	valid := []WebsocketMessage{}
+
+	for _, msg := range msgs {
+		switch {
+		case msg.ID == nil:
+			b.reject(WebsocketResponseMessage{JSONRPC: "2.0", Result: "INVALID_REQUEST"})
+		case socketChannels[msg.Method] == nil:
+			b.reject(WebsocketResponseMessage{JSONRPC: "2.0", ID: *msg.ID, Result: "INVALID_CHANNEL"})
+		case b.has(*msg.ID):
+			reason := validation_reason.DUPLICATED_REQUEST_ID
+			code, _, codeStr := reason.Code()
+			b.reject(WebsocketResponseMessage{
+				JSONRPC: "2.0",
+				ID:      *msg.ID,
+				Error: WebsocketResponseErrMessage{
+					Message: reason.String(),
+					Data:    ReasonMessage{Reason: codeStr},
+					Code:    code,
+				},
+				Testnet: true,
+			})
+		default:
+			b.add(*msg.ID)
+			msg.Batch = b
+			valid = append(valid, msg)
+		}
+	}
+
+	if len(valid) == 0 {
+		c.sendBatch(b)
+		return
+	}
+
+	c.batchMu.Lock()
+	for _, msg := range valid {
+		c.batches[*msg.ID] = b
+	}
+	c.batchMu.Unlock()
+
+	for _, msg := range valid {
+		go socketChannels[msg.Method](msg, c)
+	}
+
+	go c.waitBatch(b)
+}
+
+// collectBatch keeps the response when it answers a pending batch request
+func (c *Client) collectBatch(m WebsocketResponseMessage) bool {
+	// subscription notifications are never part of a batch
+	if m.Method != "" || m.ID == 0 {
+		return false
+	}
+
+	c.batchMu.Lock()
+	b, ok := c.batches[m.ID]
+	if ok {
+		delete(c.batches, m.ID)
+	}
+	c.batchMu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	b.set(m)
+	return true
+}
+
+func (c *Client) waitBatch(b *Batch) {
+	timer := time.NewTimer(constant.TIMEOUT)
+	defer timer.Stop()
+
+	select {
+	case <-b.done:
+	case <-timer.C:
+		reason := validation_reason.TIME_OUT
+		code, _, codeStr := reason.Code()
+
+		c.batchMu.Lock()
+		for _, id := range b.ids {
+			if c.batches[id] == b {
+				delete(c.batches, id)
+			}
+		}
+		c.batchMu.Unlock()
+
+		for _, id := range b.ids {
+			b.set(WebsocketResponseMessage{
+				JSONRPC: "2.0",
+				ID:      id,
+				Error: WebsocketResponseErrMessage{
+					Message: reason.String(),
+					Data:    ReasonMessage{Reason: codeStr},
+					Code:    code,
+				},
+				Testnet: true,
+			})
+		}
+	}
+
+	c.sendBatch(b)
+}
+
+func (c *Client) sendBatch(b *Batch) {
+	select {
+	case c.batchOut <- b.Responses():
+	case <-time.After(writeWait):
+	}
+}
+
+func unmarshalBatch(payload []byte) ([]WebsocketMessage, error) {
+	msgs := []WebsocketMessage{}
+	if err := json.Unmarshal(payload, &msgs); err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
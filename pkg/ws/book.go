@@ -97,6 +97,13 @@ func (s *BookSocket) Unsubscribe(c *Client) {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *BookSocket) BroadcastMessage(channelID string, method string, p interface{}) error {
+	publishBroadcast(bookSocket, channelID, method, p)
+
+	return s.broadcastMessage(channelID, method, p)
+}
+
+// broadcastMessage streams message to the subscribtions held by this node
+func (s *BookSocket) broadcastMessage(channelID string, method string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -109,6 +116,20 @@ func (s *BookSocket) BroadcastMessage(channelID string, method string, p interfa
 	return nil
 }
 
+// BroadcastLocalMessage streams message to the subscribtions held by this node only,
+// for the data every node publishes itself
+func (s *BookSocket) BroadcastLocalMessage(channelID string, method string, p interface{}) error {
+	return s.broadcastMessage(channelID, method, p)
+}
+
+// HasSubscriptions reports whether a connection of this node is subscribed to the channel
+func (s *BookSocket) HasSubscriptions(channelID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscriptions[channelID]) > 0
+}
+
 // SendErrorMessage sends error message on orderbookchannel
 func (s *BookSocket) SendErrorMessage(c *Client, data interface{}) {
 	c.SendMessage(data, SendMessageParams{})
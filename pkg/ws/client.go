@@ -15,11 +15,15 @@ const OrderbookChannel = "order_book"
 // const OrderChannel = "orders"
 
 type AuthedClient struct {
-	IsAuthed bool   `json:"is_authed"`
-	UserID   string `json:"user_id"`
+	IsAuthed  bool      `json:"is_authed"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	expiry *time.Timer
 }
 
-var authedConnections map[Client]AuthedClient
+var authedConnections map[*Client]AuthedClient
+var authedMutex sync.RWMutex
 
 type Client struct {
 	*websocket.Conn
@@ -27,6 +31,10 @@ type Client struct {
 	send          chan WebsocketResponseMessage
 	EnableCancel  bool
 	ConnectionKey string
+
+	batchMu  sync.Mutex
+	batches  map[uint64]*Batch
+	batchOut chan []WebsocketResponseMessage
 }
 
 type SendMessageParams struct {
@@ -40,6 +48,9 @@ type WebsocketMessage struct {
 	Method  string      `json:"method"`
 	ID      *uint64     `json:"id,omitempty"`
 	Params  interface{} `json:"params"`
+
+	// Batch is set when the message is part of a JSON-RPC batch request
+	Batch *Batch `json:"-"`
 }
 
 type WebsocketResponseMessage struct {
@@ -80,29 +91,73 @@ var registerRequestRpcIdsMutex sync.Mutex
 // To Validate rps id-s and return usIn,usOut,usDiff
 var orderRequestRpcIDS map[string]uint64
 
-func (c *Client) RegisterAuthedConnection(userID string) {
+// RegisterAuthedConnection binds the connection to the user until the token expires,
+// private requests on the connection may omit the access_token afterwards
+func (c *Client) RegisterAuthedConnection(userID string, expiresIn time.Duration) {
+	authedMutex.Lock()
+	defer authedMutex.Unlock()
+
 	if authedConnections == nil {
-		authedConnections = make(map[Client]AuthedClient)
+		authedConnections = make(map[*Client]AuthedClient)
 	}
-	authedConnections[*c] = AuthedClient{
-		IsAuthed: true,
-		UserID:   userID,
+
+	if authed, ok := authedConnections[c]; ok && authed.expiry != nil {
+		authed.expiry.Stop()
+	}
+
+	authedConnections[c] = AuthedClient{
+		IsAuthed:  true,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(expiresIn),
+		expiry:    time.AfterFunc(expiresIn, c.expireAuthedConnection),
 	}
 }
 
 func (c *Client) UnregisterAuthedConnection() {
+	authedMutex.Lock()
+	defer authedMutex.Unlock()
+
 	if authedConnections != nil {
-		delete(authedConnections, *c)
+		if authed, ok := authedConnections[c]; ok && authed.expiry != nil {
+			authed.expiry.Stop()
+		}
+		delete(authedConnections, c)
 	}
 }
 
 func (c *Client) IsAuthed() (bool, string) {
-	if authedClient, ok := authedConnections[*c]; ok {
+	authedMutex.RLock()
+	defer authedMutex.RUnlock()
+
+	if authedClient, ok := authedConnections[c]; ok && time.Now().Before(authedClient.ExpiresAt) {
 		return authedClient.IsAuthed, authedClient.UserID
 	}
 	return false, ""
 }
 
+// expireAuthedConnection drops the privileges of the connection and notifies the client
+func (c *Client) expireAuthedConnection() {
+	authedMutex.Lock()
+	authed, ok := authedConnections[c]
+	if ok {
+		delete(authedConnections, c)
+	}
+	authedMutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	reason := validation_reason.UNAUTHORIZED
+	_, _, codeStr := reason.Code()
+
+	c.SendMessageSubcription(map[string]interface{}{
+		"user_id": authed.UserID,
+		"message": "token is expired",
+		"reason":  codeStr,
+	}, "auth/expired", SendMessageParams{})
+}
+
 func (c *Client) RegisterRequestRpcIDS(id string, requestedTime uint64) (bool, string) {
 	registerRequestRpcIdsMutex.Lock()
 	defer registerRequestRpcIdsMutex.Unlock()
@@ -127,7 +182,14 @@ func (c *Client) RegisterRequestRpcIDS(id string, requestedTime uint64) (bool, s
 func NewClient(c *websocket.Conn) *Client {
 	subscriptionMutex.Lock()
 	defer subscriptionMutex.Unlock()
-	conn := &Client{Conn: c, mu: sync.Mutex{}, send: make(chan WebsocketResponseMessage), EnableCancel: false}
+	conn := &Client{
+		Conn:         c,
+		mu:           sync.Mutex{},
+		send:         make(chan WebsocketResponseMessage),
+		EnableCancel: false,
+		batches:      make(map[uint64]*Batch),
+		batchOut:     make(chan []WebsocketResponseMessage),
+	}
 
 	if unsubscribeHandlers == nil {
 		unsubscribeHandlers = make(map[*Client][]func(*Client))
@@ -140,6 +202,32 @@ func NewClient(c *websocket.Conn) *Client {
 	return conn
 }
 
+// NewStreamClient creates a client that is not backed by a websocket connection,
+// messages sent to it are read from Messages (used by the grpc streams)
+func NewStreamClient() *Client {
+	subscriptionMutex.Lock()
+	defer subscriptionMutex.Unlock()
+	conn := &Client{mu: sync.Mutex{}, send: make(chan WebsocketResponseMessage, 256), EnableCancel: false}
+
+	if unsubscribeHandlers == nil {
+		unsubscribeHandlers = make(map[*Client][]func(*Client))
+	}
+
+	unsubscribeHandlers[conn] = make([]func(*Client), 0)
+
+	return conn
+}
+
+// Messages returns the outgoing messages of the client
+func (c *Client) Messages() <-chan WebsocketResponseMessage {
+	return c.send
+}
+
+// CloseStream runs the unsubscribe handlers of a stream client
+func (c *Client) CloseStream() {
+	c.closeConnection()
+}
+
 func (c *Client) Send(message WebsocketResponseMessage) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -268,7 +356,9 @@ func (c *Client) closeConnection() {
 	}
 	c.UnregisterAuthedConnection()
 
-	c.Close()
+	if c.Conn != nil {
+		c.Close()
+	}
 }
 
 func (c *Client) SendOrderErrorMessage(err error) {
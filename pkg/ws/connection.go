@@ -1,18 +1,19 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
 
 	"gateway/internal/deribit/model"
-	"gateway/pkg/kafka/producer"
 
 	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
 	"github.com/Undercurrent-Technologies/kprime-utilities/types"
 	"github.com/gin-gonic/gin"
 
 	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
@@ -84,6 +85,21 @@ func readHandler(c *Client) {
 		msg := WebsocketMessage{}
 		if string(payload) == "PING" {
 			c.SendMessage("PONG", SendMessageParams{})
+		} else if IsBatchPayload(payload) {
+			msgs, err := unmarshalBatch(payload)
+			if err != nil {
+				logs.Log.Error().Err(err).Msg("")
+
+				c.SendMessage(err.Error(), SendMessageParams{})
+				return
+			}
+
+			if len(msgs) == 0 {
+				c.SendMessage("INVALID_REQUEST", SendMessageParams{})
+				continue
+			}
+
+			dispatchBatch(c, msgs)
 		} else {
 			if err := json.Unmarshal(payload, &msg); err != nil {
 				logs.Log.Error().Err(err).Msg("")
@@ -131,11 +147,23 @@ func writeHandler(c *Client) {
 				c.WriteMessage(websocket.CloseMessage, []byte{})
 			}
 
+			// batch responses are written together once every request is answered
+			if c.collectBatch(m) {
+				continue
+			}
+
 			err := c.WriteJSON(m)
 			if err != nil {
 				logs.Log.Error().Err(err).Msg("")
 				return
 			}
+		case res := <-c.batchOut:
+			c.SetWriteDeadline(time.Now().Add(writeWait))
+			err := c.WriteJSON(res)
+			if err != nil {
+				logs.Log.Error().Err(err).Msg("")
+				return
+			}
 		}
 	}
 }
@@ -158,10 +186,23 @@ func RegisterConnectionUnsubscribeHandler(c *Client, fn func(*Client)) {
 	subscriptionMutex.Unlock()
 }
 
+// Outbox writes the order commands before they are sent to kafka
+type Outbox interface {
+	Publish(ctx context.Context, topic, requestId, userId, clOrdId string, payload []byte) error
+}
+
+var cancelOutbox Outbox
+
+// SetupOutbox sets the outbox the cancels on disconnect are sent through
+func SetupOutbox(outbox Outbox) {
+	cancelOutbox = outbox
+}
+
 func PublishCancelAll(connkey string) {
 	payload := model.DeribitCancelAllByConnectionId{
 		Side:         string(types.CANCEL_ALL_BY_CONNECTION_ID),
 		ConnectionId: connkey,
+		RequestID:    primitive.NewObjectID().Hex(),
 	}
 	out, err := json.Marshal(payload)
 	if err != nil {
@@ -169,5 +210,9 @@ func PublishCancelAll(connkey string) {
 
 		return
 	}
-	producer.KafkaProducer(string(out), "NEW_ORDER")
+
+	// the relay sends the cancel once kafka is back
+	if err := cancelOutbox.Publish(context.Background(), types.NEW_ORDER.String(), payload.RequestID, "", "", out); err != nil {
+		logs.Log.Error().Err(err).Str("connection_id", connkey).Msg("failed to publish cancel on disconnect")
+	}
 }
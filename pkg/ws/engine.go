@@ -97,6 +97,13 @@ func (s *EngineSocket) Unsubscribe(c *Client) {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *EngineSocket) BroadcastMessage(channelID string, p interface{}) error {
+	publishBroadcast(engineSocket, channelID, "", p)
+
+	return s.broadcastMessage(channelID, p)
+}
+
+// broadcastMessage streams message to the subscribtions held by this node
+func (s *EngineSocket) broadcastMessage(channelID string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -111,6 +118,13 @@ func (s *EngineSocket) BroadcastMessage(channelID string, p interface{}) error {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *EngineSocket) BroadcastMessageSubcription(channelID string, method string, p interface{}) error {
+	publishBroadcast(engineSocket, channelID, method, p)
+
+	return s.broadcastMessageSubcription(channelID, method, p)
+}
+
+// broadcastMessageSubcription streams message to the subscribtions held by this node
+func (s *EngineSocket) broadcastMessageSubcription(channelID string, method string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -0,0 +1,97 @@
+package ws
+
+import (
+	"encoding/json"
+
+	"gateway/pkg/fanout"
+
+	"github.com/Undercurrent-Technologies/kprime-utilities/commons/logs"
+)
+
+// FanoutBroadcast is the fan-out kind of the socket broadcasts, every node
+// delivers the broadcast to the subscribtions it holds
+const FanoutBroadcast = "ws.broadcast"
+
+const (
+	bookSocket      = "book"
+	priceSocket     = "price"
+	quoteSocket     = "quote"
+	engineSocket    = "engine"
+	orderBookSocket = "orderbook"
+	orderSocket     = "order"
+	tradeSocket     = "trade"
+)
+
+type broadcastMessage struct {
+	Socket  string          `json:"socket"`
+	Channel string          `json:"channel"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params"`
+}
+
+func init() {
+	fanout.Register(FanoutBroadcast, onBroadcast)
+}
+
+func publishBroadcast(socket, channelID, method string, p interface{}) {
+	if !fanout.Enabled() {
+		return
+	}
+
+	params, err := json.Marshal(p)
+	if err != nil {
+		logs.Log.Error().Err(err).Str("channel", channelID).Msg("failed to marshal broadcast")
+		return
+	}
+
+	fanout.Publish(FanoutBroadcast, broadcastMessage{
+		Socket:  socket,
+		Channel: channelID,
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// onBroadcast delivers a broadcast published by another node, it never publishes again
+func onBroadcast(payload json.RawMessage) {
+	var m broadcastMessage
+	if err := json.Unmarshal(payload, &m); err != nil {
+		logs.Log.Error().Err(err).Msg("failed to parse broadcast")
+		return
+	}
+
+	switch m.Socket {
+	case bookSocket:
+		GetBookSocket().broadcastMessage(m.Channel, m.Method, m.Params)
+	case priceSocket:
+		GetPriceSocket().broadcastMessage(m.Channel, m.Method, m.Params)
+	case quoteSocket:
+		GetQuoteSocket().broadcastMessage(m.Channel, m.Method, m.Params)
+	case engineSocket:
+		if m.Method == "" {
+			GetEngineSocket().broadcastMessage(m.Channel, m.Params)
+		} else {
+			GetEngineSocket().broadcastMessageSubcription(m.Channel, m.Method, m.Params)
+		}
+	case orderBookSocket:
+		if m.Method == "" {
+			GetOrderBookSocket().broadcastMessage(m.Channel, m.Params)
+		} else {
+			GetOrderBookSocket().broadcastMessageSubcription(m.Channel, m.Method, m.Params)
+		}
+	case orderSocket:
+		if m.Method == "" {
+			GetOrderSocket().broadcastMessage(m.Channel, m.Params)
+		} else {
+			GetOrderSocket().broadcastMessageOrder(m.Channel, m.Method, m.Params)
+		}
+	case tradeSocket:
+		if m.Method == "" {
+			GetTradeSocket().broadcastMessage(m.Channel, m.Params)
+		} else {
+			GetTradeSocket().broadcastMessageTrade(m.Channel, m.Method, m.Params)
+		}
+	default:
+		logs.Log.Warn().Str("socket", m.Socket).Msg("unknown broadcast socket")
+	}
+}
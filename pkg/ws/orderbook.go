@@ -97,6 +97,13 @@ func (s *OrderBookSocket) Unsubscribe(c *Client) {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *OrderBookSocket) BroadcastMessage(channelID string, p interface{}) error {
+	publishBroadcast(orderBookSocket, channelID, "", p)
+
+	return s.broadcastMessage(channelID, p)
+}
+
+// broadcastMessage streams message to the subscribtions held by this node
+func (s *OrderBookSocket) broadcastMessage(channelID string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -111,6 +118,13 @@ func (s *OrderBookSocket) BroadcastMessage(channelID string, p interface{}) erro
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *OrderBookSocket) BroadcastMessageSubcription(channelID string, method string, p interface{}) error {
+	publishBroadcast(orderBookSocket, channelID, method, p)
+
+	return s.broadcastMessageSubcription(channelID, method, p)
+}
+
+// broadcastMessageSubcription streams message to the subscribtions held by this node
+func (s *OrderBookSocket) broadcastMessageSubcription(channelID string, method string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -97,6 +97,13 @@ func (s *PriceSocket) Unsubscribe(c *Client) {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *PriceSocket) BroadcastMessage(channelID string, method string, p interface{}) error {
+	publishBroadcast(priceSocket, channelID, method, p)
+
+	return s.broadcastMessage(channelID, method, p)
+}
+
+// broadcastMessage streams message to the subscribtions held by this node
+func (s *PriceSocket) broadcastMessage(channelID string, method string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -97,6 +97,13 @@ func (s *QuoteSocket) Unsubscribe(c *Client) {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *QuoteSocket) BroadcastMessage(channelID string, method string, p interface{}) error {
+	publishBroadcast(quoteSocket, channelID, method, p)
+
+	return s.broadcastMessage(channelID, method, p)
+}
+
+// broadcastMessage streams message to the subscribtions held by this node
+func (s *QuoteSocket) broadcastMessage(channelID string, method string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
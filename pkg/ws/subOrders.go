@@ -97,6 +97,13 @@ func (s *OrderSocket) Unsubscribe(c *Client) {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *OrderSocket) BroadcastMessage(channelID string, p interface{}) error {
+	publishBroadcast(orderSocket, channelID, "", p)
+
+	return s.broadcastMessage(channelID, p)
+}
+
+// broadcastMessage streams message to the subscribtions held by this node
+func (s *OrderSocket) broadcastMessage(channelID string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -111,6 +118,13 @@ func (s *OrderSocket) BroadcastMessage(channelID string, p interface{}) error {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *OrderSocket) BroadcastMessageOrder(channelID string, method string, p interface{}) error {
+	publishBroadcast(orderSocket, channelID, method, p)
+
+	return s.broadcastMessageOrder(channelID, method, p)
+}
+
+// broadcastMessageOrder streams message to the subscribtions held by this node
+func (s *OrderSocket) broadcastMessageOrder(channelID string, method string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -97,6 +97,13 @@ func (s *TradeSocket) Unsubscribe(c *Client) {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *TradeSocket) BroadcastMessage(channelID string, p interface{}) error {
+	publishBroadcast(tradeSocket, channelID, "", p)
+
+	return s.broadcastMessage(channelID, p)
+}
+
+// broadcastMessage streams message to the subscribtions held by this node
+func (s *TradeSocket) broadcastMessage(channelID string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -111,6 +118,13 @@ func (s *TradeSocket) BroadcastMessage(channelID string, p interface{}) error {
 
 // BroadcastMessage streams message to all the subscribtions subscribed to the pair
 func (s *TradeSocket) BroadcastMessageTrade(channelID string, method string, p interface{}) error {
+	publishBroadcast(tradeSocket, channelID, method, p)
+
+	return s.broadcastMessageTrade(channelID, method, p)
+}
+
+// broadcastMessageTrade streams message to the subscribtions held by this node
+func (s *TradeSocket) broadcastMessageTrade(channelID string, method string, p interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -123,6 +137,20 @@ func (s *TradeSocket) BroadcastMessageTrade(channelID string, method string, p i
 	return nil
 }
 
+// BroadcastLocalMessageTrade streams message to the subscribtions held by this node only,
+// for the data every node publishes itself
+func (s *TradeSocket) BroadcastLocalMessageTrade(channelID string, method string, p interface{}) error {
+	return s.broadcastMessageTrade(channelID, method, p)
+}
+
+// HasSubscriptions reports whether a connection of this node is subscribed to the channel
+func (s *TradeSocket) HasSubscriptions(channelID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscriptions[channelID]) > 0
+}
+
 // SendErrorMessage sends error message on orderbookchannel
 func (s *TradeSocket) SendErrorMessage(c *Client, data interface{}) {
 	c.SendMessage(data, SendMessageParams{})